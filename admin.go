@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go-multirole/config"
+	"go-multirole/db"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/repo"
+	"go-multirole/usecase"
+	"go-multirole/utils"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const adminUsage = `usage: go-multirole <command>
+
+commands:
+  user create <username> [email]          create a user, reading the password from stdin
+  user assign-role <user> <role>          assign a role to a user
+  user revoke-role <user> <role>          revoke a role from a user
+  user check <user> <permission>          exit with 0 if the user has the permission, 1 otherwise
+  user permissions <user>                 list the permissions the user's roles grant
+  user import [flags] <file>              create the users of a CSV or JSON lines file, or -
+                                          for stdin, exiting with 1 if any row fails
+      -format csv|jsonl                   file format, guessed from the extension by default
+      -mode transaction|per_row           create every user or none (default), or each valid one
+      -password provided|generate|invite  use the file's passwords (default), generate and print
+                                          them, or invite users to choose one by email
+  role create <name>                      create a role
+  role grant <role> <permission>          grant a permission to a role
+  role revoke <role> <permission>         revoke a permission from a role
+  permission create <name>                create a permission
+  policy plan <file>                      show how the database differs from a YAML or JSON
+                                          policy file, exiting with 2 if it does
+  policy apply <file>                     make the database match the policy file, deleting
+                                          roles and permissions it doesn't declare
+
+Users are given by username or email address.`
+
+// admin runs the RBAC commands of the CLI through the same use cases and
+// request validation as the API.
+type admin struct {
+	users       domain.UserUseCase
+	userImports domain.UserImportUseCase
+	roles       domain.RoleUseCase
+	permissions domain.PermissionUseCase
+	policies    domain.PolicyUseCase
+}
+
+type adminCommand struct {
+	minArgs, maxArgs int
+	run              func(a *admin, ctx context.Context, args []string) error
+}
+
+var adminCommands = map[string]adminCommand{
+	"user create":       {1, 2, (*admin).createUser},
+	"user assign-role":  {2, 2, (*admin).assignRole},
+	"user revoke-role":  {2, 2, (*admin).revokeRole},
+	"user check":        {2, 2, (*admin).checkPermission},
+	"user permissions":  {1, 1, (*admin).listPermissions},
+	"user import":       {1, 7, (*admin).importUsers},
+	"role create":       {1, 1, (*admin).createRole},
+	"role grant":        {2, 2, (*admin).grantPermission},
+	"role revoke":       {2, 2, (*admin).revokePermission},
+	"permission create": {1, 1, (*admin).createPermission},
+	"policy plan":       {1, 1, (*admin).planPolicy},
+	"policy apply":      {1, 1, (*admin).applyPolicy},
+}
+
+// exitStatus ends a command with the status without logging an error, for
+// results scripts check rather than failures.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// runAdmin runs a user, role or permission command against the configured database.
+func runAdmin(loadConfig config.Config, args []string) {
+	if len(args) < 2 {
+		log.Fatal(adminUsage)
+	}
+	command, ok := adminCommands[args[0]+" "+args[1]]
+	if !ok || len(args)-2 < command.minArgs || len(args)-2 > command.maxArgs {
+		log.Fatal(adminUsage)
+	}
+
+	a, err := newAdmin(loadConfig)
+	if err != nil {
+		log.Fatal("🚀 ", err)
+	}
+	// Interrupting the command cancels its queries
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := command.run(a, ctx, args[2:]); err != nil {
+		var status exitStatus
+		if errors.As(err, &status) {
+			os.Exit(int(status))
+		}
+		var domainErr *domain.Error
+		if errors.As(err, &domainErr) {
+			for _, field := range domainErr.Fields {
+				log.Printf("%s %s", field.Name, field.Reason)
+			}
+		}
+		log.Fatal(err)
+	}
+}
+
+func newAdmin(loadConfig config.Config) (*admin, error) {
+	passwordHasher, err := newPasswordHasher(loadConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure password hashing: %w", err)
+	}
+	userNotifier, err := newNotifier(loadConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure notifications: %w", err)
+	}
+
+	conn := db.InitDB(&loadConfig)
+	loginThrottleUseCase := usecase.NewLoginThrottleUseCase(repo.NewLoginThrottleRepository(conn), loadConfig.LoginMaxAttempts, loadConfig.LoginIPMaxAttempts, loadConfig.LoginLockoutDuration, loadConfig.LoginThrottleDelay)
+	unitOfWork := repo.NewUnitOfWork(conn, passwordHasher)
+	userRepo := repo.NewUserRepository(conn, passwordHasher)
+	roleRepo := repo.NewRoleRepository(conn)
+	permissionRepo := repo.NewPermissionRepository(conn)
+	userUseCase := usecase.NewUserUseCase(userRepo, unitOfWork, loginThrottleUseCase, passwordHasher, newPasswordPolicy(loadConfig), utils.NewTokenService(loadConfig.TokenSecret, loadConfig.TokenExpiresIn), userNotifier, loadConfig.PasswordResetTTL, loadConfig.PasswordResetURL)
+	return &admin{
+		users:       userUseCase,
+		userImports: usecase.NewUserImportUseCase(repo.NewUserImportRepository(conn, passwordHasher), roleRepo, userUseCase, userNotifier, loadConfig.InvitationTTL, loadConfig.InvitationURL),
+		roles:       usecase.NewRoleUseCase(roleRepo, unitOfWork),
+		permissions: usecase.NewPermissionUseCase(permissionRepo),
+		policies:    usecase.NewPolicyUseCase(repo.NewPolicyRepository(conn), roleRepo, permissionRepo),
+	}, nil
+}
+
+// createUser reads the password from stdin rather than the arguments, which
+// other users of the machine can see.
+func (a *admin) createUser(ctx context.Context, args []string) error {
+	request := model.CreateUserRequest{Username: args[0]}
+	if len(args) == 2 {
+		request.Email = args[1]
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("reading the password: %w", err)
+	}
+	request.Password = strings.TrimRight(password, "\r\n")
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return err
+	}
+
+	user, err := a.users.CreateUser(ctx, request.User())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %s (id %d)\n", user.Username, user.ID)
+	return nil
+}
+
+func (a *admin) assignRole(ctx context.Context, args []string) error {
+	user, role, err := a.userAndRole(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if err := a.users.AssignRoleToUser(ctx, user.ID, role.ID); err != nil {
+		return err
+	}
+	fmt.Printf("assigned role %s to %s\n", role.Name, user.Username)
+	return nil
+}
+
+func (a *admin) revokeRole(ctx context.Context, args []string) error {
+	user, role, err := a.userAndRole(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if err := a.users.RevokeRoleFromUser(ctx, user.ID, role.ID); err != nil {
+		return err
+	}
+	fmt.Printf("revoked role %s from %s\n", role.Name, user.Username)
+	return nil
+}
+
+func (a *admin) checkPermission(ctx context.Context, args []string) error {
+	user, err := a.users.FindUserByIdentifier(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	allowed, err := a.users.CheckUserPermission(ctx, user.ID, args[1])
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		fmt.Printf("%s does not have %s\n", user.Username, args[1])
+		return exitStatus(1)
+	}
+	fmt.Printf("%s has %s\n", user.Username, args[1])
+	return nil
+}
+
+func (a *admin) listPermissions(ctx context.Context, args []string) error {
+	user, err := a.users.FindUserByIdentifier(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	permissions, err := a.users.ListUserPermissions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		fmt.Println(permission.Name)
+	}
+	return nil
+}
+
+// importUsers prints the result of every row. Generated passwords are only
+// shown here, so keep the output safe.
+func (a *admin) importUsers(ctx context.Context, args []string) error {
+	var options model.UserImportOptions
+	flags := flag.NewFlagSet("user import", flag.ContinueOnError)
+	flags.StringVar(&options.Format, "format", "", "csv or jsonl")
+	flags.StringVar(&options.Mode, "mode", model.UserImportModeTransaction, "transaction or per_row")
+	flags.StringVar(&options.Password, "password", model.UserImportPasswordProvided, "provided, generate or invite")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(adminUsage)
+	}
+	if err := binding.Validator.ValidateStruct(options); err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	if options.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jsonl", ".ndjson":
+			options.Format = model.UserImportFormatJSONL
+		default:
+			options.Format = model.UserImportFormatCSV
+		}
+	}
+	rows, err := model.ParseUserImport(input, options.Format)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	report, err := a.userImports.Import(ctx, rows, options, 0)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tUSERNAME\tSTATUS\tDETAILS")
+	for _, row := range report.Rows {
+		details := row.Password
+		if row.Error != "" {
+			details = row.Error
+		}
+		for _, field := range row.Fields {
+			details += fmt.Sprintf("; %s %s", field.Name, field.Reason)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", row.Line, row.Username, row.Status, details)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("created %d, invited %d, failed %d, skipped %d\n", report.Created, report.Invited, report.Failed, report.Skipped)
+	if report.Failed > 0 {
+		return exitStatus(1)
+	}
+	return nil
+}
+
+func (a *admin) createRole(ctx context.Context, args []string) error {
+	request := model.CreateRoleRequest{Name: args[0]}
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return err
+	}
+	role, err := a.roles.CreateRole(ctx, request.Role())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created role %s (id %d)\n", role.Name, role.ID)
+	return nil
+}
+
+func (a *admin) grantPermission(ctx context.Context, args []string) error {
+	role, permission, err := a.roleAndPermission(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if err := a.roles.AssignPermissionToRole(ctx, role.ID, permission.ID); err != nil {
+		return err
+	}
+	fmt.Printf("granted %s to role %s\n", permission.Name, role.Name)
+	return nil
+}
+
+func (a *admin) revokePermission(ctx context.Context, args []string) error {
+	role, permission, err := a.roleAndPermission(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if err := a.roles.RevokePermissionFromRole(ctx, role.ID, permission.ID); err != nil {
+		return err
+	}
+	fmt.Printf("revoked %s from role %s\n", permission.Name, role.Name)
+	return nil
+}
+
+func (a *admin) createPermission(ctx context.Context, args []string) error {
+	request := model.CreatePermissionRequest{Name: args[0]}
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return err
+	}
+	permission, err := a.permissions.CreatePermission(ctx, request.Permission())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created permission %s (id %d)\n", permission.Name, permission.ID)
+	return nil
+}
+
+func (a *admin) planPolicy(ctx context.Context, args []string) error {
+	policy, err := readPolicy(args[0])
+	if err != nil {
+		return err
+	}
+	plan, err := a.policies.Plan(ctx, policy)
+	if err != nil {
+		return err
+	}
+	if plan.Empty() {
+		fmt.Printf("no changes, the database matches %s\n", args[0])
+		return nil
+	}
+	for _, change := range plan.Changes() {
+		fmt.Println(change)
+	}
+	return exitStatus(2)
+}
+
+func (a *admin) applyPolicy(ctx context.Context, args []string) error {
+	policy, err := readPolicy(args[0])
+	if err != nil {
+		return err
+	}
+	plan, err := a.policies.Apply(ctx, policy)
+	if err != nil {
+		return err
+	}
+	changes := plan.Changes()
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	fmt.Printf("applied %d changes\n", len(changes))
+	return nil
+}
+
+func readPolicy(path string) (model.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.Policy{}, err
+	}
+	policy, err := model.ParsePolicy(data)
+	if err != nil {
+		return model.Policy{}, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+func (a *admin) userAndRole(ctx context.Context, identifier string, roleName string) (model.User, model.Role, error) {
+	user, err := a.users.FindUserByIdentifier(ctx, identifier)
+	if err != nil {
+		return model.User{}, model.Role{}, err
+	}
+	role, err := a.roles.FindRoleByName(ctx, roleName)
+	return user, role, err
+}
+
+func (a *admin) roleAndPermission(ctx context.Context, roleName string, permissionName string) (model.Role, model.Permission, error) {
+	role, err := a.roles.FindRoleByName(ctx, roleName)
+	if err != nil {
+		return model.Role{}, model.Permission{}, err
+	}
+	permission, err := a.permissions.FindPermissionByName(ctx, permissionName)
+	return role, permission, err
+}
@@ -1,22 +1,101 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+const (
+	// minTokenSecretLength is the shortest TOKEN_SECRET accepted for signing tokens.
+	minTokenSecretLength = 16
+	// maxTokenTTL bounds the lifetime of access tokens.
+	maxTokenTTL = 30 * 24 * time.Hour
+)
+
 type Config struct {
-	// MySQL Setup
+	// Database Setup
+	DBDriver   string `mapstructure:"SQL_DRIVER"` // "mysql", "postgres" or "sqlite"
 	DBHost     string `mapstructure:"SQL_HOST"`
 	DBUsername string `mapstructure:"SQL_USER"`
-	DBPassword string `mapstructure:"SQL_PASSWORD"`
-	DBName     string `mapstructure:"SQL_DB"`
+	DBPassword string `mapstructure:"SQL_PASSWORD" redact:"true"`
+	DBName     string `mapstructure:"SQL_DB"` // The database file for sqlite
 	DBPort     string `mapstructure:"SQL_PORT"`
+	DBSSLMode  string `mapstructure:"SQL_SSL_MODE"` // Used by postgres, e.g. "disable" or "require"
+
+	// Apply pending migrations on start rather than refusing to start
+	DBMigrateOnStart bool `mapstructure:"SQL_MIGRATE_ON_START"`
+
+	TokenSecret          string        `mapstructure:"TOKEN_SECRET" reload:"hot" redact:"true"`
+	TokenPreviousSecrets []string      `mapstructure:"TOKEN_PREVIOUS_SECRETS" reload:"hot" redact:"true"` // Comma separated, still accepted for tokens signed before a rotation
+	TokenExpiresIn       time.Duration `mapstructure:"TOKEN_EXPIRED_IN" reload:"hot"`
+	TokenMaxAge          int           `mapstructure:"TOKEN_MAXAGE"`
+
+	OAuthTokenExpiresIn time.Duration `mapstructure:"OAUTH_TOKEN_EXPIRED_IN" reload:"hot"`
+
+	// OpenID Connect
+	OIDCIssuer         string `mapstructure:"OIDC_ISSUER"`
+	OIDCSigningKeyFile string `mapstructure:"OIDC_SIGNING_KEY_FILE"`
+
+	// Password policy
+	PasswordMinLength         int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordRequireUppercase  bool   `mapstructure:"PASSWORD_REQUIRE_UPPERCASE"`
+	PasswordRequireLowercase  bool   `mapstructure:"PASSWORD_REQUIRE_LOWERCASE"`
+	PasswordRequireDigit      bool   `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol     bool   `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordDisallowUsername  bool   `mapstructure:"PASSWORD_DISALLOW_USERNAME"`
+	PasswordHistorySize       int    `mapstructure:"PASSWORD_HISTORY_SIZE"`
+	PasswordBreachedRangesDir string `mapstructure:"PASSWORD_BREACHED_RANGES_DIR"` // Pwned Passwords style range files, empty disables the check
+
+	// Password hashing, existing hashes are upgraded on login
+	PasswordHashAlgorithm    string `mapstructure:"PASSWORD_HASH_ALGORITHM"` // "argon2id" or "bcrypt"
+	PasswordArgon2Memory     uint32 `mapstructure:"PASSWORD_ARGON2_MEMORY"`  // KiB
+	PasswordArgon2Iterations uint32 `mapstructure:"PASSWORD_ARGON2_ITERATIONS"`
+	PasswordArgon2Threads    uint8  `mapstructure:"PASSWORD_ARGON2_PARALLELISM"`
+	PasswordBcryptCost       int    `mapstructure:"PASSWORD_BCRYPT_COST"`
+
+	// Password reset
+	PasswordResetTTL time.Duration `mapstructure:"PASSWORD_RESET_TTL"`
+	PasswordResetURL string        `mapstructure:"PASSWORD_RESET_URL"` // Page the reset token is appended to
+
+	// Invitations
+	InvitationTTL time.Duration `mapstructure:"INVITATION_TTL"`
+	InvitationURL string        `mapstructure:"INVITATION_URL"` // Page the invitation token is appended to
+
+	// Notifications
+	Notifier        string `mapstructure:"NOTIFIER"`          // "smtp" or "log"
+	NotifierLogFile string `mapstructure:"NOTIFIER_LOG_FILE"` // Used by the log notifier, empty writes to the process log
+	SMTPHost        string `mapstructure:"SMTP_HOST"`
+	SMTPPort        string `mapstructure:"SMTP_PORT"`
+	SMTPUsername    string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword    string `mapstructure:"SMTP_PASSWORD" redact:"true"`
+	SMTPFrom        string `mapstructure:"SMTP_FROM"`
+
+	// Login throttling, which also limits password reset requests
+	LoginMaxAttempts     int           `mapstructure:"LOGIN_MAX_ATTEMPTS" reload:"hot"`    // Failures before an account is locked
+	LoginIPMaxAttempts   int           `mapstructure:"LOGIN_IP_MAX_ATTEMPTS" reload:"hot"` // Failures before a client IP is locked
+	LoginLockoutDuration time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION" reload:"hot"`
+	LoginThrottleDelay   time.Duration `mapstructure:"LOGIN_THROTTLE_DELAY" reload:"hot"` // First delay, doubled after every failure
+
+	// Multi-factor authentication
+	MFAIssuer string `mapstructure:"MFA_ISSUER"` // Account label shown in authenticator apps
+
+	// Webhook delivery
+	WebhookMaxAttempts      int           `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookRetryBackoff     time.Duration `mapstructure:"WEBHOOK_RETRY_BACKOFF"`
+	WebhookDispatchInterval time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
 
-	TokenSecret    string        `mapstructure:"TOKEN_SECRET"`
-	TokenExpiresIn time.Duration `mapstructure:"TOKEN_EXPIRED_IN"`
-	TokenMaxAge    int           `mapstructure:"TOKEN_MAXAGE"`
+	// Proxies whose X-Forwarded-For header gives the client IP, as addresses or
+	// CIDRs separated by commas. Empty trusts none, so clients can't pick the
+	// IP the login throttle counts against.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	// Logging
+	LogLevel string `mapstructure:"LOG_LEVEL" reload:"hot"` // "debug", "info", "warn" or "error", requests are logged at info
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -34,3 +113,56 @@ func LoadConfig(path string) (config Config, err error) {
 	err = viper.Unmarshal(&config)
 	return
 }
+
+// Validate reports every setting the server can't run with, so a bad app.env
+// fails at startup rather than on the first request that needs it.
+func (c Config) Validate() error {
+	var problems []error
+	if c.TokenSecret == "" {
+		problems = append(problems, errors.New("TOKEN_SECRET is required"))
+	} else if len(c.TokenSecret) < minTokenSecretLength {
+		problems = append(problems, fmt.Errorf("TOKEN_SECRET must be at least %d characters", minTokenSecretLength))
+	}
+	for i, secret := range c.TokenPreviousSecrets {
+		if len(secret) < minTokenSecretLength {
+			problems = append(problems, fmt.Errorf("TOKEN_PREVIOUS_SECRETS entry %d must be at least %d characters", i+1, minTokenSecretLength))
+		}
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problems = append(problems, fmt.Errorf("TRUSTED_PROXIES entry %q is neither an IP address nor a CIDR", proxy))
+		}
+	}
+	if _, err := c.Level(); err != nil {
+		problems = append(problems, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	problems = append(problems,
+		checkTTL("TOKEN_EXPIRED_IN", c.TokenExpiresIn, maxTokenTTL),
+		checkTTL("OAUTH_TOKEN_EXPIRED_IN", c.OAuthTokenExpiresIn, maxTokenTTL),
+		checkTTL("PASSWORD_RESET_TTL", c.PasswordResetTTL, 0),
+		checkTTL("INVITATION_TTL", c.InvitationTTL, 0),
+		checkTTL("WEBHOOK_DISPATCH_INTERVAL", c.WebhookDispatchInterval, 0),
+	)
+	return errors.Join(problems...)
+}
+
+// Level parses LOG_LEVEL, which defaults to info.
+func (c Config) Level() (slog.Level, error) {
+	var level slog.Level
+	if c.LogLevel == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	return level, err
+}
+
+// checkTTL requires a positive duration, at most max unless max is 0.
+func checkTTL(name string, ttl time.Duration, max time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("%s must be a positive duration such as 30m, got %s", name, ttl)
+	}
+	if max > 0 && ttl > max {
+		return fmt.Errorf("%s must be at most %s, got %s", name, max, ttl)
+	}
+	return nil
+}
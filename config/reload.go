@@ -0,0 +1,133 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// redacted replaces the value of the settings tagged redact when shown.
+const redacted = "[redacted]"
+
+// Live is the effective configuration of the running service. Settings tagged
+// reload:"hot" are swapped in when the config file changes, the others keep
+// their value until the next restart.
+type Live struct {
+	current atomic.Pointer[Config]
+	pending atomic.Pointer[[]string]
+	apply   func(Config)
+	mu      sync.Mutex // Serializes reloads
+}
+
+// NewLive calls apply with config, and again with the effective config after
+// every reload.
+func NewLive(config Config, apply func(Config)) *Live {
+	l := &Live{apply: apply}
+	l.current.Store(&config)
+	l.pending.Store(&[]string{})
+	apply(config)
+	return l
+}
+
+// Current returns the effective configuration.
+func (l *Live) Current() Config {
+	return *l.current.Load()
+}
+
+// PendingRestart lists the settings changed since the start that only take
+// effect after a restart.
+func (l *Live) PendingRestart() []string {
+	return *l.pending.Load()
+}
+
+// Reload swaps in the hot settings of next and returns the settings that
+// changed but need a restart. An invalid next is rejected as a whole.
+func (l *Live) Reload(next Config) ([]string, error) {
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	reloaded, pending := l.Current().merge(next)
+	l.current.Store(&reloaded)
+	l.pending.Store(&pending)
+	l.apply(reloaded)
+	return pending, nil
+}
+
+// Watch reloads the configuration whenever the config file read by
+// LoadConfig changes. Environment variables still override the file, but
+// they're only read from the environment of the process, so changing them
+// needs a restart.
+func (l *Live) Watch(logger *slog.Logger) {
+	viper.OnConfigChange(func(event fsnotify.Event) {
+		var next Config
+		if err := viper.Unmarshal(&next); err != nil {
+			logger.Error("configuration not reloaded", "file", event.Name, "error", err)
+			return
+		}
+		pending, err := l.Reload(next)
+		if err != nil {
+			logger.Error("configuration not reloaded", "file", event.Name, "error", err)
+			return
+		}
+		logger.Info("configuration reloaded", "file", event.Name)
+		if len(pending) > 0 {
+			logger.Warn("configuration changes need a restart", "settings", pending)
+		}
+	})
+	viper.WatchConfig()
+}
+
+// merge returns c with the hot settings of next, and the names of the other
+// settings that differ between them.
+func (c Config) merge(next Config) (Config, []string) {
+	merged := c
+	target := reflect.ValueOf(&merged).Elem()
+	current, incoming := reflect.ValueOf(c), reflect.ValueOf(next)
+	pending := []string{}
+	for i := 0; i < current.NumField(); i++ {
+		field := current.Type().Field(i)
+		switch {
+		case field.Tag.Get("reload") == "hot":
+			target.Field(i).Set(incoming.Field(i))
+		case !reflect.DeepEqual(current.Field(i).Interface(), incoming.Field(i).Interface()):
+			pending = append(pending, field.Tag.Get("mapstructure"))
+		}
+	}
+	return merged, pending
+}
+
+// Redacted returns the settings by name for display, with durations in
+// their app.env form and the secrets that are set masked.
+func (c Config) Redacted() map[string]interface{} {
+	settings := map[string]interface{}{}
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		setting := value.Field(i).Interface()
+		switch {
+		case field.Tag.Get("redact") == "true":
+			if isSet(value.Field(i)) {
+				setting = redacted
+			}
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			setting = setting.(time.Duration).String()
+		}
+		settings[field.Tag.Get("mapstructure")] = setting
+	}
+	return settings
+}
+
+func isSet(value reflect.Value) bool {
+	if value.Kind() == reflect.Slice {
+		return value.Len() > 0
+	}
+	return !value.IsZero()
+}
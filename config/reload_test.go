@@ -0,0 +1,101 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a configuration Validate accepts.
+func validConfig() Config {
+	return Config{
+		DBDriver:                "sqlite",
+		DBPassword:              "db-password",
+		TokenSecret:             "a-long-enough-secret",
+		TokenExpiresIn:          time.Hour,
+		OAuthTokenExpiresIn:     time.Hour,
+		PasswordResetTTL:        30 * time.Minute,
+		InvitationTTL:           72 * time.Hour,
+		LoginMaxAttempts:        5,
+		WebhookDispatchInterval: time.Minute,
+		LogLevel:                "info",
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := validConfig()
+	next := current
+	next.TokenSecret = "another-long-secret"
+	next.LoginMaxAttempts = 3
+	next.LogLevel = "debug"
+	next.DBDriver = "postgres"
+	next.TrustedProxies = []string{"10.0.0.0/8"}
+
+	merged, pending := current.merge(next)
+
+	// Hot settings are swapped in, the others keep their value until a restart
+	assert.Equal(t, "another-long-secret", merged.TokenSecret)
+	assert.Equal(t, 3, merged.LoginMaxAttempts)
+	assert.Equal(t, "debug", merged.LogLevel)
+	assert.Equal(t, "sqlite", merged.DBDriver)
+	assert.Nil(t, merged.TrustedProxies)
+	assert.Equal(t, []string{"SQL_DRIVER", "TRUSTED_PROXIES"}, pending)
+
+	_, pending = current.merge(current)
+	assert.Empty(t, pending, "Nothing is pending when nothing changed")
+}
+
+func TestRedacted(t *testing.T) {
+	config := validConfig()
+	config.TokenPreviousSecrets = []string{"an-old-long-secret"}
+
+	settings := config.Redacted()
+
+	assert.Equal(t, redacted, settings["TOKEN_SECRET"])
+	assert.Equal(t, redacted, settings["TOKEN_PREVIOUS_SECRETS"], "Slices of secrets are masked as a whole")
+	assert.Equal(t, redacted, settings["SQL_PASSWORD"])
+	assert.Equal(t, "", settings["SMTP_PASSWORD"], "Secrets that aren't set are shown empty")
+	assert.Equal(t, "1h0m0s", settings["TOKEN_EXPIRED_IN"])
+	assert.Equal(t, "sqlite", settings["SQL_DRIVER"])
+
+	config.TokenPreviousSecrets = []string{}
+	assert.Equal(t, []string{}, config.Redacted()["TOKEN_PREVIOUS_SECRETS"], "An empty list isn't masked")
+}
+
+func TestLiveReload(t *testing.T) {
+	var applied []Config
+	live := NewLive(validConfig(), func(c Config) { applied = append(applied, c) })
+	require.Len(t, applied, 1, "The initial config is applied")
+
+	t.Run("Applies the hot settings", func(t *testing.T) {
+		next := validConfig()
+		next.LogLevel = "warn"
+		next.DBName = "other.db"
+
+		pending, err := live.Reload(next)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"SQL_DB"}, pending)
+		assert.Equal(t, pending, live.PendingRestart())
+		assert.Equal(t, "warn", live.Current().LogLevel)
+		assert.Equal(t, "", live.Current().DBName)
+		assert.Len(t, applied, 2)
+	})
+
+	t.Run("Rejects an invalid config as a whole", func(t *testing.T) {
+		next := validConfig()
+		next.LogLevel = "debug"
+		next.TokenSecret = "short"
+
+		pending, err := live.Reload(next)
+
+		assert.ErrorContains(t, err, "TOKEN_SECRET must be at least 16 characters")
+		assert.Nil(t, pending)
+		assert.Equal(t, "warn", live.Current().LogLevel, "No setting of a rejected config is applied")
+		assert.Equal(t, "a-long-enough-secret", live.Current().TokenSecret)
+		assert.Equal(t, []string{"SQL_DB"}, live.PendingRestart())
+		assert.Len(t, applied, 2)
+	})
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"go-multirole/config"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConfigController struct {
+	live *config.Live
+}
+
+func NewConfigController(live *config.Live) *ConfigController {
+	return &ConfigController{live}
+}
+
+func (d *ConfigController) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Get config success",
+		Data: model.EffectiveConfig{
+			Settings:       d.live.Current().Redacted(),
+			PendingRestart: d.live.PendingRestart(),
+		},
+	})
+}
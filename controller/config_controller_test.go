@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"go-multirole/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func validTestConfig() config.Config {
+	return config.Config{
+		DBName:                  "rbac.db",
+		TokenSecret:             "current-secret-value",
+		TokenExpiresIn:          time.Hour,
+		OAuthTokenExpiresIn:     time.Hour,
+		PasswordResetTTL:        30 * time.Minute,
+		InvitationTTL:           72 * time.Hour,
+		WebhookDispatchInterval: 5 * time.Second,
+	}
+}
+
+func TestConfigController(t *testing.T) {
+	var applied []config.Config
+	live := config.NewLive(validTestConfig(), func(c config.Config) { applied = append(applied, c) })
+	configController := NewConfigController(live)
+
+	t.Run("Get the redacted config", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/config", nil)
+
+		handle(c, configController.GetConfig)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"TOKEN_SECRET":"[redacted]"`)
+		assert.Contains(t, w.Body.String(), `"TOKEN_EXPIRED_IN":"1h0m0s"`)
+		assert.Contains(t, w.Body.String(), `"SQL_PASSWORD":""`, "Unset secrets are shown as unset")
+		assert.NotContains(t, w.Body.String(), "current-secret-value")
+	})
+
+	t.Run("Get the config after a reload", func(t *testing.T) {
+		next := validTestConfig()
+		next.TokenSecret = "rotated-secret-value"
+		next.TokenPreviousSecrets = []string{"current-secret-value"}
+		next.TokenExpiresIn = 15 * time.Minute
+		next.DBName = "other.db"
+		pending, err := live.Reload(next)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"SQL_DB"}, pending)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/config", nil)
+
+		handle(c, configController.GetConfig)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"TOKEN_EXPIRED_IN":"15m0s"`)
+		assert.Contains(t, w.Body.String(), `"SQL_DB":"rbac.db"`, "Structural settings keep their value")
+		assert.Contains(t, w.Body.String(), `"pending_restart":["SQL_DB"]`)
+		if assert.Len(t, applied, 2) {
+			assert.Equal(t, "rotated-secret-value", applied[1].TokenSecret)
+		}
+	})
+
+	t.Run("Reject an invalid reload", func(t *testing.T) {
+		next := validTestConfig()
+		next.TokenSecret = ""
+
+		_, err := live.Reload(next)
+
+		assert.EqualError(t, err, "TOKEN_SECRET is required")
+		assert.Equal(t, "rotated-secret-value", live.Current().TokenSecret)
+		assert.Len(t, applied, 2)
+	})
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvitationController struct {
+	invitationUseCase domain.InvitationUseCase
+}
+
+func NewInvitationController(invitationUseCase domain.InvitationUseCase) *InvitationController {
+	return &InvitationController{invitationUseCase}
+}
+
+func (d *InvitationController) Invite(c *gin.Context) {
+	var request model.InvitationRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	inviterID := c.MustGet("currentUserId").(uint)
+	invitation, err := d.invitationUseCase.Invite(c.Request.Context(), request, inviterID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.Response{
+		StatusCode: http.StatusCreated,
+		Message:    "Invitation sent",
+		Data:       invitation,
+	})
+}
+
+func (d *InvitationController) ListInvitations(c *gin.Context) {
+	invitations, err := d.invitationUseCase.ListInvitations(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "List invitations success",
+		Data:       invitations,
+	})
+}
+
+func (d *InvitationController) Resend(c *gin.Context) {
+	invitationID, ok := paramID(c, "invitationID")
+	if !ok {
+		return
+	}
+
+	invitation, err := d.invitationUseCase.Resend(c.Request.Context(), invitationID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Invitation resent",
+		Data:       invitation,
+	})
+}
+
+func (d *InvitationController) Revoke(c *gin.Context) {
+	invitationID, ok := paramID(c, "invitationID")
+	if !ok {
+		return
+	}
+
+	if err := d.invitationUseCase.Revoke(c.Request.Context(), invitationID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Invitation revoked",
+	})
+}
+
+// Accept is called by the invitee with the token from the invitation.
+func (d *InvitationController) Accept(c *gin.Context) {
+	var request model.AcceptInvitationRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if err := d.invitationUseCase.Accept(c.Request.Context(), request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Invitation accepted, log in with your new password",
+	})
+}
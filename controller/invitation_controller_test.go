@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockInvitationUseCase is a mock implementation of the InvitationUseCase interface
+type MockInvitationUseCase struct {
+	mock.Mock
+}
+
+func (m *MockInvitationUseCase) Invite(ctx context.Context, request model.InvitationRequest, inviterID uint) (model.Invitation, error) {
+	args := m.Called(request, inviterID)
+	return args.Get(0).(model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationUseCase) ListInvitations(ctx context.Context) ([]model.Invitation, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationUseCase) Resend(ctx context.Context, invitationID uint) (model.Invitation, error) {
+	args := m.Called(invitationID)
+	return args.Get(0).(model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationUseCase) Revoke(ctx context.Context, invitationID uint) error {
+	args := m.Called(invitationID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationUseCase) Accept(ctx context.Context, request model.AcceptInvitationRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func TestInvite(t *testing.T) {
+	mockUseCase := new(MockInvitationUseCase)
+	invitationController := NewInvitationController(mockUseCase)
+
+	t.Run("Invite a user with roles", func(t *testing.T) {
+		request := model.InvitationRequest{Username: "jane_doe", Email: "jane@example.com", RoleIDs: []uint{2}}
+		mockUseCase.On("Invite", request, uint(1)).Return(model.Invitation{ID: 4, Username: "jane_doe", Status: model.InvitationPending}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(`{"username":"jane_doe", "email":"jane@example.com", "role_ids":[2]}`))
+
+		handle(c, invitationController.Invite)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"pending"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Require a valid email", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(1))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(`{"username":"jane_doe", "email":"not-an-email"}`))
+
+		handle(c, invitationController.Invite)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	mockUseCase := new(MockInvitationUseCase)
+	invitationController := NewInvitationController(mockUseCase)
+
+	t.Run("Accept with a password", func(t *testing.T) {
+		mockUseCase.On("Accept", model.AcceptInvitationRequest{Token: "invite-token", Password: "Chosen-Password-1"}).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/invitations/accept", bytes.NewBufferString(`{"token":"invite-token", "password":"Chosen-Password-1"}`))
+
+		handle(c, invitationController.Accept)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid tokens return 400", func(t *testing.T) {
+		mockUseCase.On("Accept", model.AcceptInvitationRequest{Token: "expired", Password: "Chosen-Password-1"}).Return(domain.ErrInvalidInvitation).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/invitations/accept", bytes.NewBufferString(`{"token":"expired", "password":"Chosen-Password-1"}`))
+
+		handle(c, invitationController.Accept)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid or expired invitation")
+		mockUseCase.AssertExpectations(t)
+	})
+}
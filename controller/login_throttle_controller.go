@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginThrottleController struct {
+	loginThrottleUseCase domain.LoginThrottleUseCase
+}
+
+func NewLoginThrottleController(loginThrottleUseCase domain.LoginThrottleUseCase) *LoginThrottleController {
+	return &LoginThrottleController{loginThrottleUseCase}
+}
+
+func (d *LoginThrottleController) ListLocked(c *gin.Context) {
+	throttles, err := d.loginThrottleUseCase.ListLocked(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "List lockouts success",
+		Data:       throttles,
+	})
+}
+
+func (d *LoginThrottleController) UnlockUser(c *gin.Context) {
+	username := c.Param("username")
+
+	if err := d.loginThrottleUseCase.UnlockUser(c.Request.Context(), username); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Unlocked user success",
+	})
+}
+
+func (d *LoginThrottleController) UnlockIP(c *gin.Context) {
+	clientIP := c.Param("ip")
+
+	if err := d.loginThrottleUseCase.UnlockIP(c.Request.Context(), clientIP); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Unlocked ip success",
+	})
+}
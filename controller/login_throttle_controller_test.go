@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLoginThrottleUseCase is a mock implementation of the LoginThrottleUseCase interface
+type MockLoginThrottleUseCase struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleUseCase) Check(ctx context.Context, username string, clientIP string) error {
+	args := m.Called(username, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	args := m.Called(username, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) RecordSuccess(ctx context.Context, username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) LimitPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	args := m.Called(identifier, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) ListLocked(ctx context.Context) ([]model.LoginThrottle, error) {
+	args := m.Called()
+	return args.Get(0).([]model.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleUseCase) UnlockUser(ctx context.Context, username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) UnlockIP(ctx context.Context, clientIP string) error {
+	args := m.Called(clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) PurgeStale(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) SetLimits(maxAttempts int, ipMaxAttempts int, lockoutDuration time.Duration, delay time.Duration) {
+	m.Called(maxAttempts, ipMaxAttempts, lockoutDuration, delay)
+}
+
+func TestLoginThrottleController(t *testing.T) {
+	mockUseCase := new(MockLoginThrottleUseCase)
+	loginThrottleController := NewLoginThrottleController(mockUseCase)
+
+	t.Run("List locked accounts and IPs", func(t *testing.T) {
+		lockedUntil := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockUseCase.On("ListLocked").Return([]model.LoginThrottle{{Key: "user:john_doe", FailedAttempts: 5, LockedUntil: &lockedUntil}}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/lockouts", nil)
+
+		handle(c, loginThrottleController.ListLocked)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"key":"user:john_doe"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Unlock a user", func(t *testing.T) {
+		mockUseCase.On("UnlockUser", "john_doe").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "username", Value: "john_doe"}}
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/lockouts/users/john_doe", nil)
+
+		handle(c, loginThrottleController.UnlockUser)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Unlocked user success")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Unlock an IP", func(t *testing.T) {
+		mockUseCase.On("UnlockIP", "203.0.113.9").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "ip", Value: "203.0.113.9"}}
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/admin/lockouts/ips/203.0.113.9", nil)
+
+		handle(c, loginThrottleController.UnlockIP)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	mfaUseCase domain.MFAUseCase
+}
+
+func NewMFAController(mfaUseCase domain.MFAUseCase) *MFAController {
+	return &MFAController{mfaUseCase}
+}
+
+func (d *MFAController) BeginEnrollment(c *gin.Context) {
+	userID := c.MustGet("currentUserId").(uint)
+
+	enrollment, err := d.mfaUseCase.BeginEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Scan the provisioning uri with an authenticator app and confirm with a code",
+		Data:       enrollment,
+	})
+}
+
+func (d *MFAController) ConfirmEnrollment(c *gin.Context) {
+	userID := c.MustGet("currentUserId").(uint)
+
+	var request model.MFACodeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	recoveryCodes, err := d.mfaUseCase.ConfirmEnrollment(c.Request.Context(), userID, request.Code)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "MFA enabled, store the recovery codes now as they won't be shown again",
+		Data:       gin.H{"recovery_codes": recoveryCodes},
+	})
+}
+
+func (d *MFAController) Disable(c *gin.Context) {
+	userID := c.MustGet("currentUserId").(uint)
+
+	var request model.MFACodeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if err := d.mfaUseCase.Disable(c.Request.Context(), userID, request.Code); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "MFA disabled",
+	})
+}
+
+// VerifyLogin is the second login step for users with MFA enabled.
+func (d *MFAController) VerifyLogin(c *gin.Context) {
+	var request model.MFALoginRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	token, err := d.mfaUseCase.VerifyLogin(c.Request.Context(), request.MFAToken, request.Code, c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Login Success",
+		Data:       model.LoginResult{Token: token},
+	})
+}
+
+func (d *MFAController) SetRolePolicy(c *gin.Context) {
+	roleID, ok := paramID(c, "roleID")
+	if !ok {
+		return
+	}
+
+	var policy model.RoleMFAPolicy
+	if !bindJSON(c, &policy) {
+		return
+	}
+
+	if err := d.mfaUseCase.SetRolePolicy(c.Request.Context(), roleID, policy); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Updated mfa policy success",
+		Data:       policy,
+	})
+}
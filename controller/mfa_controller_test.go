@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMFAUseCase is a mock implementation of the MFAUseCase interface
+type MockMFAUseCase struct {
+	mock.Mock
+}
+
+func (m *MockMFAUseCase) BeginEnrollment(ctx context.Context, userID uint) (model.MFAEnrollment, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.MFAEnrollment), args.Error(1)
+}
+
+func (m *MockMFAUseCase) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAUseCase) Disable(ctx context.Context, userID uint, code string) error {
+	args := m.Called(userID, code)
+	return args.Error(0)
+}
+
+func (m *MockMFAUseCase) VerifyLogin(ctx context.Context, mfaToken string, code string, clientIP string) (string, error) {
+	args := m.Called(mfaToken, code, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMFAUseCase) VerifySecondFactor(ctx context.Context, user model.User, code string, clientIP string) error {
+	args := m.Called(user, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAUseCase) SetRolePolicy(ctx context.Context, roleID uint, policy model.RoleMFAPolicy) error {
+	args := m.Called(roleID, policy)
+	return args.Error(0)
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	mockUseCase := new(MockMFAUseCase)
+	mfaController := NewMFAController(mockUseCase)
+
+	t.Run("Begin enrollment", func(t *testing.T) {
+		mockUseCase.On("BeginEnrollment", uint(7)).Return(model.MFAEnrollment{Secret: "SECRET", ProvisioningURI: "otpauth://totp/RBAC:john_doe?secret=SECRET"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(7))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/me/mfa/enroll", nil)
+
+		handle(c, mfaController.BeginEnrollment)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"provisioning_uri":"otpauth://totp/RBAC:john_doe?secret=SECRET"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Confirm enrollment returns recovery codes", func(t *testing.T) {
+		mockUseCase.On("ConfirmEnrollment", uint(7), "123456").Return([]string{"abcde-12345"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(7))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/me/mfa/confirm", bytes.NewBufferString(`{"code":"123456"}`))
+
+		handle(c, mfaController.ConfirmEnrollment)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"recovery_codes":["abcde-12345"]`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Confirm enrollment requires a code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(7))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/me/mfa/confirm", bytes.NewBufferString(`{}`))
+
+		handle(c, mfaController.ConfirmEnrollment)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestMFAVerifyLogin(t *testing.T) {
+	mockUseCase := new(MockMFAUseCase)
+	mfaController := NewMFAController(mockUseCase)
+
+	t.Run("Exchange the mfa token for an access token", func(t *testing.T) {
+		mockUseCase.On("VerifyLogin", "pending", "123456", "").Return("jwt", nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewBufferString(`{"mfa_token":"pending","code":"123456"}`))
+
+		handle(c, mfaController.VerifyLogin)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"token":"jwt"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Reject an invalid code", func(t *testing.T) {
+		mockUseCase.On("VerifyLogin", "pending", "000000", "").Return("", domain.Unauthorized("invalid verification code")).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewBufferString(`{"mfa_token":"pending","code":"000000"}`))
+
+		handle(c, mfaController.VerifyLogin)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid verification code")
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestMFASetRolePolicy(t *testing.T) {
+	mockUseCase := new(MockMFAUseCase)
+	mfaController := NewMFAController(mockUseCase)
+
+	mockUseCase.On("SetRolePolicy", uint(1), model.RoleMFAPolicy{RequireMFA: true}).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Params = gin.Params{gin.Param{Key: "roleID", Value: "1"}}
+	c.Request, _ = http.NewRequest(http.MethodPut, "/roles/1/mfa-policy", bytes.NewBufferString(`{"require_mfa":true}`))
+
+	handle(c, mfaController.SetRolePolicy)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Updated mfa policy success")
+	mockUseCase.AssertExpectations(t)
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthController struct {
+	oauthUseCase domain.OAuthUseCase
+	oidcUseCase  domain.OIDCUseCase
+}
+
+func NewOAuthController(oauthUseCase domain.OAuthUseCase, oidcUseCase domain.OIDCUseCase) *OAuthController {
+	return &OAuthController{oauthUseCase, oidcUseCase}
+}
+
+func (d *OAuthController) CreateClient(c *gin.Context) {
+	var client model.OAuthClient
+	if !bindJSON(c, &client) {
+		return
+	}
+
+	secret, clientResponse, err := d.oauthUseCase.CreateClient(c.Request.Context(), client)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.Response{
+		StatusCode: http.StatusCreated,
+		Message:    "Created client success, store the secret now as it won't be shown again",
+		Data:       gin.H{"client_secret": secret, "client": clientResponse},
+	})
+}
+
+func (d *OAuthController) ListClients(c *gin.Context) {
+	clients, err := d.oauthUseCase.ListClients(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "List clients success",
+		Data:       clients,
+	})
+}
+
+func (d *OAuthController) AssignRoleToClient(c *gin.Context) {
+	clientID := c.Param("clientID")
+	roleID, ok := paramID(c, "roleID")
+	if !ok {
+		return
+	}
+
+	if err := d.oauthUseCase.AssignRoleToClient(c.Request.Context(), clientID, roleID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Role assigned to client",
+	})
+}
+
+// Token serves the RFC 6749 token endpoint.
+func (d *OAuthController) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	switch c.PostForm("grant_type") {
+	case "client_credentials":
+		clientID, clientSecret := clientCredentials(c)
+		token, err := d.oauthUseCase.IssueClientCredentialsToken(c.Request.Context(), clientID, clientSecret, c.PostForm("scope"))
+		if err != nil {
+			renderOAuthError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, token)
+	case "authorization_code":
+		clientID, clientSecret := clientCredentials(c)
+		token, err := d.oidcUseCase.ExchangeAuthorizationCode(c.Request.Context(), clientID, clientSecret,
+			c.PostForm("code"), c.PostForm("redirect_uri"), c.PostForm("code_verifier"))
+		if err != nil {
+			renderOAuthError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, token)
+	case "":
+		renderOAuthError(c, &model.OAuthError{Code: "invalid_request", Description: "grant_type is required"})
+	default:
+		renderOAuthError(c, &model.OAuthError{Code: "unsupported_grant_type"})
+	}
+}
+
+// Introspect serves the RFC 7662 introspection endpoint.
+func (d *OAuthController) Introspect(c *gin.Context) {
+	token := c.PostForm("token")
+	if token == "" {
+		renderOAuthError(c, &model.OAuthError{Code: "invalid_request", Description: "token is required"})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c)
+	introspection, err := d.oauthUseCase.Introspect(c.Request.Context(), clientID, clientSecret, token)
+	if err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, introspection)
+}
+
+// Revoke serves the RFC 7009 revocation endpoint.
+func (d *OAuthController) Revoke(c *gin.Context) {
+	token := c.PostForm("token")
+	if token == "" {
+		renderOAuthError(c, &model.OAuthError{Code: "invalid_request", Description: "token is required"})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c)
+	if err := d.oauthUseCase.Revoke(c.Request.Context(), clientID, clientSecret, token); err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// clientCredentials reads client authentication from HTTP Basic or the form body.
+func clientCredentials(c *gin.Context) (string, string) {
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		return clientID, clientSecret
+	}
+	return c.PostForm("client_id"), c.PostForm("client_secret")
+}
+
+func renderOAuthError(c *gin.Context, err error) {
+	var oauthErr *model.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, model.OAuthError{Code: "server_error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, oauthErr)
+}
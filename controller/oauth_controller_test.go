@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOAuthUseCase is a mock implementation of the OAuthUseCase interface
+type MockOAuthUseCase struct {
+	mock.Mock
+}
+
+func (m *MockOAuthUseCase) CreateClient(ctx context.Context, client model.OAuthClient) (string, model.OAuthClient, error) {
+	args := m.Called(client)
+	return args.String(0), args.Get(1).(model.OAuthClient), args.Error(2)
+}
+
+func (m *MockOAuthUseCase) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	args := m.Called()
+	return args.Get(0).([]model.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthUseCase) AssignRoleToClient(ctx context.Context, clientID string, roleID uint) error {
+	args := m.Called(clientID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthUseCase) IssueClientCredentialsToken(ctx context.Context, clientID string, clientSecret string, scope string) (model.TokenResponse, error) {
+	args := m.Called(clientID, clientSecret, scope)
+	return args.Get(0).(model.TokenResponse), args.Error(1)
+}
+
+func (m *MockOAuthUseCase) Introspect(ctx context.Context, clientID string, clientSecret string, token string) (model.IntrospectionResponse, error) {
+	args := m.Called(clientID, clientSecret, token)
+	return args.Get(0).(model.IntrospectionResponse), args.Error(1)
+}
+
+func (m *MockOAuthUseCase) Revoke(ctx context.Context, clientID string, clientSecret string, token string) error {
+	args := m.Called(clientID, clientSecret, token)
+	return args.Error(0)
+}
+
+func (m *MockOAuthUseCase) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOAuthUseCase) SetTokenTTL(tokenTTL time.Duration) {
+	m.Called(tokenTTL)
+}
+
+func newFormRequest(path string, form url.Values) *http.Request {
+	request, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+func TestOAuthToken(t *testing.T) {
+	mockUseCase := new(MockOAuthUseCase)
+	oauthController := NewOAuthController(mockUseCase, new(MockOIDCUseCase))
+
+	t.Run("Issue token with basic client authentication", func(t *testing.T) {
+		mockUseCase.On("IssueClientCredentialsToken", "reporting", "secret", "read").
+			Return(model.TokenResponse{AccessToken: "jwt", TokenType: "Bearer", ExpiresIn: 3600, Scope: "read"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"read"}})
+		c.Request.SetBasicAuth("reporting", "secret")
+
+		handle(c, oauthController.Token)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.JSONEq(t, `{"access_token":"jwt","token_type":"Bearer","expires_in":3600,"scope":"read"}`, w.Body.String())
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Reject invalid client with 401", func(t *testing.T) {
+		mockUseCase.On("IssueClientCredentialsToken", "reporting", "wrong", "").
+			Return(model.TokenResponse{}, &model.OAuthError{Code: "invalid_client"}).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {"reporting"}, "client_secret": {"wrong"}})
+
+		handle(c, oauthController.Token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"invalid_client"}`, w.Body.String())
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Reject unsupported grant type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/token", url.Values{"grant_type": {"password"}})
+
+		handle(c, oauthController.Token)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"unsupported_grant_type"}`, w.Body.String())
+	})
+}
+
+func TestOAuthIntrospectAndRevoke(t *testing.T) {
+	mockUseCase := new(MockOAuthUseCase)
+	oauthController := NewOAuthController(mockUseCase, new(MockOIDCUseCase))
+
+	t.Run("Introspect an active token", func(t *testing.T) {
+		mockUseCase.On("Introspect", "reporting", "secret", "jwt").
+			Return(model.IntrospectionResponse{Active: true, ClientID: "reporting", Scope: "read"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/introspect", url.Values{"token": {"jwt"}})
+		c.Request.SetBasicAuth("reporting", "secret")
+
+		handle(c, oauthController.Introspect)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"active":true,"client_id":"reporting","scope":"read"}`, w.Body.String())
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Revoke requires a token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/revoke", url.Values{})
+
+		handle(c, oauthController.Revoke)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_request")
+	})
+
+	t.Run("Revoke a token", func(t *testing.T) {
+		mockUseCase.On("Revoke", "reporting", "secret", "jwt").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/revoke", url.Values{"token": {"jwt"}})
+		c.Request.SetBasicAuth("reporting", "secret")
+
+		handle(c, oauthController.Revoke)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var authorizeFormTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Sign in</title></head>
+<body>
+<h1>Sign in to continue to {{.ClientName}}</h1>
+{{if .Error}}<p role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<label>Username <input type="text" name="username" autocomplete="username" required></label>
+<label>Password <input type="password" name="password" autocomplete="current-password" required></label>
+<label>Verification code, if enabled <input type="text" name="otp" inputmode="numeric" autocomplete="one-time-code"></label>
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
+
+type OIDCController struct {
+	oidcUseCase domain.OIDCUseCase
+}
+
+func NewOIDCController(oidcUseCase domain.OIDCUseCase) *OIDCController {
+	return &OIDCController{oidcUseCase}
+}
+
+func (d *OIDCController) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, d.oidcUseCase.Discovery())
+}
+
+func (d *OIDCController) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, d.oidcUseCase.JWKS())
+}
+
+// AuthorizeForm validates the authentication request and shows the sign in form.
+func (d *OIDCController) AuthorizeForm(c *gin.Context) {
+	var request model.AuthorizeRequest
+	if err := c.ShouldBindQuery(&request); err != nil {
+		renderOAuthError(c, &model.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	client, err := d.oidcUseCase.ValidateAuthorizeRequest(c.Request.Context(), request)
+	if err != nil {
+		renderOAuthError(c, err)
+		return
+	}
+
+	renderAuthorizeForm(c, http.StatusOK, client, request, "")
+}
+
+// Authorize checks the submitted credentials and redirects back to the client with a code.
+func (d *OIDCController) Authorize(c *gin.Context) {
+	var request model.AuthorizeRequest
+	if err := c.ShouldBind(&request); err != nil {
+		renderOAuthError(c, &model.OAuthError{Code: "invalid_request", Description: err.Error()})
+		return
+	}
+
+	credentials := model.User{Username: c.PostForm("username"), Password: c.PostForm("password")}
+	redirect, err := d.oidcUseCase.Authorize(c.Request.Context(), request, credentials, c.PostForm("otp"), c.ClientIP())
+	if err != nil {
+		var throttled *model.LoginThrottledError
+		if errors.As(err, &throttled) {
+			client, _ := d.oidcUseCase.ValidateAuthorizeRequest(c.Request.Context(), request)
+			c.Header("Retry-After", strconv.Itoa(throttled.RetryAfterSeconds()))
+			renderAuthorizeForm(c, http.StatusTooManyRequests, client, request, "Too many failed sign in attempts, try again later")
+			return
+		}
+		var oauthErr *model.OAuthError
+		if errors.As(err, &oauthErr) && oauthErr.Code == "access_denied" {
+			client, _ := d.oidcUseCase.ValidateAuthorizeRequest(c.Request.Context(), request)
+			renderAuthorizeForm(c, http.StatusUnauthorized, client, request, "Invalid username, password or verification code")
+			return
+		}
+		renderOAuthError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirect)
+}
+
+func (d *OIDCController) UserInfo(c *gin.Context) {
+	fields := strings.Fields(c.Request.Header.Get("Authorization"))
+	if len(fields) != 2 || fields[0] != "Bearer" {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.JSON(http.StatusUnauthorized, model.OAuthError{Code: "invalid_token"})
+		return
+	}
+
+	userInfo, err := d.oidcUseCase.UserInfo(c.Request.Context(), fields[1])
+	if err != nil {
+		var oauthErr *model.OAuthError
+		if errors.As(err, &oauthErr) {
+			status := http.StatusUnauthorized
+			if oauthErr.Code == "insufficient_scope" {
+				status = http.StatusForbidden
+			}
+			c.Header("WWW-Authenticate", `Bearer error="`+oauthErr.Code+`"`)
+			c.JSON(status, oauthErr)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, model.OAuthError{Code: "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, userInfo)
+}
+
+func renderAuthorizeForm(c *gin.Context, status int, client model.OAuthClient, request model.AuthorizeRequest, message string) {
+	clientName := client.Name
+	if clientName == "" {
+		clientName = client.ClientID
+	}
+
+	var page bytes.Buffer
+	err := authorizeFormTemplate.Execute(&page, map[string]interface{}{
+		"ClientName": clientName,
+		"Request":    request,
+		"Error":      message,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, model.OAuthError{Code: "server_error"})
+		return
+	}
+
+	// The sign in form must never be framed by another site
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
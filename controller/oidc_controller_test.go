@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockOIDCUseCase is a mock implementation of the OIDCUseCase interface
+type MockOIDCUseCase struct {
+	mock.Mock
+}
+
+func (m *MockOIDCUseCase) Discovery() model.OpenIDConfiguration {
+	args := m.Called()
+	return args.Get(0).(model.OpenIDConfiguration)
+}
+
+func (m *MockOIDCUseCase) JWKS() map[string]interface{} {
+	args := m.Called()
+	return args.Get(0).(map[string]interface{})
+}
+
+func (m *MockOIDCUseCase) ValidateAuthorizeRequest(ctx context.Context, request model.AuthorizeRequest) (model.OAuthClient, error) {
+	args := m.Called(request)
+	return args.Get(0).(model.OAuthClient), args.Error(1)
+}
+
+func (m *MockOIDCUseCase) Authorize(ctx context.Context, request model.AuthorizeRequest, credentials model.User, otp string, clientIP string) (string, error) {
+	args := m.Called(request, credentials, otp, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOIDCUseCase) ExchangeAuthorizationCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string) (model.TokenResponse, error) {
+	args := m.Called(clientID, clientSecret, code, redirectURI, codeVerifier)
+	return args.Get(0).(model.TokenResponse), args.Error(1)
+}
+
+func (m *MockOIDCUseCase) UserInfo(ctx context.Context, accessToken string) (model.UserInfo, error) {
+	args := m.Called(accessToken)
+	return args.Get(0).(model.UserInfo), args.Error(1)
+}
+
+func TestOIDCAuthorize(t *testing.T) {
+	mockUseCase := new(MockOIDCUseCase)
+	oidcController := NewOIDCController(mockUseCase)
+
+	request := model.AuthorizeRequest{
+		ResponseType: "code", ClientID: "portal", RedirectURI: "https://portal.example.com/callback",
+		Scope: "openid", State: "xyz", CodeChallenge: "challenge", CodeChallengeMethod: "S256",
+	}
+	form := url.Values{
+		"response_type": {"code"}, "client_id": {"portal"}, "redirect_uri": {"https://portal.example.com/callback"},
+		"scope": {"openid"}, "state": {"xyz"}, "code_challenge": {"challenge"}, "code_challenge_method": {"S256"},
+	}
+
+	t.Run("Show the sign in form", func(t *testing.T) {
+		mockUseCase.On("ValidateAuthorizeRequest", request).Return(model.OAuthClient{ClientID: "portal", Name: "Portal"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/oauth/authorize?"+form.Encode(), nil)
+
+		handle(c, oidcController.AuthorizeForm)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Sign in to continue to Portal")
+		assert.Contains(t, w.Body.String(), `name="code_challenge" value="challenge"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Redirect back with a code", func(t *testing.T) {
+		credentials := model.User{Username: "john_doe", Password: "password123"}
+		mockUseCase.On("Authorize", request, credentials, "123456", "").Return("https://portal.example.com/callback?code=abc&state=xyz", nil).Once()
+
+		signIn := url.Values{"username": {"john_doe"}, "password": {"password123"}, "otp": {"123456"}}
+		for key, values := range form {
+			signIn[key] = values
+		}
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = newFormRequest("/oauth/authorize", signIn)
+
+		handle(c, oidcController.Authorize)
+		c.Writer.WriteHeaderNow() // The router flushes the redirect status after the handler
+
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, "https://portal.example.com/callback?code=abc&state=xyz", w.Header().Get("Location"))
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestOIDCUserInfo(t *testing.T) {
+	mockUseCase := new(MockOIDCUseCase)
+	oidcController := NewOIDCController(mockUseCase)
+
+	t.Run("Return the user's claims", func(t *testing.T) {
+		mockUseCase.On("UserInfo", "jwt").Return(model.UserInfo{Sub: "7", PreferredUsername: "john_doe", Roles: []string{"admin"}}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/userinfo", nil)
+		c.Request.Header.Set("Authorization", "Bearer jwt")
+
+		handle(c, oidcController.UserInfo)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"sub":"7","preferred_username":"john_doe","roles":["admin"]}`, w.Body.String())
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Require a bearer token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/userinfo", nil)
+
+		handle(c, oidcController.UserInfo)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "invalid_token")
+	})
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"fmt"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// renderPage responds with a page of a list. Unless it's the last page, a Link
+// header points at the next one, which keeps the filters of the request.
+func renderPage(c *gin.Context, message string, data interface{}, page model.PageInfo) {
+	if page.NextCursor != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("cursor", page.NextCursor)
+		next.RawQuery = query.Encode()
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       data,
+		Page:       &page,
+	})
+}
@@ -17,24 +17,39 @@ func NewPermissionController(permissionUseCase domain.PermissionUseCase) *Permis
 }
 
 func (d *PermissionController) CreatePermission(c *gin.Context) {
-	var permission model.Permission
-	if err := c.ShouldBindJSON(&permission); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request model.CreatePermissionRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	permissionResponse, err := d.permissionUseCase.CreatePermission(permission)
+	permission, err := d.permissionUseCase.CreatePermission(c.Request.Context(), request.Permission())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusInternalServerError,
-			Message:    "Unable to create permission: " + err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, model.Response{
 		StatusCode: http.StatusCreated,
 		Message:    "Created permission success",
-		Data:       permissionResponse,
+		Data:       model.NewPermissionView(permission),
 	})
 }
+
+func (d *PermissionController) ListPermissions(c *gin.Context) {
+	var filter model.PermissionFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	permissions, page, err := d.permissionUseCase.ListPermissions(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	views := make([]model.PermissionView, 0, len(permissions))
+	for _, permission := range permissions {
+		views = append(views, model.NewPermissionView(permission))
+	}
+	renderPage(c, "List permissions success", views, page)
+}
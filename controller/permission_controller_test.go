@@ -2,6 +2,8 @@ package controller
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"go-multirole/model"
 	"net/http"
 	"testing"
@@ -18,11 +20,21 @@ type MockPermissionUseCase struct {
 	mock.Mock
 }
 
-func (m *MockPermissionUseCase) CreatePermission(permission model.Permission) (model.Permission, error) {
+func (m *MockPermissionUseCase) CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error) {
 	args := m.Called(permission)
 	return args.Get(0).(model.Permission), args.Error(1)
 }
 
+func (m *MockPermissionUseCase) ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Permission), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockPermissionUseCase) FindPermissionByName(ctx context.Context, name string) (model.Permission, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Permission), args.Error(1)
+}
+
 // Unit tests for PermissionController
 func TestPermissionController(t *testing.T) {
 	mockUseCase := new(MockPermissionUseCase)
@@ -41,7 +53,7 @@ func TestPermissionController(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/permission", bytes.NewBufferString(`{"name":"admin_access"}`))
 
 		// Call the CreatePermission function
-		permissionController.CreatePermission(c)
+		handle(c, permissionController.CreatePermission)
 
 		// Assert the response status is Created and the message is as expected
 		assert.Equal(t, http.StatusCreated, w.Code)
@@ -58,10 +70,23 @@ func TestPermissionController(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/permission", bytes.NewBufferString(`{"name":`)) // invalid JSON
 
 		// Call the CreatePermission function
-		permissionController.CreatePermission(c)
+		handle(c, permissionController.CreatePermission)
 
 		// Assert the response status is BadRequest
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "unexpected EOF")
 	})
+	t.Run("Create permission with an invalid name", func(t *testing.T) {
+		for _, name := range []string{"   ", "read;write", "read\n"} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			body, _ := json.Marshal(map[string]string{"name": name})
+			c.Request, _ = http.NewRequest(http.MethodPost, "/permission", bytes.NewBuffer(body))
+
+			handle(c, permissionController.CreatePermission)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "expected %q to be rejected", name)
+			assert.Contains(t, w.Body.String(), `"invalid_params":[{"name":"name","reason":"can't contain ';' or control characters, nor start or end with a space"}]`)
+		}
+	})
 }
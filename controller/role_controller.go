@@ -17,38 +17,56 @@ func NewRoleController(roleUseCase domain.RoleUseCase) *RoleController {
 }
 
 func (d *RoleController) CreateRole(c *gin.Context) {
-	var role model.Role
-	if err := c.ShouldBindJSON(&role); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var request model.CreateRoleRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	roleResponse, err := d.roleUseCase.CreateRole(role)
+	role, err := d.roleUseCase.CreateRoleWithAccess(c.Request.Context(), request.Role(), request.PermissionIDs, request.UserIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusInternalServerError,
-			Message:    "Unable to create role: " + err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, model.Response{
 		StatusCode: http.StatusCreated,
 		Message:    "Created role success",
-		Data:       roleResponse,
+		Data:       model.NewRoleView(role),
 	})
 }
 
+func (d *RoleController) ListRoles(c *gin.Context) {
+	var filter model.RoleFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	roles, page, err := d.roleUseCase.ListRoles(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	views := make([]model.RoleView, 0, len(roles))
+	for _, role := range roles {
+		views = append(views, model.NewRoleView(role))
+	}
+	renderPage(c, "List roles success", views, page)
+}
+
 func (d *RoleController) AssignPermissionToRole(c *gin.Context) {
-	roleID := c.Param("roleID")
-	permissionID := c.Param("permissionID")
+	roleID, ok := paramID(c, "roleID")
+	if !ok {
+		return
+	}
+	permissionID, ok := paramID(c, "permissionID")
+	if !ok {
+		return
+	}
 
-	err := d.roleUseCase.AssignPermissionToRole(roleID, permissionID)
+	err := d.roleUseCase.AssignPermissionToRole(c.Request.Context(), roleID, permissionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusInternalServerError,
-			Message:    "Unable to create role: " + err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ServiceAccountController struct {
+	serviceAccountUseCase domain.ServiceAccountUseCase
+}
+
+func NewServiceAccountController(serviceAccountUseCase domain.ServiceAccountUseCase) *ServiceAccountController {
+	return &ServiceAccountController{serviceAccountUseCase}
+}
+
+func (d *ServiceAccountController) CreateServiceAccount(c *gin.Context) {
+	var request model.CreateServiceAccountRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	user, err := d.serviceAccountUseCase.CreateServiceAccount(c.Request.Context(), request.User())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.Response{
+		StatusCode: http.StatusCreated,
+		Message:    "Created service account success",
+		Data:       model.NewUserView(user),
+	})
+}
+
+func (d *ServiceAccountController) CreateAPIKey(c *gin.Context) {
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
+
+	var apiKey model.APIKey
+	if !bindJSON(c, &apiKey) {
+		return
+	}
+
+	key, apiKeyResponse, err := d.serviceAccountUseCase.CreateAPIKey(c.Request.Context(), userID, apiKey)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.Response{
+		StatusCode: http.StatusCreated,
+		Message:    "Created api key success, store the key now as it won't be shown again",
+		Data:       gin.H{"key": key, "api_key": apiKeyResponse},
+	})
+}
+
+func (d *ServiceAccountController) ListAPIKeys(c *gin.Context) {
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
+
+	apiKeys, err := d.serviceAccountUseCase.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "List api keys success",
+		Data:       apiKeys,
+	})
+}
+
+func (d *ServiceAccountController) RevokeAPIKey(c *gin.Context) {
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
+	keyID, ok := paramID(c, "keyID")
+	if !ok {
+		return
+	}
+
+	if err := d.serviceAccountUseCase.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Revoked api key success",
+	})
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockServiceAccountUseCase is a mock implementation of the ServiceAccountUseCase interface
+type MockServiceAccountUseCase struct {
+	mock.Mock
+}
+
+func (m *MockServiceAccountUseCase) CreateServiceAccount(ctx context.Context, user model.User) (model.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockServiceAccountUseCase) CreateAPIKey(ctx context.Context, userID uint, apiKey model.APIKey) (string, model.APIKey, error) {
+	args := m.Called(userID, apiKey)
+	return args.String(0), args.Get(1).(model.APIKey), args.Error(2)
+}
+
+func (m *MockServiceAccountUseCase) ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockServiceAccountUseCase) RevokeAPIKey(ctx context.Context, userID uint, keyID uint) error {
+	args := m.Called(userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockServiceAccountUseCase) AuthenticateAPIKey(ctx context.Context, key string) (model.APIKey, error) {
+	args := m.Called(key)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func TestServiceAccountController(t *testing.T) {
+	mockUseCase := new(MockServiceAccountUseCase)
+	serviceAccountController := NewServiceAccountController(mockUseCase)
+
+	t.Run("Create service account successfully", func(t *testing.T) {
+		mockUseCase.On("CreateServiceAccount", model.User{Username: "billing-job"}).Return(model.User{ID: 3, Username: "billing-job", ServiceAccount: true}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/service-accounts", bytes.NewBufferString(`{"username":"billing-job"}`))
+
+		handle(c, serviceAccountController.CreateServiceAccount)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"service_account":true`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Create api key returns the plain key once", func(t *testing.T) {
+		mockUseCase.On("CreateAPIKey", uint(3), model.APIKey{Name: "nightly", Scopes: []string{"read"}}).
+			Return("rbac_abcd1234_secret", model.APIKey{ID: 1, UserID: 3, Name: "nightly", Prefix: "rbac_abcd1234"}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "userID", Value: "3"}}
+		c.Request, _ = http.NewRequest(http.MethodPost, "/service-accounts/3/keys", bytes.NewBufferString(`{"name":"nightly","scopes":["read"]}`))
+
+		handle(c, serviceAccountController.CreateAPIKey)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), "rbac_abcd1234_secret")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Revoke api key successfully", func(t *testing.T) {
+		mockUseCase.On("RevokeAPIKey", uint(3), uint(1)).Return(nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "userID", Value: "3"}, gin.Param{Key: "keyID", Value: "1"}}
+		c.Request, _ = http.NewRequest(http.MethodDelete, "/service-accounts/3/keys/1", nil)
+
+		handle(c, serviceAccountController.RevokeAPIKey)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Revoked api key success")
+		mockUseCase.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SnapshotController struct {
+	snapshotUseCase domain.SnapshotUseCase
+}
+
+func NewSnapshotController(snapshotUseCase domain.SnapshotUseCase) *SnapshotController {
+	return &SnapshotController{snapshotUseCase}
+}
+
+// Export responds with the bare snapshot rather than a model.Response, so the
+// download can be posted to /admin/import as is.
+func (d *SnapshotController) Export(c *gin.Context) {
+	var options model.ExportOptions
+	if !bindQuery(c, &options) {
+		return
+	}
+
+	snapshot, err := d.snapshotUseCase.Export(c.Request.Context(), options)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	filename := fmt.Sprintf("snapshot-%s.json", snapshot.ExportedAt.Format("20060102T150405Z"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.JSON(http.StatusOK, snapshot)
+}
+
+func (d *SnapshotController) Import(c *gin.Context) {
+	var options model.ImportOptions
+	if !bindQuery(c, &options) {
+		return
+	}
+	if options.Mode == "" {
+		options.Mode = model.ImportModeMerge
+	}
+	var snapshot model.Snapshot
+	if !bindJSON(c, &snapshot) {
+		return
+	}
+
+	plan, err := d.snapshotUseCase.Import(c.Request.Context(), snapshot, options)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	message := "Imported snapshot success"
+	if options.DryRun {
+		message = "Planned snapshot import success"
+	}
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       plan.Result(options),
+	})
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSnapshotUseCase is a mock implementation of the SnapshotUseCase interface
+type MockSnapshotUseCase struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotUseCase) Export(ctx context.Context, options model.ExportOptions) (model.Snapshot, error) {
+	args := m.Called(options)
+	return args.Get(0).(model.Snapshot), args.Error(1)
+}
+
+func (m *MockSnapshotUseCase) Import(ctx context.Context, snapshot model.Snapshot, options model.ImportOptions) (model.ImportPlan, error) {
+	args := m.Called(snapshot, options)
+	return args.Get(0).(model.ImportPlan), args.Error(1)
+}
+
+func TestSnapshotController(t *testing.T) {
+	mockUseCase := new(MockSnapshotUseCase)
+	snapshotController := NewSnapshotController(mockUseCase)
+
+	t.Run("Export with password hashes", func(t *testing.T) {
+		exportedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		mockUseCase.On("Export", model.ExportOptions{IncludePasswordHashes: true}).Return(model.Snapshot{
+			Version:    model.SnapshotVersion,
+			ExportedAt: exportedAt,
+			Users:      []model.SnapshotUser{{Username: "alice", PasswordHash: "$2a$04$hash"}},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/admin/export?include_password_hashes=true", nil)
+
+		handle(c, snapshotController.Export)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `attachment; filename="snapshot-20240501T120000Z.json"`, w.Header().Get("Content-Disposition"))
+		assert.Contains(t, w.Body.String(), `{"version":1,`, "The snapshot isn't wrapped, so it can be imported as is")
+		assert.Contains(t, w.Body.String(), `"password_hash":"$2a$04$hash"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Import defaults to merge", func(t *testing.T) {
+		snapshot := model.Snapshot{Version: model.SnapshotVersion, Permissions: []string{"manage_users"}}
+		options := model.ImportOptions{Mode: model.ImportModeMerge, DryRun: true}
+		mockUseCase.On("Import", snapshot, options).Return(model.ImportPlan{
+			PolicyPlan: model.PolicyPlan{CreatePermissions: []string{"manage_users"}},
+			Conflicts:  []model.ImportConflict{{Entity: "user", Name: "alice", Field: "email", Current: "a@example.com", Imported: "b@example.com"}},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/import?dry_run=true", bytes.NewBufferString(`{"version":1,"permissions":["manage_users"]}`))
+
+		handle(c, snapshotController.Import)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Planned snapshot import success")
+		assert.Contains(t, w.Body.String(), `"mode":"merge","dry_run":true,"changes":["+ permission manage_users"]`)
+		assert.Contains(t, w.Body.String(), `"field":"email","current":"a@example.com","imported":"b@example.com"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Import with unknown mode", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/admin/import?mode=overwrite", bytes.NewBufferString(`{"version":1}`))
+
+		handle(c, snapshotController.Import)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"mode"`)
+		mockUseCase.AssertNotCalled(t, "Import", mock.Anything, model.ImportOptions{Mode: "overwrite"})
+	})
+}
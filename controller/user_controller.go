@@ -2,6 +2,7 @@ package controller
 
 import (
 	"go-multirole/domain"
+	"go-multirole/middleware"
 	"go-multirole/model"
 	"net/http"
 
@@ -17,67 +18,187 @@ func NewUserController(userUseCase domain.UserUseCase) *UserController {
 }
 
 func (d *UserController) CreateUser(c *gin.Context) {
-	var user model.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, model.Response{
-			StatusCode: http.StatusBadRequest,
-			Message:    err.Error(),
-		})
+	var request model.CreateUserRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	userResponse, err := d.userUseCase.CreateUser(user)
+	user, err := d.userUseCase.CreateUser(c.Request.Context(), request.User())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, model.Response{
 		StatusCode: http.StatusOK,
 		Message:    "Created user success",
-		Data:       userResponse,
+		Data:       model.NewUserView(user),
 	})
 }
 
 func (d *UserController) LoginUser(c *gin.Context) {
-	var user model.User
-	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, model.Response{
-			StatusCode: http.StatusBadRequest,
-			Message:    err.Error(),
-		})
+	var request model.LoginRequest
+	if !bindJSON(c, &request) {
 		return
 	}
 
-	token, err := d.userUseCase.LoginUser(user)
+	result, err := d.userUseCase.LoginUser(c.Request.Context(), request.Credentials(), c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusInternalServerError,
-			Message:    err.Error(),
-		})
+		c.Error(err)
+		return
+	}
+
+	message := "Login Success"
+	if result.MFARequired {
+		message = "Verification code required, submit it with the mfa token to /users/login/mfa"
+	} else if result.MFAEnrollmentRequired {
+		message = "Your role requires multi-factor authentication, enroll with the token at /users/me/mfa"
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       result,
+	})
+}
+
+// ChangePassword replaces the password of the logged in user. The response
+// carries a new token because every existing session has ended.
+func (d *UserController) ChangePassword(c *gin.Context) {
+	var request model.ChangePasswordRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	userID := c.MustGet("currentUserId").(uint)
+	token, err := d.userUseCase.ChangePassword(c.Request.Context(), userID, request.CurrentPassword, request.NewPassword, c.ClientIP())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Password changed, other sessions have been logged out",
+		Data:       model.LoginResult{Token: token},
+	})
+}
+
+// RequestPasswordReset always answers the same way so it can't be used to
+// find out which accounts exist.
+func (d *UserController) RequestPasswordReset(c *gin.Context) {
+	var request model.PasswordResetRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	if err := d.userUseCase.RequestPasswordReset(c.Request.Context(), request.Identifier, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, model.Response{
+		StatusCode: http.StatusAccepted,
+		Message:    "If the account exists and has an email address, a reset link has been sent",
+	})
+}
+
+func (d *UserController) ResetPassword(c *gin.Context) {
+	var request model.ResetPasswordRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	err := d.userUseCase.ResetPassword(c.Request.Context(), request.Token, request.NewPassword)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Password has been reset, log in with the new password",
+	})
+}
+
+// ListUsers returns a page of users matching the filter in the query string.
+func (d *UserController) ListUsers(c *gin.Context) {
+	var filter model.UserFilter
+	if !bindQuery(c, &filter) {
+		return
+	}
+
+	users, page, err := d.userUseCase.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	views := make([]model.UserView, 0, len(users))
+	for _, user := range users {
+		views = append(views, model.NewUserView(user))
+	}
+	renderPage(c, "List users success", views, page)
+}
+
+// ChangeStatus moves a user through the lifecycle, e.g. disabling them on offboarding.
+func (d *UserController) ChangeStatus(c *gin.Context) {
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
+
+	var request model.UserStatusRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	actorID := c.MustGet("currentUserId").(uint)
+	user, err := d.userUseCase.ChangeStatus(c.Request.Context(), userID, request, actorID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "User status changed",
+		Data:       model.NewUserView(user),
+	})
+}
+
+func (d *UserController) ListStatusChanges(c *gin.Context) {
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
+
+	changes, err := d.userUseCase.ListStatusChanges(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, model.Response{
 		StatusCode: http.StatusOK,
-		Message:    "Login Success",
-		Data:       token,
+		Message:    "List status changes success",
+		Data:       changes,
 	})
 }
 
 func (d *UserController) AssignRoleToUser(c *gin.Context) {
-	userID := c.Param("userID")
-	roleID := c.Param("roleID")
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
+	roleID, ok := paramID(c, "roleID")
+	if !ok {
+		return
+	}
 
-	err := d.userUseCase.AssignRoleToUser(userID, roleID)
+	err := d.userUseCase.AssignRoleToUser(c.Request.Context(), userID, roleID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusOK,
-			Message:    "Unable assign user: " + err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
@@ -88,15 +209,15 @@ func (d *UserController) AssignRoleToUser(c *gin.Context) {
 }
 
 func (d *UserController) CheckUserPermission(c *gin.Context) {
-	userID := c.Param("userID")
+	userID, ok := paramID(c, "userID")
+	if !ok {
+		return
+	}
 	permissionName := c.Param("permissionName")
 
-	hasPermission, err := d.userUseCase.CheckUserPermission(userID, permissionName)
+	hasPermission, err := d.userUseCase.CheckUserPermission(c.Request.Context(), userID, permissionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusInternalServerError,
-			Message:    "User not found: " + err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
@@ -104,27 +225,21 @@ func (d *UserController) CheckUserPermission(c *gin.Context) {
 }
 
 func (d *UserController) GetUserTemp(c *gin.Context) {
-	userID := c.MustGet("currentUserId").(string)
+	userID := c.MustGet("currentUserId").(uint)
 	permissionName := "read"
 
-	has_permission, err := d.userUseCase.CheckUserPermission(userID, permissionName)
+	has_permission, err := d.userUseCase.CheckUserPermission(c.Request.Context(), userID, permissionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, model.Response{
-			StatusCode: http.StatusOK,
-			Message:    "User not found, or doesnt have access: " + err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
-	if has_permission {
+	if has_permission && middleware.HasScope(c, permissionName) {
 		c.JSON(http.StatusOK, model.Response{
 			StatusCode: http.StatusOK,
 			Message:    "Temp user and role",
 		})
 	} else {
-		c.JSON(http.StatusBadRequest, model.Response{
-			StatusCode: http.StatusBadRequest,
-			Message:    "User doesnt have access",
-		})
+		c.Error(domain.Forbidden("user doesn't have access"))
 	}
 }
@@ -2,10 +2,14 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"go-multirole/domain"
+	"go-multirole/middleware"
 	"go-multirole/model"
 	"net/http"
 	"testing"
+	"time"
 
 	"net/http/httptest"
 
@@ -19,26 +23,92 @@ type MockUserUseCase struct {
 	mock.Mock
 }
 
-func (m *MockUserUseCase) CreateUser(user model.User) (model.User, error) {
+func (m *MockUserUseCase) CreateUser(ctx context.Context, user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) LoginUser(user model.User) (string, error) {
-	args := m.Called(user)
-	return args.String(0), args.Error(1)
+func (m *MockUserUseCase) LoginUser(ctx context.Context, user model.User, clientIP string) (model.LoginResult, error) {
+	args := m.Called(user, clientIP)
+	return args.Get(0).(model.LoginResult), args.Error(1)
+}
+
+func (m *MockUserUseCase) AuthenticateUser(ctx context.Context, user model.User, clientIP string) (model.User, error) {
+	args := m.Called(user, clientIP)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ValidatePassword(ctx context.Context, user model.User, password string) error {
+	args := m.Called(user, password)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	args := m.Called(identifier)
+	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) AssignRoleToUser(userID string, roleID string) error {
+func (m *MockUserUseCase) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
 	args := m.Called(userID, roleID)
 	return args.Error(0)
 }
 
-func (m *MockUserUseCase) CheckUserPermission(userID string, permissionName string) (bool, error) {
+func (m *MockUserUseCase) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
 	args := m.Called(userID, permissionName)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserUseCase) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Permission), args.Error(1)
+}
+
+func (m *MockUserUseCase) ChangePassword(ctx context.Context, userID uint, currentPassword string, newPassword string, clientIP string) (string, error) {
+	args := m.Called(userID, currentPassword, newPassword, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) RequestPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	args := m.Called(identifier, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) SessionValid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error) {
+	args := m.Called(userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockUserUseCase) ChangeStatus(ctx context.Context, userID uint, request model.UserStatusRequest, actorID uint) (model.User, error) {
+	args := m.Called(userID, request, actorID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.UserStatusChange), args.Error(1)
+}
+
+// handle calls the handler followed by the error middleware, like the router does.
+func handle(c *gin.Context, handler gin.HandlerFunc) {
+	handler(c)
+	middleware.ErrorHandler()(c)
+}
+
 // Test for CreateUser
 func TestCreateUser(t *testing.T) {
 	mockUseCase := new(MockUserUseCase)
@@ -57,11 +127,13 @@ func TestCreateUser(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/user", bytes.NewBufferString(`{"username":"john_doe", "password":"password123"}`))
 
 		// Call the CreateUser function
-		userController.CreateUser(c)
+		handle(c, userController.CreateUser)
 
 		// Assert the response status is OK and the message is as expected
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "Created user success")
+		assert.Contains(t, w.Body.String(), `"id":1`)
+		assert.NotContains(t, w.Body.String(), "password123", "The stored password must never be rendered")
 
 		// Verify mock expectations
 		mockUseCase.AssertExpectations(t)
@@ -74,12 +146,66 @@ func TestCreateUser(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/user", bytes.NewBufferString(`{"username":"john_doe"`)) // invalid JSON
 
 		// Call the CreateUser function
-		userController.CreateUser(c)
+		handle(c, userController.CreateUser)
 
 		// Assert the response status is BadRequest
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "unexpected EOF")
 	})
+
+	t.Run("Create user without a password", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/user", bytes.NewBufferString(`{"username":"john_doe"}`))
+
+		handle(c, userController.CreateUser)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"invalid_params":[{"name":"password","reason":"is required"}]`)
+	})
+
+	t.Run("Create user reports every invalid field", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/user", bytes.NewBufferString(`{"username":"john doe","password":"Password-123","email":"not-an-email"}`))
+
+		handle(c, userController.CreateUser)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Bad Request",
+			"status": 400,
+			"detail": "request has invalid fields",
+			"instance": "/user",
+			"invalid_params": [
+				{"name": "username", "reason": "may only contain letters, digits, '.', '_' and '-'"},
+				{"name": "email", "reason": "must be a valid email address"}
+			]
+		}`, w.Body.String())
+	})
+}
+
+// Test for CreateUser with a password rejected by the policy
+func TestCreateUserPasswordPolicy(t *testing.T) {
+	mockUseCase := new(MockUserUseCase)
+	userController := NewUserController(mockUseCase)
+
+	policyErr := &model.PasswordPolicyError{Violations: []model.PolicyViolation{
+		{Rule: "min_length", Message: "password must be at least 12 characters"},
+		{Rule: "digit", Message: "password must contain a digit"},
+	}}
+	mockUseCase.On("CreateUser", model.User{Username: "jane_doe", Password: "short"}).Return(model.User{}, policyErr).Once()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/user", bytes.NewBufferString(`{"username":"jane_doe", "password":"short"}`))
+
+	handle(c, userController.CreateUser)
+
+	// Each failed rule is listed
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"violations":[{"rule":"min_length","message":"password must be at least 12 characters"},{"rule":"digit","message":"password must contain a digit"}]`)
 }
 
 // Test for LoginUser
@@ -91,7 +217,7 @@ func TestLoginUser(t *testing.T) {
 		// Define a mock user and token
 		mockUser := model.User{Username: "john_doe", Password: "password123"}
 		mockToken := "mock_token"
-		mockUseCase.On("LoginUser", mockUser).Return(mockToken, nil)
+		mockUseCase.On("LoginUser", mockUser, "").Return(model.LoginResult{Token: mockToken}, nil)
 
 		// Create a test HTTP request and recorder
 		w := httptest.NewRecorder()
@@ -99,7 +225,7 @@ func TestLoginUser(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"username":"john_doe", "password":"password123"}`))
 
 		// Call the LoginUser function
-		userController.LoginUser(c)
+		handle(c, userController.LoginUser)
 
 		// Assert the response status and body
 		assert.Equal(t, http.StatusOK, w.Code)
@@ -113,7 +239,7 @@ func TestLoginUser(t *testing.T) {
 	t.Run("Login user with error", func(t *testing.T) {
 		// Define a mock user and simulate an error during login
 		mockUser := model.User{Username: "john_doe", Password: "wrong_password"}
-		mockUseCase.On("LoginUser", mockUser).Return("", errors.New("invalid credentials"))
+		mockUseCase.On("LoginUser", mockUser, "").Return(model.LoginResult{}, errors.New("invalid credentials"))
 
 		// Create a test HTTP request and recorder
 		w := httptest.NewRecorder()
@@ -121,24 +247,97 @@ func TestLoginUser(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"username":"john_doe", "password":"wrong_password"}`))
 
 		// Call the LoginUser function
-		userController.LoginUser(c)
+		handle(c, userController.LoginUser)
 
-		// Assert the response status and error message
+		// Assert the response status and that internal errors aren't exposed
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		assert.Contains(t, w.Body.String(), "invalid credentials")
+		assert.Equal(t, model.ProblemContentType, w.Header().Get("Content-Type"))
+		assert.NotContains(t, w.Body.String(), "invalid credentials")
 
 		// Verify mock expectations
 		mockUseCase.AssertExpectations(t)
 	})
 }
 
+// Test that clients can't choose the IP the login throttle counts against
+func TestLoginUser_ClientIP(t *testing.T) {
+	mockUser := model.User{Username: "john_doe", Password: "password123"}
+
+	login := func(trustedProxies []string, remoteAddr string) string {
+		router := gin.New()
+		assert.NoError(t, router.SetTrustedProxies(trustedProxies))
+		router.Use(middleware.ErrorHandler())
+		mockUseCase := new(MockUserUseCase)
+		router.POST("/users/login", NewUserController(mockUseCase).LoginUser)
+		mockUseCase.On("LoginUser", mockUser, mock.Anything).Return(model.LoginResult{Token: "mock_token"}, nil)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBufferString(`{"username":"john_doe", "password":"password123"}`))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "198.51.100.7")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUseCase.AssertExpectations(t)
+		return mockUseCase.Calls[0].Arguments.Get(1).(string)
+	}
+
+	t.Run("Ignores X-Forwarded-For without trusted proxies", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.9", login(nil, "203.0.113.9:51000"))
+	})
+
+	t.Run("Ignores X-Forwarded-For from an untrusted peer", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.9", login([]string{"10.0.0.0/8"}, "203.0.113.9:51000"))
+	})
+
+	t.Run("Uses X-Forwarded-For from a trusted proxy", func(t *testing.T) {
+		assert.Equal(t, "198.51.100.7", login([]string{"10.0.0.0/8"}, "10.0.0.2:51000"))
+	})
+}
+
+// Test for LoginUser failures that must not reveal which part was wrong
+func TestLoginUserRejected(t *testing.T) {
+	mockUseCase := new(MockUserUseCase)
+	userController := NewUserController(mockUseCase)
+
+	t.Run("Invalid credentials return 401", func(t *testing.T) {
+		mockUser := model.User{Username: "nobody", Password: "password123"}
+		mockUseCase.On("LoginUser", mockUser, "").Return(model.LoginResult{}, domain.ErrInvalidCredentials).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"username":"nobody", "password":"password123"}`))
+
+		handle(c, userController.LoginUser)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid username or password")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Throttled logins return 429 with Retry-After", func(t *testing.T) {
+		mockUser := model.User{Username: "john_doe", Password: "password123"}
+		mockUseCase.On("LoginUser", mockUser, "").Return(model.LoginResult{}, &model.LoginThrottledError{RetryAfter: 90 * time.Second}).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(`{"username":"john_doe", "password":"password123"}`))
+
+		handle(c, userController.LoginUser)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "90", w.Header().Get("Retry-After"))
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
 // Test for AssignRoleToUser
 func TestAssignRoleToUser(t *testing.T) {
 	mockUseCase := new(MockUserUseCase)
 	userController := NewUserController(mockUseCase)
 
 	t.Run("Assign role to user successfully", func(t *testing.T) {
-		mockUseCase.On("AssignRoleToUser", "1", "2").Return(nil)
+		mockUseCase.On("AssignRoleToUser", uint(1), uint(2)).Return(nil)
 
 		// Create a test HTTP request and recorder
 		w := httptest.NewRecorder()
@@ -147,7 +346,7 @@ func TestAssignRoleToUser(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodPost, "/assign-role", nil)
 
 		// Call the AssignRoleToUser function
-		userController.AssignRoleToUser(c)
+		handle(c, userController.AssignRoleToUser)
 
 		// Assert the response status and message
 		assert.Equal(t, http.StatusOK, w.Code)
@@ -164,7 +363,7 @@ func TestCheckUserPermission(t *testing.T) {
 	userController := NewUserController(mockUseCase)
 
 	t.Run("Check user permission successfully", func(t *testing.T) {
-		mockUseCase.On("CheckUserPermission", "1", "read").Return(true, nil)
+		mockUseCase.On("CheckUserPermission", uint(1), "read").Return(true, nil)
 
 		// Create a test HTTP request and recorder
 		w := httptest.NewRecorder()
@@ -173,7 +372,7 @@ func TestCheckUserPermission(t *testing.T) {
 		c.Request, _ = http.NewRequest(http.MethodGet, "/check-permission", nil)
 
 		// Call the CheckUserPermission function
-		userController.CheckUserPermission(c)
+		handle(c, userController.CheckUserPermission)
 
 		// Assert the response status and body
 		assert.Equal(t, http.StatusOK, w.Code)
@@ -183,3 +382,206 @@ func TestCheckUserPermission(t *testing.T) {
 		mockUseCase.AssertExpectations(t)
 	})
 }
+
+// Test for ChangePassword
+func TestChangePassword(t *testing.T) {
+	mockUseCase := new(MockUserUseCase)
+	userController := NewUserController(mockUseCase)
+
+	t.Run("Return a new token", func(t *testing.T) {
+		mockUseCase.On("ChangePassword", uint(7), "Current-Password-1", "New-Password-2", "").Return("new_token", nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(7))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/me/password", bytes.NewBufferString(`{"current_password":"Current-Password-1", "new_password":"New-Password-2"}`))
+
+		handle(c, userController.ChangePassword)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "new_token")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Wrong current password returns 401", func(t *testing.T) {
+		mockUseCase.On("ChangePassword", uint(7), "wrong", "New-Password-2", "").Return("", domain.ErrInvalidCredentials).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(7))
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/me/password", bytes.NewBufferString(`{"current_password":"wrong", "new_password":"New-Password-2"}`))
+
+		handle(c, userController.ChangePassword)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+// Test for the password reset flow
+func TestPasswordReset(t *testing.T) {
+	mockUseCase := new(MockUserUseCase)
+	userController := NewUserController(mockUseCase)
+
+	t.Run("Requests are always accepted", func(t *testing.T) {
+		mockUseCase.On("RequestPasswordReset", "nobody", "203.0.113.9").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/password/reset-request", bytes.NewBufferString(`{"identifier":"nobody"}`))
+		c.Request.RemoteAddr = "203.0.113.9:41000"
+
+		handle(c, userController.RequestPasswordReset)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Throttled requests return 429", func(t *testing.T) {
+		mockUseCase.On("RequestPasswordReset", "john_doe", "203.0.113.9").Return(&model.LoginThrottledError{RetryAfter: time.Minute}).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/password/reset-request", bytes.NewBufferString(`{"identifier":"john_doe"}`))
+		c.Request.RemoteAddr = "203.0.113.9:41000"
+
+		handle(c, userController.RequestPasswordReset)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("Invalid token returns 400", func(t *testing.T) {
+		mockUseCase.On("ResetPassword", "expired", "New-Password-2").Return(domain.ErrInvalidResetToken).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewBufferString(`{"token":"expired", "new_password":"New-Password-2"}`))
+
+		handle(c, userController.ResetPassword)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid or expired password reset token")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Reset the password", func(t *testing.T) {
+		mockUseCase.On("ResetPassword", "reset-token", "New-Password-2").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/password/reset", bytes.NewBufferString(`{"token":"reset-token", "new_password":"New-Password-2"}`))
+
+		handle(c, userController.ResetPassword)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+// Test for ChangeStatus
+func TestChangeStatus(t *testing.T) {
+	mockUseCase := new(MockUserUseCase)
+	userController := NewUserController(mockUseCase)
+
+	t.Run("Disable a user", func(t *testing.T) {
+		request := model.UserStatusRequest{Status: model.UserStatusDisabled, Reason: "offboarding"}
+		mockUseCase.On("ChangeStatus", uint(7), request, uint(1)).Return(model.User{ID: 7, Username: "john_doe", Status: model.UserStatusDisabled}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(1))
+		c.Params = gin.Params{{Key: "userID", Value: "7"}}
+		c.Request, _ = http.NewRequest(http.MethodPut, "/users/7/status", bytes.NewBufferString(`{"status":"disabled", "reason":"offboarding"}`))
+
+		handle(c, userController.ChangeStatus)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"disabled"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Invalid transitions return 409", func(t *testing.T) {
+		request := model.UserStatusRequest{Status: model.UserStatusPending}
+		mockUseCase.On("ChangeStatus", uint(7), request, uint(1)).Return(model.User{}, domain.Conflict("%w: cannot change from active to pending", model.ErrInvalidStatusTransition)).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("currentUserId", uint(1))
+		c.Params = gin.Params{{Key: "userID", Value: "7"}}
+		c.Request, _ = http.NewRequest(http.MethodPut, "/users/7/status", bytes.NewBufferString(`{"status":"pending"}`))
+
+		handle(c, userController.ChangeStatus)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, model.ProblemContentType, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{
+			"type": "about:blank",
+			"title": "Conflict",
+			"status": 409,
+			"detail": "invalid user status transition: cannot change from active to pending",
+			"instance": "/users/7/status"
+		}`, w.Body.String())
+		mockUseCase.AssertExpectations(t)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	mockUseCase := new(MockUserUseCase)
+	userController := NewUserController(mockUseCase)
+
+	t.Run("List a page of users", func(t *testing.T) {
+		createdAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		filter := model.UserFilter{
+			PageRequest:    model.PageRequest{Limit: 1, Sort: "-username"},
+			CreatedRange:   model.CreatedRange{CreatedAfter: &createdAfter},
+			UsernamePrefix: "jo",
+			Role:           "admin",
+		}
+		users := []model.User{{ID: 7, Username: "john_doe", Password: "hashed", Roles: []model.Role{{ID: 1, Name: "admin"}}}}
+		mockUseCase.On("ListUsers", filter).Return(users, model.PageInfo{Limit: 1, HasMore: true, NextCursor: "abc"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/users?username_prefix=jo&role=admin&sort=-username&limit=1&created_after=2024-01-01T00:00:00Z", nil)
+
+		handle(c, userController.ListUsers)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `</users?created_after=2024-01-01T00%3A00%3A00Z&cursor=abc&limit=1&role=admin&sort=-username&username_prefix=jo>; rel="next"`, w.Header().Get("Link"))
+		assert.Contains(t, w.Body.String(), `"page":{"limit":1,"has_more":true,"next_cursor":"abc"}`)
+		assert.Contains(t, w.Body.String(), `"roles":[{"id":1,"name":"admin"}]`)
+		assert.NotContains(t, w.Body.String(), "hashed")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("The last page has no link", func(t *testing.T) {
+		mockUseCase.On("ListUsers", model.UserFilter{}).Return([]model.User{}, model.PageInfo{Limit: model.DefaultPageLimit}, nil).Once()
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/users", nil)
+
+		handle(c, userController.ListUsers)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Link"))
+		assert.Contains(t, w.Body.String(), `"data":[]`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Reject invalid filters", func(t *testing.T) {
+		mockUseCase := new(MockUserUseCase)
+		userController := NewUserController(mockUseCase)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodGet, "/users?limit=500&status=deleted", nil)
+
+		handle(c, userController.ListUsers)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"invalid_params":[{"name":"limit","reason":"must be at most 200"},{"name":"status","reason":"must be one of pending, active, disabled, locked"}]`)
+		mockUseCase.AssertNotCalled(t, "ListUsers", mock.Anything)
+	})
+}
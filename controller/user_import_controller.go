@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserImportController struct {
+	userImportUseCase domain.UserImportUseCase
+}
+
+func NewUserImportController(userImportUseCase domain.UserImportUseCase) *UserImportController {
+	return &UserImportController{userImportUseCase}
+}
+
+// ImportUsers reads the CSV or JSON lines file from the request body. The
+// format is taken from the format parameter, or else the Content-Type.
+func (d *UserImportController) ImportUsers(c *gin.Context) {
+	var options model.UserImportOptions
+	if !bindQuery(c, &options) {
+		return
+	}
+	if options.Format == "" && (c.ContentType() == "application/x-ndjson" || c.ContentType() == "application/jsonl") {
+		options.Format = model.UserImportFormatJSONL
+	}
+
+	rows, err := model.ParseUserImport(c.Request.Body, options.Format)
+	if err != nil {
+		c.Error(domain.Validation("invalid import file: %v", err))
+		return
+	}
+
+	actorID := c.MustGet("currentUserId").(uint)
+	report, err := d.userImportUseCase.Import(c.Request.Context(), rows, options, actorID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	message := "Imported users success"
+	if report.Failed > 0 {
+		message = "Imported users with failures"
+	}
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    message,
+		Data:       report,
+	})
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserImportUseCase is a mock implementation of the UserImportUseCase interface
+type MockUserImportUseCase struct {
+	mock.Mock
+}
+
+func (m *MockUserImportUseCase) Import(ctx context.Context, rows []model.UserImportRow, options model.UserImportOptions, actorID uint) (model.UserImportReport, error) {
+	args := m.Called(rows, options, actorID)
+	return args.Get(0).(model.UserImportReport), args.Error(1)
+}
+
+func TestUserImportController(t *testing.T) {
+	mockUseCase := new(MockUserImportUseCase)
+	userImportController := NewUserImportController(mockUseCase)
+
+	t.Run("Import CSV", func(t *testing.T) {
+		rows := []model.UserImportRow{{Line: 2, Username: "alice", Password: "Secret-123456"}}
+		options := model.UserImportOptions{Mode: model.UserImportModePerRow}
+		mockUseCase.On("Import", rows, options, uint(1)).Return(model.UserImportReport{
+			Failed: 1,
+			Rows:   []model.UserImportResult{{Line: 2, Username: "alice", Status: model.UserImportFailed, Error: "username alice is already taken"}},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/import?mode=per_row", bytes.NewBufferString("username,password\nalice,Secret-123456\n"))
+		c.Request.Header.Set("Content-Type", "text/csv")
+		c.Set("currentUserId", uint(1))
+
+		handle(c, userImportController.ImportUsers)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Imported users with failures")
+		assert.Contains(t, w.Body.String(), `"status":"failed","error":"username alice is already taken"`)
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Import JSON lines", func(t *testing.T) {
+		rows := []model.UserImportRow{{Line: 1, Username: "bob", Email: "bob@example.com"}}
+		options := model.UserImportOptions{Format: model.UserImportFormatJSONL, Password: model.UserImportPasswordInvite}
+		mockUseCase.On("Import", rows, options, uint(1)).Return(model.UserImportReport{
+			Invited: 1,
+			Rows:    []model.UserImportResult{{Line: 1, Username: "bob", Status: model.UserImportInvited, UserID: 7}},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/import?password=invite", bytes.NewBufferString(`{"username":"bob","email":"bob@example.com"}`))
+		c.Request.Header.Set("Content-Type", "application/x-ndjson")
+		c.Set("currentUserId", uint(1))
+
+		handle(c, userImportController.ImportUsers)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Imported users success")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Malformed file", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/users/import", bytes.NewBufferString("name\nalice\n"))
+		c.Set("currentUserId", uint(1))
+
+		handle(c, userImportController.ImportUsers)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `invalid import file: unknown column \"name\"`)
+	})
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	// Report fields by the name clients send them with
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			if name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	validate.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return model.ValidUsername(fl.Field().String())
+	})
+	validate.RegisterValidation("name", func(fl validator.FieldLevel) bool {
+		return model.ValidName(fl.Field().String())
+	})
+}
+
+// bindJSON decodes and validates the request body into request. On failure it
+// records the error, listing every invalid field, and reports false.
+func bindJSON(c *gin.Context, request interface{}) bool {
+	return bindOK(c, c.ShouldBindJSON(request))
+}
+
+// bindQuery is bindJSON for the query parameters, such as list filters.
+func bindQuery(c *gin.Context, request interface{}) bool {
+	return bindOK(c, c.ShouldBindQuery(request))
+}
+
+func bindOK(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var (
+		validationErrs validator.ValidationErrors
+		typeErr        *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]model.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			// Field includes the index of slice elements, e.g. "role_ids[1]"
+			fields = append(fields, model.FieldError{Name: fieldErr.Field(), Reason: fieldReason(fieldErr)})
+		}
+		c.Error(domain.InvalidFields(fields...))
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		c.Error(domain.InvalidFields(model.FieldError{Name: typeErr.Field, Reason: "must be a " + typeErr.Type.String()}))
+	default:
+		c.Error(err).SetType(gin.ErrorTypeBind)
+	}
+	return false
+}
+
+// paramID parses the named path parameter as an entity id. On failure it
+// records the error and reports false.
+func paramID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		c.Error(domain.InvalidFields(model.FieldError{Name: name, Reason: "must be a positive integer"}))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func fieldReason(fieldErr validator.FieldError) string {
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map:
+		unit = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if unit == "" {
+			return "must be at least " + fieldErr.Param()
+		}
+		return fmt.Sprintf("must contain at least %s%s", fieldErr.Param(), unit)
+	case "max":
+		if unit == "" {
+			return "must be at most " + fieldErr.Param()
+		}
+		return fmt.Sprintf("must contain at most %s%s", fieldErr.Param(), unit)
+	case "gt":
+		return "must be greater than " + fieldErr.Param()
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid url"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "username":
+		return "may only contain letters, digits, '.', '_' and '-'"
+	case "name":
+		return model.NameReason
+	}
+	return "is invalid"
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"bytes"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamID(t *testing.T) {
+	for _, value := range []string{"7", "1 OR 1=1", "0", "-1", "99999999999"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "userID", Value: value}}
+
+		id, ok := paramID(c, "userID")
+
+		if value == "7" {
+			assert.True(t, ok)
+			assert.Equal(t, uint(7), id)
+			assert.Empty(t, c.Errors)
+			continue
+		}
+		assert.False(t, ok, "expected %q to be rejected", value)
+		assert.EqualError(t, c.Errors.Last().Err, "request has invalid fields")
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+	t.Run("Reports nested fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(`{"username":"jane_doe","email":"jane@example.com","role_ids":[1,0]}`))
+
+		var request model.InvitationRequest
+		assert.False(t, handleBind(c, &request))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"invalid_params":[{"name":"role_ids[1]","reason":"must be greater than 0"}]`)
+	})
+
+	t.Run("Reports fields of the wrong type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/invitations", bytes.NewBufferString(`{"username":"jane_doe","email":"jane@example.com","role_ids":"admin"}`))
+
+		var request model.InvitationRequest
+		assert.False(t, handleBind(c, &request))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"invalid_params":[{"name":"role_ids","reason":"must be a []uint"}]`)
+	})
+}
+
+// handleBind runs bindJSON as a handler would and renders its error.
+func handleBind(c *gin.Context, request interface{}) bool {
+	var ok bool
+	handle(c, func(c *gin.Context) { ok = bindJSON(c, request) })
+	return ok
+}
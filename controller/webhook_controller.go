@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookController struct {
+	webhookUseCase domain.WebhookUseCase
+}
+
+func NewWebhookController(webhookUseCase domain.WebhookUseCase) *WebhookController {
+	return &WebhookController{webhookUseCase}
+}
+
+func (d *WebhookController) CreateWebhook(c *gin.Context) {
+	var webhook model.Webhook
+	if !bindJSON(c, &webhook) {
+		return
+	}
+
+	webhookResponse, err := d.webhookUseCase.CreateWebhook(c.Request.Context(), webhook)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, model.Response{
+		StatusCode: http.StatusCreated,
+		Message:    "Created webhook success",
+		Data:       webhookResponse,
+	})
+}
+
+func (d *WebhookController) ListWebhooks(c *gin.Context) {
+	webhooks, err := d.webhookUseCase.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "List webhooks success",
+		Data:       webhooks,
+	})
+}
+
+func (d *WebhookController) DeleteWebhook(c *gin.Context) {
+	webhookID, ok := paramID(c, "webhookID")
+	if !ok {
+		return
+	}
+
+	if err := d.webhookUseCase.DeleteWebhook(c.Request.Context(), webhookID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "Deleted webhook success",
+	})
+}
+
+func (d *WebhookController) ListDeliveries(c *gin.Context) {
+	webhookID, ok := paramID(c, "webhookID")
+	if !ok {
+		return
+	}
+
+	deliveries, err := d.webhookUseCase.ListDeliveries(c.Request.Context(), webhookID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.Response{
+		StatusCode: http.StatusOK,
+		Message:    "List deliveries success",
+		Data:       deliveries,
+	})
+}
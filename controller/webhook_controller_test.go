@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookUseCase is a mock implementation of the WebhookUseCase interface
+type MockWebhookUseCase struct {
+	mock.Mock
+}
+
+func (m *MockWebhookUseCase) CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	args := m.Called(webhook)
+	return args.Get(0).(model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	args := m.Called(webhookID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookUseCase) ListDeliveries(ctx context.Context, webhookID uint) ([]model.WebhookDelivery, error) {
+	args := m.Called(webhookID)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookUseCase) DispatchPending(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestWebhookController(t *testing.T) {
+	mockUseCase := new(MockWebhookUseCase)
+	webhookController := NewWebhookController(mockUseCase)
+
+	t.Run("Create webhook successfully", func(t *testing.T) {
+		input := model.Webhook{URL: "https://hr.example.com/hook", Events: []string{model.EventUserRoleAssigned}}
+		mockUseCase.On("CreateWebhook", input).Return(model.Webhook{ID: 1, URL: input.URL, Secret: "generated"}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(`{"url":"https://hr.example.com/hook","events":["user.role_assigned"]}`))
+
+		handle(c, webhookController.CreateWebhook)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), "Created webhook success")
+		assert.Contains(t, w.Body.String(), "generated")
+		mockUseCase.AssertExpectations(t)
+	})
+
+	t.Run("Create webhook with invalid JSON", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(`{"url":`))
+
+		handle(c, webhookController.CreateWebhook)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unexpected EOF")
+	})
+
+	t.Run("List deliveries with error", func(t *testing.T) {
+		mockUseCase.On("ListDeliveries", uint(9)).Return([]model.WebhookDelivery(nil), domain.NotFound("webhook not found"))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Params = gin.Params{gin.Param{Key: "webhookID", Value: "9"}}
+		c.Request, _ = http.NewRequest(http.MethodGet, "/webhooks/9/deliveries", nil)
+
+		handle(c, webhookController.ListDeliveries)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"detail":"webhook not found"`)
+		mockUseCase.AssertExpectations(t)
+	})
+}
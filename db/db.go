@@ -5,20 +5,108 @@ import (
 	"go-multirole/config"
 	"go-multirole/model"
 	"log"
+	"net"
+	"net/url"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// InitDB connects to the database selected by SQL_DRIVER. It applies pending
+// migrations when SQL_MIGRATE_ON_START is set and otherwise refuses to start
+// on an outdated schema.
 func InitDB(config *config.Config) *gorm.DB {
-	dsn := fmt.Sprintf("%s:%s@tcp(127.0.0.1:3306)/%s?charset=utf8mb4&parseTime=True&loc=Local", config.DBUsername, config.DBPassword, config.DBName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	dialector, err := Dialector(config)
 	if err != nil {
-		log.Fatal("failed to connect to database")
+		log.Fatal("failed to configure the database: ", err)
 	}
 
-	// Automatically migrate schema
-	db.AutoMigrate(&model.User{}, &model.Role{}, &model.Permission{})
+	db, err := Open(dialector)
+	if err != nil {
+		log.Fatal("failed to connect to database: ", err)
+	}
 
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		log.Fatal("failed to load migrations: ", err)
+	}
+	if config.DBMigrateOnStart {
+		err = migrator.Up()
+	} else {
+		err = migrator.Check()
+	}
+	if err != nil {
+		log.Fatal("failed to migrate the database, run `go-multirole migrate status` for details: ", err)
+	}
 	return db
 }
+
+// Dialector returns the gorm driver for the configured database. MySQL is
+// used when no driver is set; for SQLite, SQL_DB is the database file.
+func Dialector(config *config.Config) (gorm.Dialector, error) {
+	switch config.DBDriver {
+	case "mysql", "":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			config.DBUsername, config.DBPassword, hostPort(config, "3306"), config.DBName)
+		return mysql.Open(dsn), nil
+	case "postgres":
+		dsn := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(config.DBUsername, config.DBPassword),
+			Host:   hostPort(config, "5432"),
+			Path:   "/" + config.DBName,
+		}
+		if config.DBSSLMode != "" {
+			dsn.RawQuery = url.Values{"sslmode": {config.DBSSLMode}}.Encode()
+		}
+		return postgres.Open(dsn.String()), nil
+	case "sqlite":
+		return sqlite.Open(config.DBName), nil
+	}
+	return nil, fmt.Errorf("unknown SQL_DRIVER %q, expected mysql, postgres or sqlite", config.DBDriver)
+}
+
+// Open connects with the settings the repositories rely on. The schema is
+// managed by Migrator.
+func Open(dialector gorm.Dialector) (*gorm.DB, error) {
+	// TranslateError reports duplicate keys as gorm.ErrDuplicatedKey for the repositories
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Custom join tables add the indexes used to filter lists by role and permission
+	if err := db.SetupJoinTable(&model.User{}, "Roles", &model.UserRole{}); err != nil {
+		return nil, err
+	}
+	if err := db.SetupJoinTable(&model.Role{}, "Permissions", &model.RolePermission{}); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Models returns every model with a table. Migrations must create a column for
+// each of their fields.
+func Models() []interface{} {
+	return []interface{}{
+		&model.User{}, &model.Role{}, &model.Permission{},
+		&model.Webhook{}, &model.OutboxEvent{}, &model.WebhookDelivery{},
+		&model.APIKey{},
+		&model.OAuthClient{}, &model.RevokedToken{}, &model.AuthorizationCode{},
+		&model.RecoveryCode{}, &model.LoginThrottle{}, &model.PasswordHistory{}, &model.PasswordResetToken{},
+		&model.UserStatusChange{}, &model.Invitation{},
+	}
+}
+
+func hostPort(config *config.Config, defaultPort string) string {
+	host, port := config.DBHost, config.DBPort
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if port == "" {
+		port = defaultPort
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	createTablePattern = regexp.MustCompile("(?s)^CREATE TABLE IF NOT EXISTS [`\"](\\w+)[`\"] \\((.*)\\)$")
+	inlineIndexPattern = regexp.MustCompile("^(UNIQUE )?INDEX `(\\w+)` (\\(.*\\))$")
+	varcharPattern     = regexp.MustCompile(`^varchar\((\d+)\)`)
+)
+
+// upgradeLegacy brings the tables of a database created before migrations
+// were versioned to the shape the first migration creates, which then adopts
+// them. AutoMigrate only ever added columns and indexes, so whichever build
+// created the database, its tables lack some columns and indexes and may have
+// narrower varchar columns, and that is all this fixes. Tables that don't
+// exist yet are left to the migration.
+func upgradeLegacy(tx *gorm.DB, first Migration) error {
+	for _, statement := range splitStatements(first.up) {
+		match := createTablePattern.FindStringSubmatch(statement)
+		if match == nil || !tx.Migrator().HasTable(match[1]) {
+			continue
+		}
+		if err := upgradeLegacyTable(tx, match[1], splitDefinitions(match[2])); err != nil {
+			return fmt.Errorf("upgrading table %s: %w", match[1], err)
+		}
+	}
+	return nil
+}
+
+func upgradeLegacyTable(tx *gorm.DB, table string, definitions []string) error {
+	columnTypes, err := tx.Migrator().ColumnTypes(table)
+	if err != nil {
+		return err
+	}
+	lengths := map[string]int64{}
+	for _, columnType := range columnTypes {
+		length, _ := columnType.Length()
+		lengths[columnType.Name()] = length
+	}
+
+	dialect := tx.Dialector.Name()
+	for _, definition := range definitions {
+		// MySQL declares the indexes with the table
+		if index := inlineIndexPattern.FindStringSubmatch(definition); index != nil {
+			if !tx.Migrator().HasIndex(table, index[2]) {
+				statement := fmt.Sprintf("CREATE %sINDEX `%s` ON `%s` %s", index[1], index[2], table, index[3])
+				if err := tx.Exec(statement).Error; err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !strings.HasPrefix(definition, "`") && !strings.HasPrefix(definition, `"`) {
+			continue // Keys and constraints
+		}
+
+		name, columnType, _ := strings.Cut(definition[1:], definition[:1]+" ")
+		length, exists := lengths[name]
+		if !exists {
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", tx.Statement.Quote(table), definition)).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		// SQLite doesn't enforce lengths, the others need wider columns
+		varchar := varcharPattern.FindStringSubmatch(columnType)
+		if varchar == nil || dialect == "sqlite" {
+			continue
+		}
+		if want, _ := strconv.ParseInt(varchar[1], 10, 64); length >= want {
+			continue
+		}
+		statement := fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE %s`, tx.Statement.Quote(table), tx.Statement.Quote(name), varchar[0])
+		if dialect == "mysql" {
+			statement = fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN %s", table, definition)
+		}
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitDefinitions splits the body of a CREATE TABLE on the commas outside
+// parentheses.
+func splitDefinitions(body string) []string {
+	var definitions []string
+	depth, start := 0, 0
+	for i, r := range body {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, strings.TrimSpace(body[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(definitions, strings.TrimSpace(body[start:]))
+}
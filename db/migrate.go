@@ -0,0 +1,400 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles holds the versioned schema changes of every database, named
+// <version>_<name>.up.sql and <version>_<name>.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// DefaultMigrationLockTimeout is how long to wait for another instance to
+// finish migrating.
+const DefaultMigrationLockTimeout = 5 * time.Minute
+
+const migrationLockName = "go_multirole_schema_migrations"
+
+// ErrSchemaOutdated is returned by Check when migrations are pending.
+var ErrSchemaOutdated = errors.New("database schema is outdated")
+
+// Migration is one versioned schema change and the statements undoing it.
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus describes a migration known to this build or found applied
+// in the database. AppliedAt is nil while it's pending.
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+	// Unknown marks migrations applied by a newer build.
+	Unknown bool
+}
+
+// schemaMigration records an applied migration.
+type schemaMigration struct {
+	Version   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(255)"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and reverts the migrations of the database's dialect. Only
+// one instance migrates at a time: MySQL and PostgreSQL hold a database-wide
+// lock while migrating, and SQLite serializes the migration transactions.
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	LockTimeout time.Duration
+}
+
+// NewMigrator loads the migrations embedded for the database's dialect.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations, LockTimeout: DefaultMigrationLockTimeout}, nil
+}
+
+// Latest returns the version the schema of this build is at.
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every migration, known or applied, by version.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		record := record
+		statuses = append(statuses, MigrationStatus{Version: record.Version, Name: record.Name, AppliedAt: &record.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Check returns ErrSchemaOutdated unless every migration of this build has
+// been applied. Migrations applied by a newer build are tolerated, so an
+// older build can still be rolled back to.
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w, pending migrations: %s", ErrSchemaOutdated, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// To applies or reverts migrations until the schema is at version. Version 0
+// reverts every migration.
+func (m *Migrator) To(version uint) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.locked(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		if err := m.revert(applied, func(v uint) bool { return v > version }); err != nil {
+			return err
+		}
+		// Databases created before migrations were versioned are adopted
+		// by the first migration once their tables are brought up to it
+		if len(applied) == 0 && version > 0 {
+			if err := upgradeLegacy(m.db, m.migrations[0]); err != nil {
+				return err
+			}
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.run(migration, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts the last steps applied migrations.
+func (m *Migrator) Down(steps int) error {
+	return m.locked(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		versions := appliedVersions(applied)
+		if steps < len(versions) {
+			versions = versions[:steps]
+		}
+		last := make(map[uint]bool, len(versions))
+		for _, v := range versions {
+			last[v] = true
+		}
+		return m.revert(applied, func(v uint) bool { return last[v] })
+	})
+}
+
+// revert reverts the applied migrations selected by include, newest first.
+func (m *Migrator) revert(applied map[uint]schemaMigration, include func(version uint) bool) error {
+	for _, version := range appliedVersions(applied) {
+		if !include(version) {
+			continue
+		}
+		migration := m.find(version)
+		if migration == nil {
+			return fmt.Errorf("migration %d was applied by a newer build and can't be reverted by this one", version)
+		}
+		if err := m.run(*migration, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run applies or reverts a migration along with its record. Statements run in
+// one transaction, but MySQL commits each schema change on its own, so a
+// failing MySQL migration may need cleaning up by hand.
+func (m *Migrator) run(migration Migration, up bool) error {
+	script, direction := migration.down, "down"
+	if up {
+		script, direction = migration.up, "up"
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range splitStatements(script) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrating %s %d_%s: %w", direction, migration.Version, migration.Name, err)
+	}
+	log.Printf("migrated %s %d_%s", direction, migration.Version, migration.Name)
+	return nil
+}
+
+func (m *Migrator) applied() (map[uint]schemaMigration, error) {
+	applied := map[uint]schemaMigration{}
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+	var records []schemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// locked runs fn while holding the migration lock, creating the table of
+// applied migrations first.
+func (m *Migrator) locked(fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.LockTimeout)
+	defer cancel()
+
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !m.db.Migrator().HasTable(&schemaMigration{}) {
+		if err := m.db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return err
+		}
+	}
+	return fn()
+}
+
+// lock takes the database-wide migration lock of MySQL and PostgreSQL. Both
+// belong to the session, so they're held on a connection of their own.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	var acquire, release string
+	switch m.db.Dialector.Name() {
+	case "mysql":
+		acquire, release = "SELECT GET_LOCK(?, 0)", "SELECT RELEASE_LOCK(?)"
+	case "postgres":
+		acquire, release = "SELECT pg_try_advisory_lock(hashtext($1))", "SELECT pg_advisory_unlock(hashtext($1))"
+	default:
+		return func() {}, nil
+	}
+
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, acquire, migrationLockName).Scan(&acquired); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, errors.New("timed out waiting for another instance to finish migrating")
+		case <-time.After(time.Second):
+		}
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), release, migrationLockName)
+		conn.Close()
+	}, nil
+}
+
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s: %w", dialect, err)
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		base, up := strings.TrimSuffix(entry.Name(), ".up.sql"), true
+		if base == entry.Name() {
+			base, up = strings.TrimSuffix(entry.Name(), ".down.sql"), false
+		}
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 32)
+		if base == entry.Name() || !ok || err != nil || version == 0 {
+			return nil, fmt.Errorf("migration file %s/%s isn't named <version>_<name>.up.sql or .down.sql", dir, entry.Name())
+		}
+
+		script, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = migration
+		}
+		if up {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %d_%s of %s needs both an up and a down script", migration.Version, migration.Name, dialect)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements splits a script into statements ending with a semicolon at
+// the end of a line, skipping "--" comments.
+func splitStatements(script string) []string {
+	var statements []string
+	var statement strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(statement.String()), ";"))
+			statement.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+func appliedVersions(applied map[uint]schemaMigration) []uint {
+	versions := make([]uint, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return versions
+}
@@ -0,0 +1,43 @@
+-- The schema AutoMigrate created before migrations were versioned. Every statement
+-- is conditional, and Migrator first adds the columns and indexes that tables
+-- created by an older build lack, so existing databases adopt this version.
+
+CREATE TABLE IF NOT EXISTS `users` (`id` bigint unsigned AUTO_INCREMENT,`username` varchar(100),`password` varchar(255),`email` varchar(255),`sessions_valid_after` datetime(3) NULL,`status` varchar(20) DEFAULT 'active',`status_reason` varchar(255),`status_changed_at` datetime(3) NULL,`service_account` boolean DEFAULT false,`mfa_enabled` boolean DEFAULT false,`totp_secret` varchar(64),`totp_last_step` bigint,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_users_username` (`username`),INDEX `idx_users_email` (`email`),INDEX `idx_users_status` (`status`),INDEX `idx_users_created_at` (`created_at`));
+
+CREATE TABLE IF NOT EXISTS `roles` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(100),`require_mfa` boolean DEFAULT false,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_roles_name` (`name`),INDEX `idx_roles_created_at` (`created_at`));
+
+CREATE TABLE IF NOT EXISTS `user_roles` (`user_id` bigint unsigned,`role_id` bigint unsigned,PRIMARY KEY (`user_id`,`role_id`),INDEX `idx_user_roles_role_id` (`role_id`),CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`));
+
+CREATE TABLE IF NOT EXISTS `permissions` (`id` bigint unsigned AUTO_INCREMENT,`name` varchar(100),`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_permissions_name` (`name`),INDEX `idx_permissions_created_at` (`created_at`));
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (`role_id` bigint unsigned,`permission_id` bigint unsigned,PRIMARY KEY (`role_id`,`permission_id`),INDEX `idx_role_permissions_permission_id` (`permission_id`),CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`));
+
+CREATE TABLE IF NOT EXISTS `webhooks` (`id` bigint unsigned AUTO_INCREMENT,`url` varchar(255),`secret` varchar(100),`events` text,`active` boolean DEFAULT true,`created_at` datetime(3) NULL,PRIMARY KEY (`id`));
+
+CREATE TABLE IF NOT EXISTS `outbox_events` (`id` bigint unsigned AUTO_INCREMENT,`type` varchar(100),`payload` text,`created_at` datetime(3) NULL,`processed_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_outbox_events_type` (`type`),INDEX `idx_outbox_events_processed_at` (`processed_at`));
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (`id` bigint unsigned AUTO_INCREMENT,`webhook_id` bigint unsigned,`event_id` bigint unsigned,`event_type` varchar(100),`status` varchar(20),`attempts` bigint,`next_attempt_at` datetime(3) NULL,`last_status_code` bigint,`last_error` text,`delivered_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_webhook_deliveries_webhook_id` (`webhook_id`),INDEX `idx_webhook_deliveries_event_id` (`event_id`),INDEX `idx_webhook_deliveries_status` (`status`),INDEX `idx_webhook_deliveries_next_attempt_at` (`next_attempt_at`),CONSTRAINT `fk_webhook_deliveries_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`),CONSTRAINT `fk_webhook_deliveries_event` FOREIGN KEY (`event_id`) REFERENCES `outbox_events`(`id`));
+
+CREATE TABLE IF NOT EXISTS `api_keys` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`name` varchar(100),`prefix` varchar(20),`key_hash` varchar(100),`scopes` text,`expires_at` datetime(3) NULL,`last_used_at` datetime(3) NULL,`revoked_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_api_keys_user_id` (`user_id`),UNIQUE INDEX `idx_api_keys_prefix` (`prefix`));
+
+CREATE TABLE IF NOT EXISTS `o_auth_clients` (`id` bigint unsigned AUTO_INCREMENT,`client_id` varchar(64),`secret_hash` varchar(100),`name` varchar(100),`public` boolean DEFAULT false,`redirect_uris` text,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_o_auth_clients_client_id` (`client_id`));
+
+CREATE TABLE IF NOT EXISTS `oauth_client_roles` (`o_auth_client_id` bigint unsigned,`role_id` bigint unsigned,PRIMARY KEY (`o_auth_client_id`,`role_id`),CONSTRAINT `fk_oauth_client_roles_o_auth_client` FOREIGN KEY (`o_auth_client_id`) REFERENCES `o_auth_clients`(`id`),CONSTRAINT `fk_oauth_client_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`));
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (`id` bigint unsigned AUTO_INCREMENT,`jti` varchar(64),`expires_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_revoked_tokens_jti` (`jti`),INDEX `idx_revoked_tokens_expires_at` (`expires_at`));
+
+CREATE TABLE IF NOT EXISTS `authorization_codes` (`id` bigint unsigned AUTO_INCREMENT,`code_hash` varchar(64),`client_id` varchar(64),`user_id` bigint unsigned,`redirect_uri` varchar(255),`scope` varchar(255),`nonce` varchar(255),`code_challenge` varchar(128),`auth_time` datetime(3) NULL,`expires_at` datetime(3) NULL,`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_authorization_codes_code_hash` (`code_hash`),INDEX `idx_authorization_codes_user_id` (`user_id`));
+
+CREATE TABLE IF NOT EXISTS `recovery_codes` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`code_hash` varchar(64),`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_recovery_codes_user_id` (`user_id`));
+
+CREATE TABLE IF NOT EXISTS `login_throttles` (`id` bigint unsigned AUTO_INCREMENT,`throttle_key` varchar(191),`failed_attempts` bigint,`last_failed_at` datetime(3) NULL,`locked_until` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_login_throttles_key` (`throttle_key`));
+
+CREATE TABLE IF NOT EXISTS `password_histories` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`password_hash` varchar(255),`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_password_histories_user_id` (`user_id`));
+
+CREATE TABLE IF NOT EXISTS `password_reset_tokens` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`token_hash` varchar(64),`expires_at` datetime(3) NULL,`used_at` datetime(3) NULL,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_password_reset_tokens_user_id` (`user_id`),UNIQUE INDEX `idx_password_reset_tokens_token_hash` (`token_hash`));
+
+CREATE TABLE IF NOT EXISTS `user_status_changes` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`from_status` varchar(20),`to_status` varchar(20),`reason` varchar(255),`changed_by` bigint unsigned,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_user_status_changes_user_id` (`user_id`));
+
+CREATE TABLE IF NOT EXISTS `invitations` (`id` bigint unsigned AUTO_INCREMENT,`user_id` bigint unsigned,`username` varchar(100),`email` varchar(255),`token_hash` varchar(64),`invited_by` bigint unsigned,`expires_at` datetime(3) NULL,`accepted_at` datetime(3) NULL,`revoked_at` datetime(3) NULL,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_invitations_user_id` (`user_id`),UNIQUE INDEX `idx_invitations_token_hash` (`token_hash`));
+
+CREATE TABLE IF NOT EXISTS `invitation_roles` (`invitation_id` bigint unsigned,`role_id` bigint unsigned,PRIMARY KEY (`invitation_id`,`role_id`),CONSTRAINT `fk_invitation_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),CONSTRAINT `fk_invitation_roles_invitation` FOREIGN KEY (`invitation_id`) REFERENCES `invitations`(`id`));
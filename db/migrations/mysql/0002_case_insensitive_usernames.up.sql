@@ -0,0 +1,2 @@
+-- Usernames are unique regardless of case. MySQL's default collation already
+-- compares them that way, so idx_users_username is enough.
@@ -0,0 +1,2 @@
+-- Fails while keys with the longer prefixes exist; revoke and delete them first.
+ALTER TABLE `api_keys` MODIFY COLUMN `prefix` varchar(20);
@@ -0,0 +1 @@
+DROP INDEX `idx_webhook_deliveries_webhook_event` ON `webhook_deliveries`;
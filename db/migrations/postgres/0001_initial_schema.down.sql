@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS "invitation_roles";
+DROP TABLE IF EXISTS "invitations";
+DROP TABLE IF EXISTS "user_status_changes";
+DROP TABLE IF EXISTS "password_reset_tokens";
+DROP TABLE IF EXISTS "password_histories";
+DROP TABLE IF EXISTS "login_throttles";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP TABLE IF EXISTS "authorization_codes";
+DROP TABLE IF EXISTS "revoked_tokens";
+DROP TABLE IF EXISTS "oauth_client_roles";
+DROP TABLE IF EXISTS "o_auth_clients";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "outbox_events";
+DROP TABLE IF EXISTS "webhooks";
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "permissions";
+DROP TABLE IF EXISTS "user_roles";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "users";
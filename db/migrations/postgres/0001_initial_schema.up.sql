@@ -0,0 +1,74 @@
+-- The schema AutoMigrate created before migrations were versioned. Every statement
+-- is conditional, and Migrator first adds the columns and indexes that tables
+-- created by an older build lack, so existing databases adopt this version.
+
+CREATE TABLE IF NOT EXISTS "users" ("id" bigserial,"username" varchar(100),"password" varchar(255),"email" varchar(255),"sessions_valid_after" timestamptz,"status" varchar(20) DEFAULT 'active',"status_reason" varchar(255),"status_changed_at" timestamptz,"service_account" boolean DEFAULT false,"mfa_enabled" boolean DEFAULT false,"totp_secret" varchar(64),"totp_last_step" bigint,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_users_created_at" ON "users" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_users_status" ON "users" ("status");
+CREATE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+
+CREATE TABLE IF NOT EXISTS "roles" ("id" bigserial,"name" varchar(100),"require_mfa" boolean DEFAULT false,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_roles_created_at" ON "roles" ("created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE IF NOT EXISTS "user_roles" ("user_id" bigint,"role_id" bigint,PRIMARY KEY ("user_id","role_id"),CONSTRAINT "fk_user_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"),CONSTRAINT "fk_user_roles_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"));
+CREATE INDEX IF NOT EXISTS "idx_user_roles_role_id" ON "user_roles" ("role_id");
+
+CREATE TABLE IF NOT EXISTS "permissions" ("id" bigserial,"name" varchar(100),"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_permissions_created_at" ON "permissions" ("created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE IF NOT EXISTS "role_permissions" ("role_id" bigint,"permission_id" bigint,PRIMARY KEY ("role_id","permission_id"),CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"),CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id"));
+CREATE INDEX IF NOT EXISTS "idx_role_permissions_permission_id" ON "role_permissions" ("permission_id");
+
+CREATE TABLE IF NOT EXISTS "webhooks" ("id" bigserial,"url" varchar(255),"secret" varchar(100),"events" text,"active" boolean DEFAULT true,"created_at" timestamptz,PRIMARY KEY ("id"));
+
+CREATE TABLE IF NOT EXISTS "outbox_events" ("id" bigserial,"type" varchar(100),"payload" text,"created_at" timestamptz,"processed_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_processed_at" ON "outbox_events" ("processed_at");
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_type" ON "outbox_events" ("type");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" ("id" bigserial,"webhook_id" bigint,"event_id" bigint,"event_type" varchar(100),"status" varchar(20),"attempts" bigint,"next_attempt_at" timestamptz,"last_status_code" bigint,"last_error" text,"delivered_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"),CONSTRAINT "fk_webhook_deliveries_webhook" FOREIGN KEY ("webhook_id") REFERENCES "webhooks"("id"),CONSTRAINT "fk_webhook_deliveries_event" FOREIGN KEY ("event_id") REFERENCES "outbox_events"("id"));
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_event_id" ON "webhook_deliveries" ("event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_webhook_id" ON "webhook_deliveries" ("webhook_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_next_attempt_at" ON "webhook_deliveries" ("next_attempt_at");
+CREATE INDEX IF NOT EXISTS "idx_webhook_deliveries_status" ON "webhook_deliveries" ("status");
+
+CREATE TABLE IF NOT EXISTS "api_keys" ("id" bigserial,"user_id" bigint,"name" varchar(100),"prefix" varchar(20),"key_hash" varchar(100),"scopes" text,"expires_at" timestamptz,"last_used_at" timestamptz,"revoked_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_prefix" ON "api_keys" ("prefix");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_user_id" ON "api_keys" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "o_auth_clients" ("id" bigserial,"client_id" varchar(64),"secret_hash" varchar(100),"name" varchar(100),"public" boolean DEFAULT false,"redirect_uris" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_o_auth_clients_client_id" ON "o_auth_clients" ("client_id");
+
+CREATE TABLE IF NOT EXISTS "oauth_client_roles" ("o_auth_client_id" bigint,"role_id" bigint,PRIMARY KEY ("o_auth_client_id","role_id"),CONSTRAINT "fk_oauth_client_roles_o_auth_client" FOREIGN KEY ("o_auth_client_id") REFERENCES "o_auth_clients"("id"),CONSTRAINT "fk_oauth_client_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"));
+
+CREATE TABLE IF NOT EXISTS "revoked_tokens" ("id" bigserial,"jti" varchar(64),"expires_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_revoked_tokens_expires_at" ON "revoked_tokens" ("expires_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_revoked_tokens_jti" ON "revoked_tokens" ("jti");
+
+CREATE TABLE IF NOT EXISTS "authorization_codes" ("id" bigserial,"code_hash" varchar(64),"client_id" varchar(64),"user_id" bigint,"redirect_uri" varchar(255),"scope" varchar(255),"nonce" varchar(255),"code_challenge" varchar(128),"auth_time" timestamptz,"expires_at" timestamptz,"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_authorization_codes_user_id" ON "authorization_codes" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_authorization_codes_code_hash" ON "authorization_codes" ("code_hash");
+
+CREATE TABLE IF NOT EXISTS "recovery_codes" ("id" bigserial,"user_id" bigint,"code_hash" varchar(64),"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "login_throttles" ("id" bigserial,"throttle_key" varchar(191),"failed_attempts" bigint,"last_failed_at" timestamptz,"locked_until" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_login_throttles_key" ON "login_throttles" ("throttle_key");
+
+CREATE TABLE IF NOT EXISTS "password_histories" ("id" bigserial,"user_id" bigint,"password_hash" varchar(255),"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_password_histories_user_id" ON "password_histories" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "password_reset_tokens" ("id" bigserial,"user_id" bigint,"token_hash" varchar(64),"expires_at" timestamptz,"used_at" timestamptz,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_status_changes" ("id" bigserial,"user_id" bigint,"from_status" varchar(20),"to_status" varchar(20),"reason" varchar(255),"changed_by" bigint,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX IF NOT EXISTS "idx_user_status_changes_user_id" ON "user_status_changes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "invitations" ("id" bigserial,"user_id" bigint,"username" varchar(100),"email" varchar(255),"token_hash" varchar(64),"invited_by" bigint,"expires_at" timestamptz,"accepted_at" timestamptz,"revoked_at" timestamptz,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_invitations_token_hash" ON "invitations" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_invitations_user_id" ON "invitations" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "invitation_roles" ("invitation_id" bigint,"role_id" bigint,PRIMARY KEY ("invitation_id","role_id"),CONSTRAINT "fk_invitation_roles_invitation" FOREIGN KEY ("invitation_id") REFERENCES "invitations"("id"),CONSTRAINT "fk_invitation_roles_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"));
@@ -0,0 +1,4 @@
+-- Usernames are unique regardless of case, like under MySQL's default
+-- collation. Fails while users differing only in case exist; rename one first.
+
+CREATE UNIQUE INDEX "idx_users_username_lower" ON "users" (LOWER("username"));
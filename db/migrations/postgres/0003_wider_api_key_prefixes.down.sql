@@ -0,0 +1,2 @@
+-- Fails while keys with the longer prefixes exist; revoke and delete them first.
+ALTER TABLE "api_keys" ALTER COLUMN "prefix" TYPE varchar(20);
@@ -0,0 +1,2 @@
+-- API key prefixes carry 64 random bits, which no longer fit 20 characters.
+ALTER TABLE "api_keys" ALTER COLUMN "prefix" TYPE varchar(32);
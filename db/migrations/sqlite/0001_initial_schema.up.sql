@@ -0,0 +1,74 @@
+-- The schema AutoMigrate created before migrations were versioned. Every statement
+-- is conditional, and Migrator first adds the columns and indexes that tables
+-- created by an older build lack, so existing databases adopt this version.
+
+CREATE TABLE IF NOT EXISTS `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`username` varchar(100),`password` varchar(255),`email` varchar(255),`sessions_valid_after` datetime,`status` varchar(20) DEFAULT 'active',`status_reason` varchar(255),`status_changed_at` datetime,`service_account` numeric DEFAULT false,`mfa_enabled` numeric DEFAULT false,`totp_secret` varchar(64),`totp_last_step` integer,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_users_created_at` ON `users`(`created_at`);
+CREATE INDEX IF NOT EXISTS `idx_users_status` ON `users`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_users_email` ON `users`(`email`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_username` ON `users`(`username`);
+
+CREATE TABLE IF NOT EXISTS `roles` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` varchar(100),`require_mfa` numeric DEFAULT false,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_roles_created_at` ON `roles`(`created_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_roles_name` ON `roles`(`name`);
+
+CREATE TABLE IF NOT EXISTS `user_roles` (`user_id` integer,`role_id` integer,PRIMARY KEY (`user_id`,`role_id`),CONSTRAINT `fk_user_roles_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),CONSTRAINT `fk_user_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_user_roles_role_id` ON `user_roles`(`role_id`);
+
+CREATE TABLE IF NOT EXISTS `permissions` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` varchar(100),`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_permissions_created_at` ON `permissions`(`created_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_permissions_name` ON `permissions`(`name`);
+
+CREATE TABLE IF NOT EXISTS `role_permissions` (`role_id` integer,`permission_id` integer,PRIMARY KEY (`role_id`,`permission_id`),CONSTRAINT `fk_role_permissions_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),CONSTRAINT `fk_role_permissions_permission` FOREIGN KEY (`permission_id`) REFERENCES `permissions`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_role_permissions_permission_id` ON `role_permissions`(`permission_id`);
+
+CREATE TABLE IF NOT EXISTS `webhooks` (`id` integer PRIMARY KEY AUTOINCREMENT,`url` varchar(255),`secret` varchar(100),`events` text,`active` numeric DEFAULT true,`created_at` datetime);
+
+CREATE TABLE IF NOT EXISTS `outbox_events` (`id` integer PRIMARY KEY AUTOINCREMENT,`type` varchar(100),`payload` text,`created_at` datetime,`processed_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_outbox_events_processed_at` ON `outbox_events`(`processed_at`);
+CREATE INDEX IF NOT EXISTS `idx_outbox_events_type` ON `outbox_events`(`type`);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (`id` integer PRIMARY KEY AUTOINCREMENT,`webhook_id` integer,`event_id` integer,`event_type` varchar(100),`status` varchar(20),`attempts` integer,`next_attempt_at` datetime,`last_status_code` integer,`last_error` text,`delivered_at` datetime,`created_at` datetime,CONSTRAINT `fk_webhook_deliveries_webhook` FOREIGN KEY (`webhook_id`) REFERENCES `webhooks`(`id`),CONSTRAINT `fk_webhook_deliveries_event` FOREIGN KEY (`event_id`) REFERENCES `outbox_events`(`id`));
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_next_attempt_at` ON `webhook_deliveries`(`next_attempt_at`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_status` ON `webhook_deliveries`(`status`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_event_id` ON `webhook_deliveries`(`event_id`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook_id` ON `webhook_deliveries`(`webhook_id`);
+
+CREATE TABLE IF NOT EXISTS `api_keys` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`name` varchar(100),`prefix` varchar(20),`key_hash` varchar(100),`scopes` text,`expires_at` datetime,`last_used_at` datetime,`revoked_at` datetime,`created_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_api_keys_prefix` ON `api_keys`(`prefix`);
+CREATE INDEX IF NOT EXISTS `idx_api_keys_user_id` ON `api_keys`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `o_auth_clients` (`id` integer PRIMARY KEY AUTOINCREMENT,`client_id` varchar(64),`secret_hash` varchar(100),`name` varchar(100),`public` numeric DEFAULT false,`redirect_uris` text,`created_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_o_auth_clients_client_id` ON `o_auth_clients`(`client_id`);
+
+CREATE TABLE IF NOT EXISTS `oauth_client_roles` (`o_auth_client_id` integer,`role_id` integer,PRIMARY KEY (`o_auth_client_id`,`role_id`),CONSTRAINT `fk_oauth_client_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`),CONSTRAINT `fk_oauth_client_roles_o_auth_client` FOREIGN KEY (`o_auth_client_id`) REFERENCES `o_auth_clients`(`id`));
+
+CREATE TABLE IF NOT EXISTS `revoked_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`jti` varchar(64),`expires_at` datetime,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_revoked_tokens_expires_at` ON `revoked_tokens`(`expires_at`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_revoked_tokens_jti` ON `revoked_tokens`(`jti`);
+
+CREATE TABLE IF NOT EXISTS `authorization_codes` (`id` integer PRIMARY KEY AUTOINCREMENT,`code_hash` varchar(64),`client_id` varchar(64),`user_id` integer,`redirect_uri` varchar(255),`scope` varchar(255),`nonce` varchar(255),`code_challenge` varchar(128),`auth_time` datetime,`expires_at` datetime,`used_at` datetime,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_authorization_codes_user_id` ON `authorization_codes`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_authorization_codes_code_hash` ON `authorization_codes`(`code_hash`);
+
+CREATE TABLE IF NOT EXISTS `recovery_codes` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`code_hash` varchar(64),`used_at` datetime,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `login_throttles` (`id` integer PRIMARY KEY AUTOINCREMENT,`throttle_key` varchar(191),`failed_attempts` integer,`last_failed_at` datetime,`locked_until` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_login_throttles_key` ON `login_throttles`(`throttle_key`);
+
+CREATE TABLE IF NOT EXISTS `password_histories` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`password_hash` varchar(255),`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_password_histories_user_id` ON `password_histories`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `password_reset_tokens` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`token_hash` varchar(64),`expires_at` datetime,`used_at` datetime,`created_at` datetime);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_password_reset_tokens_token_hash` ON `password_reset_tokens`(`token_hash`);
+CREATE INDEX IF NOT EXISTS `idx_password_reset_tokens_user_id` ON `password_reset_tokens`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `user_status_changes` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`from_status` varchar(20),`to_status` varchar(20),`reason` varchar(255),`changed_by` integer,`created_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_user_status_changes_user_id` ON `user_status_changes`(`user_id`);
+
+CREATE TABLE IF NOT EXISTS `invitations` (`id` integer PRIMARY KEY AUTOINCREMENT,`user_id` integer,`username` varchar(100),`email` varchar(255),`token_hash` varchar(64),`invited_by` integer,`expires_at` datetime,`accepted_at` datetime,`revoked_at` datetime,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX IF NOT EXISTS `idx_invitations_user_id` ON `invitations`(`user_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_invitations_token_hash` ON `invitations`(`token_hash`);
+
+CREATE TABLE IF NOT EXISTS `invitation_roles` (`invitation_id` integer,`role_id` integer,PRIMARY KEY (`invitation_id`,`role_id`),CONSTRAINT `fk_invitation_roles_invitation` FOREIGN KEY (`invitation_id`) REFERENCES `invitations`(`id`),CONSTRAINT `fk_invitation_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`));
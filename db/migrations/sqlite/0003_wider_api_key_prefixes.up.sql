@@ -0,0 +1,2 @@
+-- API key prefixes carry 64 random bits, which no longer fit 20 characters.
+-- SQLite doesn't enforce varchar lengths, so the column already fits them.
@@ -0,0 +1,4 @@
+-- An outbox event is delivered to each webhook at most once. Duplicates
+-- created by instances fanning out the same event are dropped first.
+DELETE FROM `webhook_deliveries` WHERE `id` NOT IN (SELECT `id` FROM (SELECT MIN(`id`) AS `id` FROM `webhook_deliveries` GROUP BY `webhook_id`, `event_id`) AS `kept`);
+CREATE UNIQUE INDEX `idx_webhook_deliveries_webhook_event` ON `webhook_deliveries` (`webhook_id`, `event_id`);
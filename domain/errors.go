@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"go-multirole/model"
+)
+
+// Kinds of domain errors. Every error the repositories and use cases expect a
+// client to act on is an *Error of one of these kinds, which decides the HTTP
+// status it is rendered with; anything else is an internal error.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Error is a domain error of a known kind. errors.Is matches it against its
+// kind, itself and the error it wraps, if any.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+	Fields  []model.FieldError // The offending fields of an ErrValidation error, if known
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// NotFound creates an ErrNotFound error. Like fmt.Errorf, a %w verb wraps its operand.
+func NotFound(format string, args ...interface{}) error {
+	return newError(ErrNotFound, format, args...)
+}
+
+// Conflict creates an ErrConflict error for requests clashing with the current
+// state, such as duplicate names.
+func Conflict(format string, args ...interface{}) error {
+	return newError(ErrConflict, format, args...)
+}
+
+// Validation creates an ErrValidation error for malformed or rejected input.
+func Validation(format string, args ...interface{}) error {
+	return newError(ErrValidation, format, args...)
+}
+
+// InvalidFields creates an ErrValidation error listing every rejected field,
+// so clients can fix all of them at once.
+func InvalidFields(fields ...model.FieldError) error {
+	return &Error{Kind: ErrValidation, Message: "request has invalid fields", Fields: fields}
+}
+
+// Forbidden creates an ErrForbidden error for authenticated callers lacking access.
+func Forbidden(format string, args ...interface{}) error {
+	return newError(ErrForbidden, format, args...)
+}
+
+// Unauthorized creates an ErrUnauthorized error for missing or invalid credentials.
+func Unauthorized(format string, args ...interface{}) error {
+	return newError(ErrUnauthorized, format, args...)
+}
+
+func newError(kind error, format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Kind: kind, Message: err.Error(), Err: errors.Unwrap(err)}
+}
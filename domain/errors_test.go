@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"go-multirole/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	err := NotFound("role %d not found", 3)
+
+	assert.EqualError(t, err, "role 3 not found")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, fmt.Errorf("assign role: %w", err), ErrNotFound, "Kinds should survive further wrapping")
+
+	assert.ErrorIs(t, ErrUserNotFound, ErrNotFound)
+	assert.ErrorIs(t, ErrInvalidCredentials, ErrUnauthorized)
+	assert.ErrorIs(t, ErrAccountInactive, ErrForbidden)
+	assert.NotErrorIs(t, NotFound("user not found"), ErrUserNotFound, "Errors with the same message should stay distinct")
+}
+
+func TestErrorWrapping(t *testing.T) {
+	cause := errors.New("invalid user status transition")
+	err := Conflict("%w: cannot change from active to pending", cause)
+
+	assert.EqualError(t, err, "invalid user status transition: cannot change from active to pending")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, cause)
+
+	// The outermost error decides the kind
+	outer := Unauthorized("%w", Validation("invalid verification code"))
+	var domainErr *Error
+	assert.ErrorAs(t, outer, &domainErr)
+	assert.Equal(t, ErrUnauthorized, domainErr.Kind)
+}
+
+func TestInvalidFields(t *testing.T) {
+	err := InvalidFields(model.FieldError{Name: "username", Reason: "is required"}, model.FieldError{Name: "email", Reason: "must be a valid email address"})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.EqualError(t, err, "request has invalid fields")
+
+	var domainErr *Error
+	assert.ErrorAs(t, err, &domainErr)
+	assert.Len(t, domainErr.Fields, 2)
+	assert.Equal(t, "email", domainErr.Fields[1].Name)
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+// ErrInvalidInvitation is returned for unknown, accepted, revoked and expired invitation tokens.
+var ErrInvalidInvitation = Validation("invalid or expired invitation")
+
+type InvitationRepo interface {
+	CreateInvitation(ctx context.Context, invitation model.Invitation, roleIDs []uint) (model.Invitation, error)
+	ListInvitations(ctx context.Context) ([]model.Invitation, error)
+	FindInvitation(ctx context.Context, invitationID uint) (model.Invitation, error)
+	FindInvitationByToken(ctx context.Context, tokenHash string) (model.Invitation, error)
+	RenewInvitation(ctx context.Context, invitationID uint, tokenHash string, expiresAt time.Time) (bool, error)
+	RevokeInvitation(ctx context.Context, invitationID uint, revokedAt time.Time) (bool, error)
+	AcceptInvitation(ctx context.Context, invitation model.Invitation, password string, acceptedAt time.Time) (bool, error)
+}
+
+type InvitationUseCase interface {
+	Invite(ctx context.Context, request model.InvitationRequest, inviterID uint) (model.Invitation, error)
+	ListInvitations(ctx context.Context) ([]model.Invitation, error)
+	Resend(ctx context.Context, invitationID uint) (model.Invitation, error)
+	Revoke(ctx context.Context, invitationID uint) error
+	Accept(ctx context.Context, request model.AcceptInvitationRequest) error
+}
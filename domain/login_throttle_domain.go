@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+type LoginThrottleRepo interface {
+	FindThrottles(ctx context.Context, keys []string) ([]model.LoginThrottle, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, windowStart time.Time, lockAfter int, lockUntil time.Time) (model.LoginThrottle, error)
+	DeleteThrottle(ctx context.Context, key string) error
+	ListLockedThrottles(ctx context.Context, now time.Time) ([]model.LoginThrottle, error)
+	DeleteStaleThrottles(ctx context.Context, before time.Time) error
+}
+
+type LoginThrottleUseCase interface {
+	Check(ctx context.Context, username string, clientIP string) error
+	RecordFailure(ctx context.Context, username string, clientIP string) error
+	RecordSuccess(ctx context.Context, username string) error
+	LimitPasswordReset(ctx context.Context, identifier string, clientIP string) error
+	ListLocked(ctx context.Context) ([]model.LoginThrottle, error)
+	UnlockUser(ctx context.Context, username string) error
+	UnlockIP(ctx context.Context, clientIP string) error
+	PurgeStale(ctx context.Context) error
+	SetLimits(maxAttempts int, ipMaxAttempts int, lockoutDuration time.Duration, delay time.Duration)
+}
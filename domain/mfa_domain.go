@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+type MFARepo interface {
+	FindUser(ctx context.Context, userID uint) (model.User, error)
+	SaveTOTPSecret(ctx context.Context, userID uint, secret string) error
+	EnableMFA(ctx context.Context, userID uint, step int64, recoveryCodes []model.RecoveryCode) error
+	DisableMFA(ctx context.Context, userID uint) error
+	AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string, usedAt time.Time) (bool, error)
+	SetRoleRequireMFA(ctx context.Context, roleID uint, required bool) error
+}
+
+type MFAUseCase interface {
+	BeginEnrollment(ctx context.Context, userID uint) (model.MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	Disable(ctx context.Context, userID uint, code string) error
+	VerifyLogin(ctx context.Context, mfaToken string, code string, clientIP string) (string, error)
+	VerifySecondFactor(ctx context.Context, user model.User, code string, clientIP string) error
+	SetRolePolicy(ctx context.Context, roleID uint, policy model.RoleMFAPolicy) error
+}
@@ -0,0 +1,11 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+)
+
+// Notifier delivers messages such as password reset links to users.
+type Notifier interface {
+	Notify(ctx context.Context, notification model.Notification) error
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+type OAuthRepo interface {
+	CreateClient(ctx context.Context, client model.OAuthClient) (model.OAuthClient, error)
+	FindClientByClientID(ctx context.Context, clientID string) (model.OAuthClient, error)
+	ListClients(ctx context.Context) ([]model.OAuthClient, error)
+	AssignRoleToClient(ctx context.Context, clientID string, roleID uint) error
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type OAuthUseCase interface {
+	CreateClient(ctx context.Context, client model.OAuthClient) (string, model.OAuthClient, error)
+	ListClients(ctx context.Context) ([]model.OAuthClient, error)
+	AssignRoleToClient(ctx context.Context, clientID string, roleID uint) error
+	IssueClientCredentialsToken(ctx context.Context, clientID string, clientSecret string, scope string) (model.TokenResponse, error)
+	Introspect(ctx context.Context, clientID string, clientSecret string, token string) (model.IntrospectionResponse, error)
+	Revoke(ctx context.Context, clientID string, clientSecret string, token string) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	SetTokenTTL(tokenTTL time.Duration)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+type OIDCRepo interface {
+	CreateAuthorizationCode(ctx context.Context, code model.AuthorizationCode) (model.AuthorizationCode, error)
+	FindAuthorizationCode(ctx context.Context, codeHash string) (model.AuthorizationCode, error)
+	MarkAuthorizationCodeUsed(ctx context.Context, codeID uint, usedAt time.Time) (bool, error)
+	FindUserWithRoles(ctx context.Context, userID uint) (model.User, error)
+}
+
+type OIDCUseCase interface {
+	Discovery() model.OpenIDConfiguration
+	JWKS() map[string]interface{}
+	ValidateAuthorizeRequest(ctx context.Context, request model.AuthorizeRequest) (model.OAuthClient, error)
+	Authorize(ctx context.Context, request model.AuthorizeRequest, credentials model.User, otp string, clientIP string) (string, error)
+	ExchangeAuthorizationCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string) (model.TokenResponse, error)
+	UserInfo(ctx context.Context, accessToken string) (model.UserInfo, error)
+}
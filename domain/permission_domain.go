@@ -1,11 +1,18 @@
 package domain
 
-import "go-multirole/model"
+import (
+	"context"
+	"go-multirole/model"
+)
 
 type PermissionRepo interface {
-	CreatePermission(permission model.Permission) (model.Permission, error)
+	CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error)
+	ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error)
+	FindPermissionByName(ctx context.Context, name string) (model.Permission, error)
 }
 
 type PermissionUseCase interface {
-	CreatePermission(permission model.Permission) (model.Permission, error)
+	CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error)
+	ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error)
+	FindPermissionByName(ctx context.Context, name string) (model.Permission, error)
 }
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"go-multirole/model"
 	"testing"
 
@@ -13,21 +14,41 @@ type MockPermissionRepo struct {
 	mock.Mock
 }
 
-func (m *MockPermissionRepo) CreatePermission(permission model.Permission) (model.Permission, error) {
+func (m *MockPermissionRepo) CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error) {
 	args := m.Called(permission)
 	return args.Get(0).(model.Permission), args.Error(1)
 }
 
+func (m *MockPermissionRepo) ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Permission), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockPermissionRepo) FindPermissionByName(ctx context.Context, name string) (model.Permission, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Permission), args.Error(1)
+}
+
 // Mock for PermissionUseCase interface
 type MockPermissionUseCase struct {
 	mock.Mock
 }
 
-func (m *MockPermissionUseCase) CreatePermission(permission model.Permission) (model.Permission, error) {
+func (m *MockPermissionUseCase) CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error) {
 	args := m.Called(permission)
 	return args.Get(0).(model.Permission), args.Error(1)
 }
 
+func (m *MockPermissionUseCase) ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Permission), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockPermissionUseCase) FindPermissionByName(ctx context.Context, name string) (model.Permission, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Permission), args.Error(1)
+}
+
 // Unit Test for PermissionRepo interface
 func TestPermissionRepo(t *testing.T) {
 	mockRepo := new(MockPermissionRepo)
@@ -37,7 +58,7 @@ func TestPermissionRepo(t *testing.T) {
 		permission := model.Permission{ID: 1, Name: "read"}
 		mockRepo.On("CreatePermission", permission).Return(permission, nil)
 
-		createdPermission, err := mockRepo.CreatePermission(permission)
+		createdPermission, err := mockRepo.CreatePermission(context.Background(), permission)
 
 		assert.NoError(t, err)
 		assert.Equal(t, permission, createdPermission)
@@ -54,7 +75,7 @@ func TestPermissionUseCase(t *testing.T) {
 		permission := model.Permission{ID: 1, Name: "read"}
 		mockUseCase.On("CreatePermission", permission).Return(permission, nil)
 
-		createdPermission, err := mockUseCase.CreatePermission(permission)
+		createdPermission, err := mockUseCase.CreatePermission(context.Background(), permission)
 
 		assert.NoError(t, err)
 		assert.Equal(t, permission, createdPermission)
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+)
+
+type PolicyRepo interface {
+	// ApplyPolicyPlan applies every change of the plan in one transaction.
+	ApplyPolicyPlan(ctx context.Context, plan model.PolicyPlan) error
+}
+
+type PolicyUseCase interface {
+	Plan(ctx context.Context, policy model.Policy) (model.PolicyPlan, error)
+	Apply(ctx context.Context, policy model.Policy) (model.PolicyPlan, error)
+}
@@ -1,13 +1,25 @@
 package domain
 
-import "go-multirole/model"
+import (
+	"context"
+	"go-multirole/model"
+)
 
 type RoleRepo interface {
-	CreateRole(role model.Role) (model.Role, error)
-	AssignPermissionToRole(roleID string, permissionID string) error
+	CreateRole(ctx context.Context, role model.Role) (model.Role, error)
+	ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error)
+	FindRoleByName(ctx context.Context, name string) (model.Role, error)
+	AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error
+	RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error
 }
 
 type RoleUseCase interface {
-	CreateRole(role model.Role) (model.Role, error)
-	AssignPermissionToRole(roleID string, permissionID string) error
+	CreateRole(ctx context.Context, role model.Role) (model.Role, error)
+	// CreateRoleWithAccess creates the role, grants it the permissions and
+	// assigns it to the users, all or nothing.
+	CreateRoleWithAccess(ctx context.Context, role model.Role, permissionIDs []uint, userIDs []uint) (model.Role, error)
+	ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error)
+	FindRoleByName(ctx context.Context, name string) (model.Role, error)
+	AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error
+	RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error
 }
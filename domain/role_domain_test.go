@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"go-multirole/model"
 	"testing"
 
@@ -13,12 +14,27 @@ type MockRoleRepo struct {
 	mock.Mock
 }
 
-func (m *MockRoleRepo) CreateRole(role model.Role) (model.Role, error) {
+func (m *MockRoleRepo) CreateRole(ctx context.Context, role model.Role) (model.Role, error) {
 	args := m.Called(role)
 	return args.Get(0).(model.Role), args.Error(1)
 }
 
-func (m *MockRoleRepo) AssignPermissionToRole(roleID string, permissionID string) error {
+func (m *MockRoleRepo) ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Role), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockRoleRepo) FindRoleByName(ctx context.Context, name string) (model.Role, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockRoleRepo) AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error {
+	args := m.Called(roleID, permissionID)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error {
 	args := m.Called(roleID, permissionID)
 	return args.Error(0)
 }
@@ -28,12 +44,32 @@ type MockRoleUseCase struct {
 	mock.Mock
 }
 
-func (m *MockRoleUseCase) CreateRole(role model.Role) (model.Role, error) {
+func (m *MockRoleUseCase) CreateRole(ctx context.Context, role model.Role) (model.Role, error) {
 	args := m.Called(role)
 	return args.Get(0).(model.Role), args.Error(1)
 }
 
-func (m *MockRoleUseCase) AssignPermissionToRole(roleID string, permissionID string) error {
+func (m *MockRoleUseCase) CreateRoleWithAccess(ctx context.Context, role model.Role, permissionIDs []uint, userIDs []uint) (model.Role, error) {
+	args := m.Called(role, permissionIDs, userIDs)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockRoleUseCase) ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Role), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockRoleUseCase) FindRoleByName(ctx context.Context, name string) (model.Role, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockRoleUseCase) AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error {
+	args := m.Called(roleID, permissionID)
+	return args.Error(0)
+}
+
+func (m *MockRoleUseCase) RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error {
 	args := m.Called(roleID, permissionID)
 	return args.Error(0)
 }
@@ -47,7 +83,7 @@ func TestRoleRepo(t *testing.T) {
 		role := model.Role{ID: 1, Name: "admin"}
 		mockRepo.On("CreateRole", role).Return(role, nil)
 
-		createdRole, err := mockRepo.CreateRole(role)
+		createdRole, err := mockRepo.CreateRole(context.Background(), role)
 
 		assert.NoError(t, err)
 		assert.Equal(t, role, createdRole)
@@ -56,11 +92,11 @@ func TestRoleRepo(t *testing.T) {
 
 	// Test: Assign Permission to Role
 	t.Run("Assign Permission to Role", func(t *testing.T) {
-		roleID := "1"
-		permissionID := "read_permission"
+		roleID := uint(1)
+		permissionID := uint(2)
 		mockRepo.On("AssignPermissionToRole", roleID, permissionID).Return(nil)
 
-		err := mockRepo.AssignPermissionToRole(roleID, permissionID)
+		err := mockRepo.AssignPermissionToRole(context.Background(), roleID, permissionID)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -76,7 +112,7 @@ func TestRoleUseCase(t *testing.T) {
 		role := model.Role{ID: 1, Name: "admin"}
 		mockUseCase.On("CreateRole", role).Return(role, nil)
 
-		createdRole, err := mockUseCase.CreateRole(role)
+		createdRole, err := mockUseCase.CreateRole(context.Background(), role)
 
 		assert.NoError(t, err)
 		assert.Equal(t, role, createdRole)
@@ -85,11 +121,11 @@ func TestRoleUseCase(t *testing.T) {
 
 	// Test: Assign Permission to Role
 	t.Run("Assign Permission to Role", func(t *testing.T) {
-		roleID := "1"
-		permissionID := "read_permission"
+		roleID := uint(1)
+		permissionID := uint(2)
 		mockUseCase.On("AssignPermissionToRole", roleID, permissionID).Return(nil)
 
-		err := mockUseCase.AssignPermissionToRole(roleID, permissionID)
+		err := mockUseCase.AssignPermissionToRole(context.Background(), roleID, permissionID)
 
 		assert.NoError(t, err)
 		mockUseCase.AssertExpectations(t)
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+type ServiceAccountRepo interface {
+	CreateServiceAccount(ctx context.Context, user model.User) (model.User, error)
+	FindServiceAccount(ctx context.Context, userID uint) (model.User, error)
+	CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error)
+	FindAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID uint, keyID uint, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, keyID uint, usedAt time.Time) error
+}
+
+type ServiceAccountUseCase interface {
+	CreateServiceAccount(ctx context.Context, user model.User) (model.User, error)
+	CreateAPIKey(ctx context.Context, userID uint, apiKey model.APIKey) (string, model.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID uint, keyID uint) error
+	AuthenticateAPIKey(ctx context.Context, key string) (model.APIKey, error)
+}
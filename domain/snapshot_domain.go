@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+)
+
+type SnapshotRepo interface {
+	// ExportSnapshot reads every permission, role and user in one transaction.
+	ExportSnapshot(ctx context.Context, includePasswordHashes bool) (model.Snapshot, error)
+	// ApplyImportPlan applies every change of the plan in one transaction.
+	ApplyImportPlan(ctx context.Context, plan model.ImportPlan) error
+}
+
+type SnapshotUseCase interface {
+	Export(ctx context.Context, options model.ExportOptions) (model.Snapshot, error)
+	// Import returns the plan importing the snapshot, and applies it unless
+	// options.DryRun is set.
+	Import(ctx context.Context, snapshot model.Snapshot, options model.ImportOptions) (model.ImportPlan, error)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "context"
+
+// Repos are the repositories a unit of work hands to its function, all bound
+// to the same transaction.
+type Repos struct {
+	Users       UserRepo
+	Roles       RoleRepo
+	Permissions PermissionRepo
+}
+
+// UnitOfWork lets a usecase compose repository calls that commit or roll back
+// as one.
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a new transaction, committing it
+	// when fn returns nil and rolling it back otherwise. The repositories must
+	// not be used after fn returns.
+	Do(ctx context.Context, fn func(repos Repos) error) error
+}
@@ -1,17 +1,59 @@
 package domain
 
-import "go-multirole/model"
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+var (
+	// ErrUserNotFound is returned by UserRepo.LoginUser and FindUserByIdentifier for unknown users.
+	ErrUserNotFound = NotFound("user not found")
+	// ErrInvalidCredentials is returned for every failed password check so the
+	// response doesn't reveal whether the username exists.
+	ErrInvalidCredentials = Unauthorized("invalid username or password")
+	// ErrAccountInactive is returned when a pending, locked or disabled user
+	// presents valid credentials.
+	ErrAccountInactive = Forbidden("account is not active")
+	// ErrInvalidResetToken is returned for unknown, used and expired password reset tokens.
+	ErrInvalidResetToken = Validation("invalid or expired password reset token")
+)
 
 type UserRepo interface {
-	CreateUser(user model.User) (model.User, error)
-	LoginUser(user model.User) (model.User, error)
-	AssignRoleToUser(userId string, roleID string) error
-	CheckUserPermission(userID string, permissionName string) (bool, error)
+	CreateUser(ctx context.Context, user model.User) (model.User, error)
+	LoginUser(ctx context.Context, user model.User) (model.User, error)
+	ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]model.PasswordHistory, error)
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error)
+	FindUserByID(ctx context.Context, userID uint) (model.User, error)
+	FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error)
+	UpdatePassword(ctx context.Context, userID uint, newPassword string, changedAt time.Time) error
+	RehashPassword(ctx context.Context, userID uint, currentHash string, password string) error
+	UpdateStatus(ctx context.Context, change model.UserStatusChange, changedAt time.Time) error
+	ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error)
+	CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error
+	FindPasswordResetToken(ctx context.Context, tokenHash string) (model.PasswordResetToken, error)
+	MarkPasswordResetTokenUsed(ctx context.Context, tokenID uint, usedAt time.Time) (bool, error)
+	AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error
+	RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error
+	CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error)
+	ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error)
 }
 
 type UserUseCase interface {
-	CreateUser(user model.User) (model.User, error)
-	LoginUser(user model.User) (string, error)
-	AssignRoleToUser(userId string, roleID string) error
-	CheckUserPermission(userID string, permissionName string) (bool, error)
+	CreateUser(ctx context.Context, user model.User) (model.User, error)
+	LoginUser(ctx context.Context, user model.User, clientIP string) (model.LoginResult, error)
+	AuthenticateUser(ctx context.Context, user model.User, clientIP string) (model.User, error)
+	ValidatePassword(ctx context.Context, user model.User, password string) error
+	ChangePassword(ctx context.Context, userID uint, currentPassword string, newPassword string, clientIP string) (string, error)
+	RequestPasswordReset(ctx context.Context, identifier string, clientIP string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
+	SessionValid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error)
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error)
+	ChangeStatus(ctx context.Context, userID uint, request model.UserStatusRequest, actorID uint) (model.User, error)
+	ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error)
+	FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error)
+	AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error
+	RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error
+	CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error)
+	ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error)
 }
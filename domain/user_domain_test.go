@@ -1,8 +1,10 @@
 package domain
 
 import (
+	"context"
 	"go-multirole/model"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,51 +15,176 @@ type MockUserRepo struct {
 	mock.Mock
 }
 
-func (m *MockUserRepo) CreateUser(user model.User) (model.User, error) {
+func (m *MockUserRepo) CreateUser(ctx context.Context, user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *MockUserRepo) LoginUser(user model.User) (model.User, error) {
+func (m *MockUserRepo) LoginUser(ctx context.Context, user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *MockUserRepo) AssignRoleToUser(userId string, roleID string) error {
-	args := m.Called(userId, roleID)
+func (m *MockUserRepo) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]model.PasswordHistory, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]model.PasswordHistory), args.Error(1)
+}
+
+func (m *MockUserRepo) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockUserRepo) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
 	return args.Error(0)
 }
 
-func (m *MockUserRepo) CheckUserPermission(userID string, permissionName string) (bool, error) {
+func (m *MockUserRepo) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
 	args := m.Called(userID, permissionName)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserRepo) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Permission), args.Error(1)
+}
+
+func (m *MockUserRepo) FindUserByID(ctx context.Context, userID uint) (model.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserRepo) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	args := m.Called(identifier)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserRepo) UpdatePassword(ctx context.Context, userID uint, newPassword string, changedAt time.Time) error {
+	args := m.Called(userID, newPassword, changedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) RehashPassword(ctx context.Context, userID uint, currentHash string, password string) error {
+	args := m.Called(userID, currentHash, password)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) UpdateStatus(ctx context.Context, change model.UserStatusChange, changedAt time.Time) error {
+	args := m.Called(change, changedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.UserStatusChange), args.Error(1)
+}
+
+func (m *MockUserRepo) CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) FindPasswordResetToken(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	args := m.Called(tokenHash)
+	return args.Get(0).(model.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockUserRepo) MarkPasswordResetTokenUsed(ctx context.Context, tokenID uint, usedAt time.Time) (bool, error) {
+	args := m.Called(tokenID, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
 // Mock for UserUseCase interface
 type MockUserUseCase struct {
 	mock.Mock
 }
 
-func (m *MockUserUseCase) CreateUser(user model.User) (model.User, error) {
+func (m *MockUserUseCase) CreateUser(ctx context.Context, user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *MockUserUseCase) LoginUser(user model.User) (string, error) {
-	args := m.Called(user)
-	return args.String(0), args.Error(1)
+func (m *MockUserUseCase) LoginUser(ctx context.Context, user model.User, clientIP string) (model.LoginResult, error) {
+	args := m.Called(user, clientIP)
+	return args.Get(0).(model.LoginResult), args.Error(1)
 }
 
-func (m *MockUserUseCase) AssignRoleToUser(userId string, roleID string) error {
-	args := m.Called(userId, roleID)
+func (m *MockUserUseCase) AuthenticateUser(ctx context.Context, user model.User, clientIP string) (model.User, error) {
+	args := m.Called(user, clientIP)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ValidatePassword(ctx context.Context, user model.User, password string) error {
+	args := m.Called(user, password)
 	return args.Error(0)
 }
 
-func (m *MockUserUseCase) CheckUserPermission(userID string, permissionName string) (bool, error) {
+func (m *MockUserUseCase) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	args := m.Called(identifier)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
 	args := m.Called(userID, permissionName)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserUseCase) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Permission), args.Error(1)
+}
+
+func (m *MockUserUseCase) ChangePassword(ctx context.Context, userID uint, currentPassword string, newPassword string, clientIP string) (string, error) {
+	args := m.Called(userID, currentPassword, newPassword, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) RequestPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	args := m.Called(identifier, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) SessionValid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error) {
+	args := m.Called(userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockUserUseCase) ChangeStatus(ctx context.Context, userID uint, request model.UserStatusRequest, actorID uint) (model.User, error) {
+	args := m.Called(userID, request, actorID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.UserStatusChange), args.Error(1)
+}
+
 // Unit Test for UserRepo interface
 func TestUserRepo(t *testing.T) {
 	mockRepo := new(MockUserRepo)
@@ -67,7 +194,7 @@ func TestUserRepo(t *testing.T) {
 		user := model.User{ID: 1, Username: "john_doe", Password: "password123"}
 		mockRepo.On("CreateUser", user).Return(user, nil)
 
-		createdUser, err := mockRepo.CreateUser(user)
+		createdUser, err := mockRepo.CreateUser(context.Background(), user)
 
 		assert.NoError(t, err)
 		assert.Equal(t, user, createdUser)
@@ -79,7 +206,7 @@ func TestUserRepo(t *testing.T) {
 		user := model.User{Username: "john_doe", Password: "password123"}
 		mockRepo.On("LoginUser", user).Return(user, nil)
 
-		loggedInUser, err := mockRepo.LoginUser(user)
+		loggedInUser, err := mockRepo.LoginUser(context.Background(), user)
 
 		assert.NoError(t, err)
 		assert.Equal(t, user, loggedInUser)
@@ -88,11 +215,11 @@ func TestUserRepo(t *testing.T) {
 
 	// Test: Assign Role to User
 	t.Run("Assign Role to User", func(t *testing.T) {
-		userId := "1"
-		roleID := "admin"
-		mockRepo.On("AssignRoleToUser", userId, roleID).Return(nil)
+		userID := uint(1)
+		roleID := uint(2)
+		mockRepo.On("AssignRoleToUser", userID, roleID).Return(nil)
 
-		err := mockRepo.AssignRoleToUser(userId, roleID)
+		err := mockRepo.AssignRoleToUser(context.Background(), userID, roleID)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -100,11 +227,11 @@ func TestUserRepo(t *testing.T) {
 
 	// Test: Check User Permission
 	t.Run("Check User Permission", func(t *testing.T) {
-		userId := "1"
+		userID := uint(1)
 		permissionName := "admin_access"
-		mockRepo.On("CheckUserPermission", userId, permissionName).Return(true, nil)
+		mockRepo.On("CheckUserPermission", userID, permissionName).Return(true, nil)
 
-		hasPermission, err := mockRepo.CheckUserPermission(userId, permissionName)
+		hasPermission, err := mockRepo.CheckUserPermission(context.Background(), userID, permissionName)
 
 		assert.NoError(t, err)
 		assert.True(t, hasPermission)
@@ -121,7 +248,7 @@ func TestUserUseCase(t *testing.T) {
 		user := model.User{ID: 1, Username: "john_doe", Password: "password123"}
 		mockUseCase.On("CreateUser", user).Return(user, nil)
 
-		createdUser, err := mockUseCase.CreateUser(user)
+		createdUser, err := mockUseCase.CreateUser(context.Background(), user)
 
 		assert.NoError(t, err)
 		assert.Equal(t, user, createdUser)
@@ -131,22 +258,22 @@ func TestUserUseCase(t *testing.T) {
 	// Test: Login User
 	t.Run("Login User", func(t *testing.T) {
 		user := model.User{Username: "john_doe", Password: "password123"}
-		mockUseCase.On("LoginUser", user).Return("1", nil)
+		mockUseCase.On("LoginUser", user, "127.0.0.1").Return(model.LoginResult{Token: "token"}, nil)
 
-		userId, err := mockUseCase.LoginUser(user)
+		result, err := mockUseCase.LoginUser(context.Background(), user, "127.0.0.1")
 
 		assert.NoError(t, err)
-		assert.Equal(t, "1", userId)
+		assert.Equal(t, "token", result.Token)
 		mockUseCase.AssertExpectations(t)
 	})
 
 	// Test: Assign Role to User
 	t.Run("Assign Role to User", func(t *testing.T) {
-		userId := "1"
-		roleID := "admin"
-		mockUseCase.On("AssignRoleToUser", userId, roleID).Return(nil)
+		userID := uint(1)
+		roleID := uint(2)
+		mockUseCase.On("AssignRoleToUser", userID, roleID).Return(nil)
 
-		err := mockUseCase.AssignRoleToUser(userId, roleID)
+		err := mockUseCase.AssignRoleToUser(context.Background(), userID, roleID)
 
 		assert.NoError(t, err)
 		mockUseCase.AssertExpectations(t)
@@ -154,11 +281,11 @@ func TestUserUseCase(t *testing.T) {
 
 	// Test: Check User Permission
 	t.Run("Check User Permission", func(t *testing.T) {
-		userId := "1"
+		userID := uint(1)
 		permissionName := "admin_access"
-		mockUseCase.On("CheckUserPermission", userId, permissionName).Return(true, nil)
+		mockUseCase.On("CheckUserPermission", userID, permissionName).Return(true, nil)
 
-		hasPermission, err := mockUseCase.CheckUserPermission(userId, permissionName)
+		hasPermission, err := mockUseCase.CheckUserPermission(context.Background(), userID, permissionName)
 
 		assert.NoError(t, err)
 		assert.True(t, hasPermission)
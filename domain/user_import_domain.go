@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+)
+
+type UserImportRepo interface {
+	// ImportUsers creates the users and returns the stored user or the error
+	// of each. Unless perRow is set they're created in one transaction, and
+	// when a user fails every other user is returned zero with no error.
+	ImportUsers(ctx context.Context, imports []model.UserImport, perRow bool) ([]model.User, []error)
+}
+
+type UserImportUseCase interface {
+	// Import creates the users of the rows and reports the outcome of each.
+	// actorID is recorded as the inviter of invitees, 0 for the CLI.
+	Import(ctx context.Context, rows []model.UserImportRow, options model.UserImportOptions, actorID uint) (model.UserImportReport, error)
+}
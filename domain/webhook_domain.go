@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"context"
+	"go-multirole/model"
+	"time"
+)
+
+type WebhookRepo interface {
+	CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint) error
+	FanOutPendingEvents(ctx context.Context, limit int) (int, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID uint) ([]model.WebhookDelivery, error)
+}
+
+type WebhookUseCase interface {
+	CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]model.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint) error
+	ListDeliveries(ctx context.Context, webhookID uint) ([]model.WebhookDelivery, error)
+	DispatchPending(ctx context.Context) error
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"context"
+	"crypto/rsa"
+	"fmt"
 	"go-multirole/config"
 	"go-multirole/controller"
 	"go-multirole/db"
+	"go-multirole/domain"
 	"go-multirole/middleware"
+	"go-multirole/model"
+	"go-multirole/notifier"
 	"go-multirole/repo"
 	"go-multirole/usecase"
+	"go-multirole/utils"
 	"log"
+	"log/slog"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,34 +27,261 @@ func main() {
 	if err != nil {
 		log.Fatal("🚀 Could not load environment variables", err)
 	}
+	// migrate only needs the database settings
+	if len(os.Args) < 2 || os.Args[1] != "migrate" {
+		if err := loadConfig.Validate(); err != nil {
+			log.Fatalf("🚀 Invalid configuration:\n%v", err)
+		}
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(loadConfig, os.Args[2:])
+		case "user", "role", "permission", "policy":
+			runAdmin(loadConfig, os.Args[1:])
+		default:
+			log.Fatalf("unknown command %q\n\n%s\n\n%s", os.Args[1], adminUsage, migrateUsage)
+		}
+		return
+	}
 
 	db := db.InitDB(&loadConfig)
-	router := gin.Default()
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	// Route slog's default logger and the log package through logger, so
+	// LOG_LEVEL applies to all logging
+	slog.SetDefault(logger)
+	router := gin.New()
+	var trustedProxies []string // nil trusts no proxy
+	if len(loadConfig.TrustedProxies) > 0 {
+		trustedProxies = loadConfig.TrustedProxies
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		fatal("🚀 Invalid TRUSTED_PROXIES", err)
+	}
+	router.Use(gin.Recovery(), middleware.RequestLogger(logger), middleware.ErrorHandler())
+
+	loginThrottleRepo := repo.NewLoginThrottleRepository(db)
+	loginThrottleUseCase := usecase.NewLoginThrottleUseCase(loginThrottleRepo, loadConfig.LoginMaxAttempts, loadConfig.LoginIPMaxAttempts, loadConfig.LoginLockoutDuration, loadConfig.LoginThrottleDelay)
+	loginThrottleController := controller.NewLoginThrottleController(loginThrottleUseCase)
 
-	userRepo := repo.NewUserRepository(db)
-	userUseCase := usecase.NewUserUseCase(userRepo)
+	passwordPolicy := newPasswordPolicy(loadConfig)
+	tokens := utils.NewTokenService(loadConfig.TokenSecret, loadConfig.TokenExpiresIn)
+	userNotifier, err := newNotifier(loadConfig)
+	if err != nil {
+		fatal("🚀 Could not configure notifications", err)
+	}
+	passwordHasher, err := newPasswordHasher(loadConfig)
+	if err != nil {
+		fatal("🚀 Could not configure password hashing", err)
+	}
+
+	unitOfWork := repo.NewUnitOfWork(db, passwordHasher)
+	userRepo := repo.NewUserRepository(db, passwordHasher)
+	userUseCase := usecase.NewUserUseCase(userRepo, unitOfWork, loginThrottleUseCase, passwordHasher, passwordPolicy, tokens, userNotifier, loadConfig.PasswordResetTTL, loadConfig.PasswordResetURL)
 	userController := controller.NewUserController(userUseCase)
 
+	invitationRepo := repo.NewInvitationRepository(db, passwordHasher)
+	invitationUseCase := usecase.NewInvitationUseCase(invitationRepo, userRepo, userUseCase, userNotifier, loadConfig.InvitationTTL, loadConfig.InvitationURL)
+	invitationController := controller.NewInvitationController(invitationUseCase)
+
 	roleRepo := repo.NewRoleRepository(db)
-	roleUseCase := usecase.NewRoleUseCase(roleRepo)
+	roleUseCase := usecase.NewRoleUseCase(roleRepo, unitOfWork)
 	roleController := controller.NewRoleController(roleUseCase)
 
+	userImportRepo := repo.NewUserImportRepository(db, passwordHasher)
+	userImportUseCase := usecase.NewUserImportUseCase(userImportRepo, roleRepo, userUseCase, userNotifier, loadConfig.InvitationTTL, loadConfig.InvitationURL)
+	userImportController := controller.NewUserImportController(userImportUseCase)
+
 	permissionRepo := repo.NewPermissionRepository(db)
 	permissionUseCase := usecase.NewPermissionUseCase(permissionRepo)
 	permissionController := controller.NewPermissionController(permissionUseCase)
 
+	serviceAccountRepo := repo.NewServiceAccountRepository(db)
+	serviceAccountUseCase := usecase.NewServiceAccountUseCase(serviceAccountRepo)
+	serviceAccountController := controller.NewServiceAccountController(serviceAccountUseCase)
+
+	mfaRepo := repo.NewMFARepository(db)
+	mfaUseCase := usecase.NewMFAUseCase(mfaRepo, loginThrottleUseCase, loadConfig.MFAIssuer, tokens)
+	mfaController := controller.NewMFAController(mfaUseCase)
+
+	oauthRepo := repo.NewOAuthRepository(db)
+	oauthUseCase := usecase.NewOAuthUseCase(oauthRepo, userUseCase, tokens, loadConfig.OAuthTokenExpiresIn)
+
+	oidcSigningKey, err := loadOIDCSigningKey(loadConfig.OIDCSigningKeyFile)
+	if err != nil {
+		fatal("🚀 Could not load the OpenID Connect signing key", err)
+	}
+	oidcRepo := repo.NewOIDCRepository(db)
+	oidcUseCase := usecase.NewOIDCUseCase(oidcRepo, oauthRepo, userUseCase, mfaUseCase, loadConfig.OIDCIssuer, tokens, oidcSigningKey)
+	oidcController := controller.NewOIDCController(oidcUseCase)
+	oauthController := controller.NewOAuthController(oauthUseCase, oidcUseCase)
+
+	snapshotRepo := repo.NewSnapshotRepository(db)
+	snapshotUseCase := usecase.NewSnapshotUseCase(snapshotRepo)
+	snapshotController := controller.NewSnapshotController(snapshotUseCase)
+
+	webhookRepo := repo.NewWebhookRepository(db)
+	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo, loadConfig.WebhookMaxAttempts, loadConfig.WebhookRetryBackoff)
+	webhookController := controller.NewWebhookController(webhookUseCase)
+
+	// Deliver outbox events to webhooks in the background
+	go func() {
+		for range time.Tick(loadConfig.WebhookDispatchInterval) {
+			if err := webhookUseCase.DispatchPending(context.Background()); err != nil {
+				logger.Error("webhook dispatch failed", "error", err)
+			}
+		}
+	}()
+
+	// Forget expired login failures
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := loginThrottleUseCase.PurgeStale(context.Background()); err != nil {
+				logger.Error("login throttle cleanup failed", "error", err)
+			}
+		}
+	}()
+
+	// Swap the hot settings in when app.env changes, so rotating
+	// TOKEN_SECRET doesn't need a restart
+	live := config.NewLive(loadConfig, func(c config.Config) {
+		tokens.Rotate(utils.TokenKeys{Secret: c.TokenSecret, PreviousSecrets: c.TokenPreviousSecrets, TTL: c.TokenExpiresIn})
+		oauthUseCase.SetTokenTTL(c.OAuthTokenExpiresIn)
+		loginThrottleUseCase.SetLimits(c.LoginMaxAttempts, c.LoginIPMaxAttempts, c.LoginLockoutDuration, c.LoginThrottleDelay)
+		level, _ := c.Level() // Checked by Validate
+		logLevel.Set(level)
+	})
+	live.Watch(logger)
+	configController := controller.NewConfigController(live)
+
 	// Define routes
 	router.POST("/roles", roleController.CreateRole)
 	router.POST("/permissions", permissionController.CreatePermission)
+	router.GET("/roles", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_roles"), roleController.ListRoles)
+	router.GET("/permissions", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_roles"), permissionController.ListPermissions)
 
 	router.POST("/users", userController.CreateUser)
+	router.GET("/users", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_users"), userController.ListUsers)
+	router.POST("/users/import", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_users"), userImportController.ImportUsers)
 	router.POST("/users/login", userController.LoginUser)
+	router.POST("/users/login/mfa", mfaController.VerifyLogin)
+	router.POST("/users/password/reset-request", userController.RequestPasswordReset)
+	router.POST("/users/password/reset", userController.ResetPassword)
+	router.POST("/users/me/password", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), userController.ChangePassword)
+
+	// Enrollment also accepts the restricted token of users whose role requires MFA
+	mfa := router.Group("/users/me/mfa")
+	mfa.POST("/enroll", middleware.MFAEnrollmentMiddleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), mfaController.BeginEnrollment)
+	mfa.POST("/confirm", middleware.MFAEnrollmentMiddleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), mfaController.ConfirmEnrollment)
+	mfa.DELETE("", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), mfaController.Disable)
+	router.PUT("/roles/:roleID/mfa-policy", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_roles"), mfaController.SetRolePolicy)
 
 	router.GET("/users/:userID/roles/:roleID", userController.AssignRoleToUser)
 	router.GET("/roles/:roleID/permissions/:permissionID", roleController.AssignPermissionToRole)
 	router.GET("/users/:userID/permissions/:permissionName", userController.CheckUserPermission)
 
-	router.GET("/users/temp", middleware.Middleware(), userController.GetUserTemp)
+	userStatus := router.Group("/users/:userID", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_users"))
+	userStatus.PUT("/status", userController.ChangeStatus)
+	userStatus.GET("/status-history", userController.ListStatusChanges)
+
+	router.POST("/invitations/accept", invitationController.Accept)
+	invitations := router.Group("/invitations", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_users"))
+	invitations.POST("", invitationController.Invite)
+	invitations.GET("", invitationController.ListInvitations)
+	invitations.POST("/:invitationID/resend", invitationController.Resend)
+	invitations.DELETE("/:invitationID", invitationController.Revoke)
+
+	router.GET("/users/temp", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), userController.GetUserTemp)
+
+	webhooks := router.Group("/webhooks", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_webhooks"))
+	webhooks.POST("", webhookController.CreateWebhook)
+	webhooks.GET("", webhookController.ListWebhooks)
+	webhooks.DELETE("/:webhookID", webhookController.DeleteWebhook)
+	webhooks.GET("/:webhookID/deliveries", webhookController.ListDeliveries)
+
+	serviceAccounts := router.Group("/service-accounts", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_service_accounts"))
+	serviceAccounts.POST("", serviceAccountController.CreateServiceAccount)
+	serviceAccounts.POST("/:userID/keys", serviceAccountController.CreateAPIKey)
+	serviceAccounts.GET("/:userID/keys", serviceAccountController.ListAPIKeys)
+	serviceAccounts.DELETE("/:userID/keys/:keyID", serviceAccountController.RevokeAPIKey)
+
+	lockouts := router.Group("/admin/lockouts", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "unlock_accounts"))
+	lockouts.GET("", loginThrottleController.ListLocked)
+	lockouts.DELETE("/users/:username", loginThrottleController.UnlockUser)
+	lockouts.DELETE("/ips/:ip", loginThrottleController.UnlockIP)
+
+	router.GET("/admin/config", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "view_config"), configController.GetConfig)
+
+	// Snapshots hold every user and role, so both permissions are required
+	snapshots := router.Group("/admin", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_users"), middleware.RequirePermission(userUseCase, "manage_roles"))
+	snapshots.GET("/export", snapshotController.Export)
+	snapshots.POST("/import", snapshotController.Import)
+
+	router.GET("/.well-known/openid-configuration", oidcController.Discovery)
+	router.GET("/.well-known/jwks.json", oidcController.JWKS)
+	router.GET("/oauth/authorize", oidcController.AuthorizeForm)
+	router.POST("/oauth/authorize", oidcController.Authorize)
+	router.GET("/userinfo", oidcController.UserInfo)
+	router.POST("/userinfo", oidcController.UserInfo)
+	router.POST("/oauth/token", oauthController.Token)
+	router.POST("/oauth/introspect", oauthController.Introspect)
+	router.POST("/oauth/revoke", oauthController.Revoke)
+
+	oauthClients := router.Group("/oauth/clients", middleware.Middleware(tokens, serviceAccountUseCase, oauthUseCase, userUseCase), middleware.RequirePermission(userUseCase, "manage_oauth_clients"))
+	oauthClients.POST("", oauthController.CreateClient)
+	oauthClients.GET("", oauthController.ListClients)
+	oauthClients.POST("/:clientID/roles/:roleID", oauthController.AssignRoleToClient)
 
 	router.Run(":9091")
 }
+
+func newPasswordPolicy(loadConfig config.Config) model.PasswordPolicy {
+	return model.PasswordPolicy{
+		MinLength:         loadConfig.PasswordMinLength,
+		RequireUppercase:  loadConfig.PasswordRequireUppercase,
+		RequireLowercase:  loadConfig.PasswordRequireLowercase,
+		RequireDigit:      loadConfig.PasswordRequireDigit,
+		RequireSymbol:     loadConfig.PasswordRequireSymbol,
+		DisallowUsername:  loadConfig.PasswordDisallowUsername,
+		HistorySize:       loadConfig.PasswordHistorySize,
+		BreachedRangesDir: loadConfig.PasswordBreachedRangesDir,
+	}
+}
+
+func newNotifier(loadConfig config.Config) (domain.Notifier, error) {
+	switch loadConfig.Notifier {
+	case "smtp":
+		return notifier.NewSMTPNotifier(loadConfig.SMTPHost, loadConfig.SMTPPort, loadConfig.SMTPUsername, loadConfig.SMTPPassword, loadConfig.SMTPFrom), nil
+	case "log", "":
+		return notifier.NewLogNotifier(loadConfig.NotifierLogFile), nil
+	}
+	return nil, fmt.Errorf("unknown NOTIFIER %s", loadConfig.Notifier)
+}
+
+func newPasswordHasher(loadConfig config.Config) (utils.PasswordHasher, error) {
+	argon2idParams := utils.Argon2idParams{
+		Memory:      loadConfig.PasswordArgon2Memory,
+		Iterations:  loadConfig.PasswordArgon2Iterations,
+		Parallelism: loadConfig.PasswordArgon2Threads,
+	}
+	return utils.NewPasswordHasher(loadConfig.PasswordHashAlgorithm, argon2idParams, loadConfig.PasswordBcryptCost)
+}
+
+// fatal logs err at error level and exits. It replaces log.Fatal once the
+// default logger is filtered by LOG_LEVEL, which would drop its message.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// loadOIDCSigningKey reads the ID token signing key, or generates one that only
+// lives as long as the process when no key file is configured.
+func loadOIDCSigningKey(path string) (*rsa.PrivateKey, error) {
+	if path != "" {
+		return utils.LoadRSAPrivateKey(path)
+	}
+	slog.Warn("OIDC_SIGNING_KEY_FILE is not set, ID tokens are signed with an ephemeral key")
+	return utils.GenerateRSAPrivateKey()
+}
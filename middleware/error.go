@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// kindStatuses maps domain error kinds to HTTP statuses.
+var kindStatuses = map[error]int{
+	domain.ErrNotFound:     http.StatusNotFound,
+	domain.ErrConflict:     http.StatusConflict,
+	domain.ErrValidation:   http.StatusBadRequest,
+	domain.ErrForbidden:    http.StatusForbidden,
+	domain.ErrUnauthorized: http.StatusUnauthorized,
+}
+
+// ErrorHandler renders the last error recorded with ctx.Error as problem
+// details once the handlers are done, unless they already wrote a response.
+// It must be registered before every other handler.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		last := ctx.Errors.Last()
+		if last == nil || ctx.Writer.Written() {
+			return
+		}
+		problem := newProblem(ctx, last)
+		ctx.Header("Content-Type", model.ProblemContentType)
+		ctx.JSON(problem.Status, problem)
+	}
+}
+
+// newProblem describes the error. The status comes from the outermost domain
+// error; errors of unknown kind are logged and reported without details.
+func newProblem(ctx *gin.Context, ginErr *gin.Error) model.Problem {
+	problem := model.Problem{Type: "about:blank", Instance: ctx.Request.URL.Path}
+
+	var (
+		domainErr *domain.Error
+		policyErr *model.PasswordPolicyError
+		throttled *model.LoginThrottledError
+	)
+	err := ginErr.Err
+	switch {
+	case ginErr.IsType(gin.ErrorTypeBind):
+		problem.Status = http.StatusBadRequest
+		problem.Detail = err.Error()
+	case errors.As(err, &throttled):
+		problem.Status = http.StatusTooManyRequests
+		problem.Detail = err.Error()
+		problem.RetryAfter = throttled.RetryAfterSeconds()
+		ctx.Header("Retry-After", strconv.Itoa(problem.RetryAfter))
+	case errors.As(err, &policyErr):
+		problem.Status = http.StatusBadRequest
+		problem.Detail = "password does not meet the policy"
+		problem.Violations = policyErr.Violations
+	case errors.As(err, &domainErr) && kindStatuses[domainErr.Kind] != 0:
+		problem.Status = kindStatuses[domainErr.Kind]
+		problem.Detail = domainErr.Error()
+		problem.InvalidParams = domainErr.Fields
+	default:
+		slog.Error("request failed", "method", ctx.Request.Method, "path", ctx.Request.URL.Path, "error", err)
+		problem.Status = http.StatusInternalServerError
+	}
+
+	problem.Title = http.StatusText(problem.Status)
+	return problem
+}
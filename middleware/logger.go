@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger logs every request at info level, or at error level when it
+// fails with a server error. It must be registered before ErrorHandler to
+// see the status of the problem responses.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		level := slog.LevelInfo
+		if ctx.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Log(ctx.Request.Context(), level, "request",
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"status", ctx.Writer.Status(),
+			"latency", time.Since(start),
+			"client_ip", ctx.ClientIP(),
+		)
+	}
+}
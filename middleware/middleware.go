@@ -1,47 +1,148 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
-	"go-multirole/config"
+	"go-multirole/domain"
 	"go-multirole/model"
 	"go-multirole/utils"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Middleware() gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		var token string
+// CurrentScopesKey holds the scopes of the API key used for the request, if any.
+const CurrentScopesKey = "currentScopes"
+
+func Middleware(tokens *utils.TokenService, serviceAccountUseCase domain.ServiceAccountUseCase, oauthUseCase domain.OAuthUseCase, userUseCase domain.UserUseCase) gin.HandlerFunc {
+	return authenticate(tokens, serviceAccountUseCase, oauthUseCase, userUseCase, "")
+}
 
+// MFAEnrollmentMiddleware additionally accepts the restricted token issued to
+// users whose role requires MFA, so they can enroll before a full login.
+func MFAEnrollmentMiddleware(tokens *utils.TokenService, serviceAccountUseCase domain.ServiceAccountUseCase, oauthUseCase domain.OAuthUseCase, userUseCase domain.UserUseCase) gin.HandlerFunc {
+	return authenticate(tokens, serviceAccountUseCase, oauthUseCase, userUseCase, model.TokenPurposeMFAEnrollment)
+}
+
+// authenticate accepts API keys and user tokens. Tokens issued for a purpose
+// are rejected unless it is allowedPurpose, and tokens issued before the
+// user's last password change are rejected.
+func authenticate(tokens *utils.TokenService, serviceAccountUseCase domain.ServiceAccountUseCase, oauthUseCase domain.OAuthUseCase, userUseCase domain.UserUseCase, allowedPurpose string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var token, apiKey string
 		authorizationHeader := ctx.Request.Header.Get("Authorization")
 		fields := strings.Fields(authorizationHeader)
 
-		if len(fields) != 0 && fields[0] == "Bearer" {
+		if len(fields) == 2 && fields[0] == "Bearer" {
 			token = fields[1]
+		} else if len(fields) == 2 && fields[0] == "ApiKey" {
+			apiKey = fields[1]
+		} else if header := ctx.Request.Header.Get("X-API-Key"); header != "" {
+			apiKey = header
+		}
+
+		if apiKey != "" {
+			key, err := serviceAccountUseCase.AuthenticateAPIKey(ctx.Request.Context(), apiKey)
+			if err != nil {
+				abort(ctx, err)
+				return
+			}
+
+			ctx.Set("currentUserId", key.UserID)
+			ctx.Set(CurrentScopesKey, key.Scopes)
+			ctx.Next()
+			return
 		}
 
 		if token == "" {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.Response{
-				StatusCode: http.StatusUnauthorized,
-				Message:    "You are not logged in",
-			})
+			abort(ctx, domain.Unauthorized("you are not logged in"))
 			return
 		}
 
-		config, _ := config.LoadConfig(".")
-		sub, err := utils.ValidateToken(token, config.TokenSecret)
+		claims, err := tokens.Parse(token)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, model.Response{
-				StatusCode: http.StatusUnauthorized,
-				Message:    err.Error(),
-			})
+			abort(ctx, domain.Unauthorized("%w", err))
+			return
+		}
+
+		// Client credential tokens identify OAuth clients, not users
+		if _, isClientToken := claims["gty"]; isClientToken {
+			abort(ctx, domain.Unauthorized("client tokens are not accepted here"))
+			return
+		}
+
+		if purpose, ok := claims[model.TokenPurposeClaim]; ok && purpose != allowedPurpose {
+			if purpose == model.TokenPurposeOIDCAccess {
+				abort(ctx, domain.Unauthorized("OpenID Connect access tokens are only accepted by the userinfo endpoint"))
+				return
+			}
+			abort(ctx, domain.Unauthorized("token can only be used to complete multi-factor authentication"))
+			return
+		}
+
+		revoked, err := oauthUseCase.IsTokenRevoked(ctx.Request.Context(), fmt.Sprint(claims["jti"]))
+		if err != nil || revoked {
+			abort(ctx, domain.Unauthorized("token has been revoked"))
+			return
+		}
+
+		userID, ok := utils.TokenSubjectID(claims)
+		if !ok {
+			abort(ctx, domain.Unauthorized("token does not identify a user"))
+			return
+		}
+
+		valid, err := userUseCase.SessionValid(ctx.Request.Context(), userID, utils.TokenIssuedAt(claims))
+		if errors.Is(err, domain.ErrAccountInactive) {
+			abort(ctx, err)
+			return
+		}
+		if err != nil || !valid {
+			abort(ctx, domain.Unauthorized("session has expired, please log in again"))
+			return
+		}
+
+		ctx.Set("currentUserId", userID)
+		ctx.Next()
+	}
+}
+
+// HasScope reports whether the request's credential may exercise the permission.
+// Tokens and API keys without scopes are limited only by the account's roles.
+func HasScope(ctx *gin.Context, permissionName string) bool {
+	value, exists := ctx.Get(CurrentScopesKey)
+	if !exists {
+		return true
+	}
+	scopes, _ := value.([]string)
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope == permissionName {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission must run after Middleware and rejects users lacking the permission.
+func RequirePermission(userUseCase domain.UserUseCase, permissionName string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.MustGet("currentUserId").(uint)
+
+		hasPermission, err := userUseCase.CheckUserPermission(ctx.Request.Context(), userID, permissionName)
+		if err != nil || !hasPermission || !HasScope(ctx, permissionName) {
+			abort(ctx, domain.Forbidden("you do not have the %s permission", permissionName))
 			return
 		}
 
-		idStr := fmt.Sprint(sub)
-		ctx.Set("currentUserId", idStr)
 		ctx.Next()
 	}
 }
+
+// abort stops the chain, leaving the error for ErrorHandler to render.
+func abort(ctx *gin.Context, err error) {
+	ctx.Error(err)
+	ctx.Abort()
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTokenSecret = "middleware-test-secret"
+
+// stubOAuthUseCase only answers the revocation check done by authenticate.
+type stubOAuthUseCase struct {
+	domain.OAuthUseCase
+}
+
+func (stubOAuthUseCase) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+// stubUserUseCase only answers the session check done by authenticate.
+type stubUserUseCase struct {
+	domain.UserUseCase
+}
+
+func (stubUserUseCase) SessionValid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error) {
+	return true, nil
+}
+
+func newTestRouter(tokens *utils.TokenService) *gin.Engine {
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/users", Middleware(tokens, nil, stubOAuthUseCase{}, stubUserUseCase{}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestAuthenticate(t *testing.T) {
+	tokens := utils.NewTokenService(testTokenSecret, time.Hour)
+	router := newTestRouter(tokens)
+
+	get := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Accepts a user token", func(t *testing.T) {
+		token, _ := tokens.Issue(7)
+
+		assert.Equal(t, http.StatusOK, get(token).Code)
+	})
+
+	t.Run("Rejects an OpenID Connect access token", func(t *testing.T) {
+		token, _ := tokens.IssueWithClaims(time.Hour, map[string]interface{}{
+			"sub":                   7,
+			"client_id":             "portal",
+			"scope":                 "openid profile",
+			model.TokenPurposeClaim: model.TokenPurposeOIDCAccess,
+		})
+
+		w := get(token)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "OpenID Connect access tokens are only accepted by the userinfo endpoint")
+	})
+
+	t.Run("Rejects a client credentials token", func(t *testing.T) {
+		token, _ := tokens.IssueWithClaims(time.Hour, map[string]interface{}{"sub": "reporting", "gty": "client_credentials"})
+
+		assert.Equal(t, http.StatusUnauthorized, get(token).Code)
+	})
+}
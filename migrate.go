@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"go-multirole/config"
+	"go-multirole/db"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: go-multirole migrate <command>
+
+commands:
+  up            apply every pending migration
+  down [steps]  revert the last applied migration, or the last steps
+  status        list migrations and when they were applied
+  to <version>  apply or revert migrations until the schema is at version`
+
+// runMigrate runs the migrate subcommand against the configured database.
+func runMigrate(loadConfig config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	dialector, err := db.Dialector(&loadConfig)
+	if err != nil {
+		log.Fatal("🚀 Could not configure the database ", err)
+	}
+	conn, err := db.Open(dialector)
+	if err != nil {
+		log.Fatal("🚀 Could not connect to the database ", err)
+	}
+	migrator, err := db.NewMigrator(conn)
+	if err != nil {
+		log.Fatal("🚀 Could not load migrations ", err)
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		err = migrator.Up()
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal("🚀 down takes a positive number of steps")
+			}
+		}
+		err = migrator.Down(steps)
+	case args[0] == "to" && len(args) == 2:
+		version, parseErr := strconv.ParseUint(args[1], 10, 32)
+		if parseErr != nil {
+			log.Fatal("🚀 to takes a migration version")
+		}
+		err = migrator.To(uint(version))
+	case args[0] == "status" && len(args) == 1:
+		err = printMigrationStatus(migrator)
+	default:
+		log.Fatal(migrateUsage)
+	}
+	if err != nil {
+		log.Fatal("🚀 Migration failed ", err)
+	}
+}
+
+func printMigrationStatus(migrator *db.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = status.AppliedAt.Format(time.RFC3339)
+		}
+		if status.Unknown {
+			applied += " (unknown to this build)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// APIKey is a long-lived credential of a service account. Only a hash of the
+// key is stored; the prefix identifies the key without revealing it.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100)" json:"name" binding:"required,max=100"`
+	Prefix     string     `gorm:"type:varchar(32);uniqueIndex" json:"prefix"`
+	KeyHash    string     `gorm:"type:varchar(100)" json:"-"`
+	Scopes     []string   `gorm:"type:text;serializer:json" json:"scopes" binding:"dive,required,max=100"` // Empty means every permission of the account
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the key is neither revoked nor expired at the given time.
+func (k APIKey) Usable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// CreateServiceAccountRequest is the body of POST /service-accounts.
+type CreateServiceAccountRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=100,username"`
+}
+
+// User returns the service account to create from the request.
+func (r CreateServiceAccountRequest) User() User {
+	return User{Username: r.Username}
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyUsable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	assert.True(t, APIKey{}.Usable(now), "Key without expiry should be usable")
+	assert.True(t, APIKey{ExpiresAt: &future}.Usable(now), "Key expiring in the future should be usable")
+	assert.False(t, APIKey{ExpiresAt: &past}.Usable(now), "Expired key should not be usable")
+	assert.False(t, APIKey{RevokedAt: &past}.Usable(now), "Revoked key should not be usable")
+}
+
+func TestAPIKeyJSONMarshaling(t *testing.T) {
+	// The key hash must never be serialized
+	key := APIKey{ID: 1, UserID: 2, Name: "batch", Prefix: "rbac_abcd1234", KeyHash: "hash"}
+
+	actualJSON, err := json.Marshal(key)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "hash", "Key hash should not be serialized")
+	assert.Contains(t, string(actualJSON), `"prefix":"rbac_abcd1234"`, "Prefix should be serialized")
+}
@@ -0,0 +1,7 @@
+package model
+
+// EffectiveConfig is the configuration the service is running with.
+type EffectiveConfig struct {
+	Settings       map[string]interface{} `json:"settings"`        // By app.env name, secrets are redacted
+	PendingRestart []string               `json:"pending_restart"` // Changed settings that need a restart
+}
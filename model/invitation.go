@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// States of an Invitation, derived from its timestamps.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation onboards a user chosen by an administrator. The user exists in
+// the pending state from the moment they are invited; the roles are only
+// assigned once the invitee accepts and sets a password. Only a hash of the
+// token is stored.
+type Invitation struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	Username   string     `gorm:"type:varchar(100)" json:"username"`
+	Email      string     `gorm:"type:varchar(255)" json:"email"`
+	Roles      []Role     `gorm:"many2many:invitation_roles;" json:"roles"`
+	TokenHash  string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	InvitedBy  *uint      `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Status string `gorm:"-" json:"status"` // Filled in from StatusAt when listing
+}
+
+// StatusAt returns the state of the invitation at the given time.
+func (i Invitation) StatusAt(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	}
+	return InvitationPending
+}
+
+// InvitationRequest is the body of POST /invitations.
+type InvitationRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=100,username"`
+	Email    string `json:"email" binding:"required,email,max=255"`
+	RoleIDs  []uint `json:"role_ids" binding:"dive,gt=0"`
+}
+
+// AcceptInvitationRequest is the body of POST /invitations/accept.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required,max=128"`
+	Password string `json:"password" binding:"required,max=128"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationStatusAt(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Minute)
+
+	assert.Equal(t, InvitationPending, Invitation{ExpiresAt: now.Add(time.Hour)}.StatusAt(now))
+	assert.Equal(t, InvitationExpired, Invitation{ExpiresAt: earlier}.StatusAt(now))
+	assert.Equal(t, InvitationAccepted, Invitation{ExpiresAt: earlier, AcceptedAt: &earlier}.StatusAt(now), "Accepted invitations stay accepted after expiry")
+	assert.Equal(t, InvitationRevoked, Invitation{ExpiresAt: now.Add(time.Hour), RevokedAt: &earlier}.StatusAt(now))
+}
+
+func TestInvitationJSONMarshaling(t *testing.T) {
+	invitation := Invitation{ID: 1, Username: "jane_doe", TokenHash: "secret-hash", Status: InvitationPending}
+
+	actualJSON, err := json.Marshal(invitation)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "secret-hash", "Token hash should not be serialized")
+	assert.Contains(t, string(actualJSON), `"status":"pending"`)
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Throttle key prefixes; failures are tracked per account and per client IP.
+// Password reset requests are tracked under the same keys with the reset
+// prefix in front.
+const (
+	ThrottleKeyUserPrefix  = "user:"
+	ThrottleKeyIPPrefix    = "ip:"
+	ThrottleKeyResetPrefix = "reset:"
+)
+
+// LoginThrottle counts recent failed logins for an account or client IP.
+// Accounts are keyed by username so unknown usernames are throttled exactly
+// like existing ones.
+type LoginThrottle struct {
+	ID             uint       `gorm:"primaryKey"`
+	Key            string     `gorm:"column:throttle_key;type:varchar(191);uniqueIndex" json:"key"`
+	FailedAttempts int        `json:"failed_attempts"`
+	LastFailedAt   *time.Time `json:"last_failed_at"`
+	LockedUntil    *time.Time `json:"locked_until"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Locked reports whether the key is locked out at the given time.
+func (t LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// LoginThrottledError is returned while an account or IP must wait before
+// trying again.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds the wait up for the Retry-After header.
+func (e *LoginThrottledError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottleLocked(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.False(t, LoginThrottle{}.Locked(now), "Throttle without lock should not be locked")
+	assert.False(t, LoginThrottle{LockedUntil: &past}.Locked(now), "Expired lock should not be locked")
+	assert.True(t, LoginThrottle{LockedUntil: &future}.Locked(now), "Active lock should be locked")
+}
+
+func TestLoginThrottledError(t *testing.T) {
+	err := &LoginThrottledError{RetryAfter: 1500 * time.Millisecond}
+
+	assert.Equal(t, 2, err.RetryAfterSeconds(), "Retry-After should be rounded up")
+	assert.EqualError(t, err, "too many failed login attempts, try again in 2 seconds")
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// Token purposes restrict what an intermediate login token may be used for.
+// Tokens with a purpose claim are rejected by the regular auth middleware.
+const (
+	TokenPurposeClaim         = "purpose"
+	TokenPurposeMFAPending    = "mfa_pending"    // password verified, second factor outstanding
+	TokenPurposeMFAEnrollment = "mfa_enrollment" // a role requires MFA the user hasn't enrolled yet
+)
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only a hash
+// of the code is stored.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64)" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LoginResult is returned by the first login step. When MFA is required the
+// token is an intermediate token only accepted by the MFA endpoints.
+type LoginResult struct {
+	Token                 string `json:"token"`
+	MFARequired           bool   `json:"mfa_required"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required"`
+}
+
+// MFAEnrollment holds the TOTP secret shown to the user while enrolling.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // Render as a QR code for authenticator apps
+}
+
+// MFACodeRequest carries a TOTP or recovery code.
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// MFALoginRequest completes a login that returned an mfa_pending token.
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required,max=2048"`
+	Code     string `json:"code" binding:"required,max=32"`
+}
+
+// RoleMFAPolicy toggles whether members of a role must use MFA.
+type RoleMFAPolicy struct {
+	RequireMFA bool `json:"require_mfa"`
+}
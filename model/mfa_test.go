@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserRequiresMFA(t *testing.T) {
+	user := User{Roles: []Role{{Name: "viewer"}}}
+	assert.False(t, user.RequiresMFA(), "Roles without policy should not require MFA")
+
+	user.Roles = append(user.Roles, Role{Name: "admin", RequireMFA: true})
+	assert.True(t, user.RequiresMFA(), "Any role with the policy should require MFA")
+}
+
+func TestUserMFASecretNotSerialized(t *testing.T) {
+	user := User{ID: 1, Username: "johndoe", MFAEnabled: true, TOTPSecret: "JBSWY3DPEHPK3PXP", TOTPLastStep: 42}
+
+	actualJSON, err := json.Marshal(user)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "JBSWY3DPEHPK3PXP", "TOTP secret should not be serialized")
+	assert.NotContains(t, string(actualJSON), "42", "TOTP step should not be serialized")
+	assert.Contains(t, string(actualJSON), `"mfa_enabled":true`, "MFA status should be serialized")
+}
+
+func TestRecoveryCodeJSONMarshaling(t *testing.T) {
+	code := RecoveryCode{ID: 1, UserID: 2, CodeHash: "hash"}
+
+	actualJSON, err := json.Marshal(code)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "hash", "Code hash should not be serialized")
+}
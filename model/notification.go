@@ -0,0 +1,8 @@
+package model
+
+// Notification is a message sent to a user through a domain.Notifier.
+type Notification struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
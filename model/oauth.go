@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// OAuthClient is a registered client of the OAuth2 token endpoint. The scopes
+// it may request are the permissions granted through its roles.
+type OAuthClient struct {
+	ID           uint      `gorm:"primaryKey"`
+	ClientID     string    `gorm:"type:varchar(64);uniqueIndex" json:"client_id" binding:"required,max=64"`
+	SecretHash   string    `gorm:"type:varchar(100)" json:"-"`
+	Name         string    `gorm:"type:varchar(100)" json:"name" binding:"max=100"`
+	Public       bool      `gorm:"default:false" json:"public"` // Has no secret and must use PKCE
+	RedirectURIs []string  `gorm:"type:text;serializer:json" json:"redirect_uris" binding:"dive,url"`
+	Roles        []Role    `gorm:"many2many:oauth_client_roles;" json:"roles"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AllowsRedirectURI reports whether the URI exactly matches a registered one.
+func (c OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// RevokedToken records the jti of a revoked token until it would have expired anyway.
+type RevokedToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	JTI       string    `gorm:"type:varchar(64);uniqueIndex" json:"jti"`
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TokenResponse is the RFC 6749 access token response.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// IntrospectionResponse is the RFC 7662 token introspection response.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
+
+// OAuthError is the RFC 6749 error response and doubles as a Go error.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClientJSONMarshaling(t *testing.T) {
+	// The secret hash must never be serialized
+	client := OAuthClient{ID: 1, ClientID: "reporting", SecretHash: "hash", Name: "Reporting"}
+
+	actualJSON, err := json.Marshal(client)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "hash", "Secret hash should not be serialized")
+	assert.Contains(t, string(actualJSON), `"client_id":"reporting"`, "Client id should be serialized")
+}
+
+func TestIntrospectionResponseInactive(t *testing.T) {
+	// An inactive token reveals nothing but its state
+	actualJSON, err := json.Marshal(IntrospectionResponse{})
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.JSONEq(t, `{"active":false}`, string(actualJSON), "Inactive response should only contain active")
+}
+
+func TestOAuthError(t *testing.T) {
+	err := &OAuthError{Code: "invalid_client", Description: "unknown client"}
+
+	assert.Equal(t, "invalid_client: unknown client", err.Error(), "Error should include code and description")
+	assert.Equal(t, "invalid_scope", (&OAuthError{Code: "invalid_scope"}).Error(), "Error without description should be the code")
+}
+
+func TestOAuthClientAllowsRedirectURI(t *testing.T) {
+	client := OAuthClient{RedirectURIs: []string{"https://app.example.com/callback"}}
+
+	// Redirect URIs must match exactly
+	assert.True(t, client.AllowsRedirectURI("https://app.example.com/callback"), "Registered URI should be allowed")
+	assert.False(t, client.AllowsRedirectURI("https://app.example.com/callback/evil"), "Extended URI should not be allowed")
+	assert.False(t, client.AllowsRedirectURI(""), "Empty URI should not be allowed")
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// TokenPurposeOIDCAccess is the purpose of the access tokens issued to OpenID
+// Connect clients. They only grant access to the userinfo endpoint, not to
+// the API on behalf of the user.
+const TokenPurposeOIDCAccess = "oidc_access"
+
+// AuthorizationCode is a single-use code of the authorization code flow.
+// Only a hash of the code is stored.
+type AuthorizationCode struct {
+	ID            uint       `gorm:"primaryKey"`
+	CodeHash      string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	ClientID      string     `gorm:"type:varchar(64)" json:"client_id"`
+	UserID        uint       `gorm:"index" json:"user_id"`
+	RedirectURI   string     `gorm:"type:varchar(255)" json:"redirect_uri"`
+	Scope         string     `gorm:"type:varchar(255)" json:"scope"`
+	Nonce         string     `gorm:"type:varchar(255)" json:"nonce"`
+	CodeChallenge string     `gorm:"type:varchar(128)" json:"-"`
+	AuthTime      time.Time  `json:"auth_time"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	UsedAt        *time.Time `json:"used_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// AuthorizeRequest holds the parameters of an OpenID Connect authentication request.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// UserInfo is the response of the OpenID Connect userinfo endpoint.
+type UserInfo struct {
+	Sub               string   `json:"sub"`
+	PreferredUsername string   `json:"preferred_username"`
+	Roles             []string `json:"roles"`
+}
+
+// OpenIDConfiguration is the OpenID Connect discovery document.
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// Page sizes of list endpoints.
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// PageRequest holds the query parameters shared by every list endpoint. Sort
+// names a field, prefixed with "-" for descending order; Cursor is the
+// next_cursor of the previous page, used with the same filters and sort.
+type PageRequest struct {
+	Cursor string `form:"cursor" binding:"max=512"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=200"`
+	Sort   string `form:"sort" binding:"max=50"`
+}
+
+// PageLimit returns the requested page size or the default one.
+func (p PageRequest) PageLimit() int {
+	if p.Limit <= 0 {
+		return DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// PageInfo describes where a page ends. NextCursor is empty on the last page.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CreatedRange filters on the creation time; either bound may be omitted.
+type CreatedRange struct {
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// bcryptMaxBytes is the longest password bcrypt can hash.
+const bcryptMaxBytes = 72
+
+// PasswordPolicy describes the rules new passwords must satisfy. The history
+// and breach rules need stored data and are checked by the user use case.
+type PasswordPolicy struct {
+	MinLength         int
+	RequireUppercase  bool
+	RequireLowercase  bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	DisallowUsername  bool
+	HistorySize       int    // Number of previous passwords that can't be reused
+	BreachedRangesDir string // Directory of k-anonymity range files, empty to skip the check
+}
+
+// PolicyViolation is a single failed password rule.
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password failed.
+type PasswordPolicyError struct {
+	Violations []PolicyViolation `json:"violations"`
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+	return "password does not meet the policy: " + strings.Join(messages, "; ")
+}
+
+// Check returns the violations of the rules that only need the password itself.
+func (p PasswordPolicy) Check(username string, password string) []PolicyViolation {
+	if password == "" {
+		return []PolicyViolation{{Rule: "required", Message: "password is required"}}
+	}
+
+	var violations []PolicyViolation
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, PolicyViolation{Rule: "min_length", Message: fmt.Sprintf("password must be at least %d characters", p.MinLength)})
+	}
+	if len(password) > bcryptMaxBytes {
+		violations = append(violations, PolicyViolation{Rule: "max_length", Message: fmt.Sprintf("password must be at most %d bytes", bcryptMaxBytes)})
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, PolicyViolation{Rule: "uppercase", Message: "password must contain an uppercase letter"})
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, PolicyViolation{Rule: "lowercase", Message: "password must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, PolicyViolation{Rule: "digit", Message: "password must contain a digit"})
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, PolicyViolation{Rule: "symbol", Message: "password must contain a symbol"})
+	}
+	if p.DisallowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, PolicyViolation{Rule: "username", Message: "password must not contain the username"})
+	}
+	return violations
+}
+
+// PasswordHistory keeps the hashes of previous passwords to prevent reuse.
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey"`
+	UserID       uint      `gorm:"index" json:"user_id"`
+	PasswordHash string    `gorm:"type:varchar(255)" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
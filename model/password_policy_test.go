@@ -0,0 +1,45 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(violations []PolicyViolation) []string {
+	names := []string{}
+	for _, violation := range violations {
+		names = append(names, violation.Rule)
+	}
+	return names
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:        12,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+	}
+
+	assert.Empty(t, policy.Check("john_doe", "Correct-Horse-42"), "Compliant password should pass")
+	assert.Equal(t, []string{"required"}, rules(policy.Check("john_doe", "")), "Empty password should only report required")
+	assert.Equal(t, []string{"min_length", "uppercase", "digit", "symbol"}, rules(policy.Check("john_doe", "short")), "Each failed rule should be listed")
+	assert.Equal(t, []string{"username"}, rules(policy.Check("john_doe", "My-JOHN_DOE-pass1")), "Username should be matched case-insensitively")
+	assert.Contains(t, rules(policy.Check("john_doe", strings.Repeat("Aa1-", 19))), "max_length", "Passwords bcrypt can't hash should be rejected")
+
+	// A zero policy only requires a password
+	assert.Empty(t, PasswordPolicy{}.Check("john_doe", "x"), "Zero policy should accept any password")
+}
+
+func TestPasswordPolicyError(t *testing.T) {
+	err := &PasswordPolicyError{Violations: []PolicyViolation{
+		{Rule: "min_length", Message: "password must be at least 12 characters"},
+		{Rule: "digit", Message: "password must contain a digit"},
+	}}
+
+	assert.EqualError(t, err, "password does not meet the policy: password must be at least 12 characters; password must contain a digit")
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// PasswordResetToken is a single-use token mailed to a user who forgot their
+// password. Only a hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"index" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the token is neither used nor expired at the given time.
+func (t PasswordResetToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// ChangePasswordRequest is the body of POST /users/me/password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,max=128"`
+	NewPassword     string `json:"new_password" binding:"required,max=128"`
+}
+
+// PasswordResetRequest starts a reset for the account with the given username or email.
+type PasswordResetRequest struct {
+	Identifier string `json:"identifier" binding:"required,max=255"`
+}
+
+// ResetPasswordRequest completes a reset with the token from the notification.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required,max=128"`
+	NewPassword string `json:"new_password" binding:"required,max=128"`
+}
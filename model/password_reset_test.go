@@ -0,0 +1,26 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetTokenUsable(t *testing.T) {
+	now := time.Now()
+	used := now.Add(-time.Minute)
+
+	assert.True(t, PasswordResetToken{ExpiresAt: now.Add(time.Minute)}.Usable(now), "Fresh token should be usable")
+	assert.False(t, PasswordResetToken{ExpiresAt: now.Add(-time.Minute)}.Usable(now), "Expired token should not be usable")
+	assert.False(t, PasswordResetToken{ExpiresAt: now.Add(time.Minute), UsedAt: &used}.Usable(now), "Used token should not be usable")
+}
+
+func TestPasswordResetTokenJSONMarshaling(t *testing.T) {
+	token := PasswordResetToken{ID: 1, UserID: 2, TokenHash: "hash"}
+
+	actualJSON, err := json.Marshal(token)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "hash", "Token hash should not be serialized")
+}
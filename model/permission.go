@@ -1,6 +1,29 @@
 package model
 
+import "time"
+
 type Permission struct {
 	ID   uint   `gorm:"primaryKey"`
 	Name string `gorm:"type:varchar(100);uniqueIndex" json:"name"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PermissionFilter holds the query parameters of GET /permissions.
+// Permissions can be sorted by id, name and created_at.
+type PermissionFilter struct {
+	PageRequest
+	CreatedRange
+	NamePrefix string `form:"name_prefix" binding:"max=100"`
+}
+
+// CreatePermissionRequest is the body of POST /permissions.
+type CreatePermissionRequest struct {
+	Name string `json:"name" binding:"required,max=100,name"`
+}
+
+// Permission returns the permission to create from the request.
+func (r CreatePermissionRequest) Permission() Permission {
+	return Permission{Name: r.Name}
 }
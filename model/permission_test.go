@@ -31,7 +31,7 @@ func TestPermissionJSONMarshaling(t *testing.T) {
 	// Test JSON marshaling for the Permission struct
 	perm := Permission{ID: 1, Name: "manage_users"}
 
-	expectedJSON := `{"ID":1,"name":"manage_users"}`
+	expectedJSON := `{"ID":1,"name":"manage_users","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`
 	actualJSON, err := json.Marshal(perm)
 	assert.NoError(t, err, "JSON marshaling should not produce an error")
 	assert.JSONEq(t, expectedJSON, string(actualJSON), "JSON output does not match expected format")
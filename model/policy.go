@@ -0,0 +1,129 @@
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy declares every role and permission and the permissions each role
+// grants. It's kept in version control and synced to the database with the
+// policy plan and apply commands, which delete roles and permissions it
+// doesn't declare.
+type Policy struct {
+	Permissions []string     `yaml:"permissions" json:"permissions"`
+	Roles       []PolicyRole `yaml:"roles" json:"roles"`
+}
+
+type PolicyRole struct {
+	Name        string   `yaml:"name" json:"name"`
+	RequireMFA  bool     `yaml:"require_mfa" json:"require_mfa"`
+	Permissions []string `yaml:"permissions" json:"permissions"`
+}
+
+// ParsePolicy reads a policy from YAML or JSON. Unknown keys are rejected so
+// typos don't silently drop settings.
+func ParsePolicy(data []byte) (Policy, error) {
+	var policy Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return Policy{}, err
+	}
+	return policy, nil
+}
+
+// Validate returns the invalid fields of the policy: names must be set, fit
+// the database and be unique, and roles may only grant declared permissions.
+func (p Policy) Validate() []FieldError {
+	var fields []FieldError
+	checkName := func(field string, name string, seen map[string]bool) {
+		switch {
+		case name == "":
+			fields = append(fields, FieldError{Name: field, Reason: "is required"})
+		case len(name) > 100:
+			fields = append(fields, FieldError{Name: field, Reason: "must contain at most 100 characters"})
+		case !ValidName(name):
+			fields = append(fields, FieldError{Name: field, Reason: NameReason})
+		case seen[name]:
+			fields = append(fields, FieldError{Name: field, Reason: fmt.Sprintf("%s is declared twice", name)})
+		}
+		seen[name] = true
+	}
+
+	permissions := map[string]bool{}
+	for i, name := range p.Permissions {
+		checkName(fmt.Sprintf("permissions[%d]", i), name, permissions)
+	}
+	roles := map[string]bool{}
+	for i, role := range p.Roles {
+		checkName(fmt.Sprintf("roles[%d].name", i), role.Name, roles)
+		granted := map[string]bool{}
+		for j, name := range role.Permissions {
+			field := fmt.Sprintf("roles[%d].permissions[%d]", i, j)
+			switch {
+			case !permissions[name]:
+				fields = append(fields, FieldError{Name: field, Reason: fmt.Sprintf("%s isn't a declared permission", name)})
+			case granted[name]:
+				fields = append(fields, FieldError{Name: field, Reason: fmt.Sprintf("%s is granted twice", name)})
+			}
+			granted[name] = true
+		}
+	}
+	return fields
+}
+
+// PolicyBinding is a permission granted by a role.
+type PolicyBinding struct {
+	Role       string
+	Permission string
+}
+
+// PolicyPlan lists the changes that make the database match a policy. Roles
+// and permissions are referred to by name; deleting them also removes their
+// bindings and role assignments.
+type PolicyPlan struct {
+	CreatePermissions []string
+	CreateRoles       []Role
+	UpdateRoles       []Role // Roles whose RequireMFA changes
+	Revoke            []PolicyBinding
+	Grant             []PolicyBinding
+	DeleteRoles       []string
+	DeletePermissions []string
+}
+
+// Empty reports whether the database already matches the policy.
+func (p PolicyPlan) Empty() bool {
+	return len(p.CreatePermissions)+len(p.CreateRoles)+len(p.UpdateRoles)+len(p.Revoke)+
+		len(p.Grant)+len(p.DeleteRoles)+len(p.DeletePermissions) == 0
+}
+
+// Changes describes the plan one change per line, in the order it's applied.
+func (p PolicyPlan) Changes() []string {
+	var changes []string
+	for _, name := range p.CreatePermissions {
+		changes = append(changes, "+ permission "+name)
+	}
+	for _, role := range p.CreateRoles {
+		changes = append(changes, fmt.Sprintf("+ role %s (require_mfa: %t)", role.Name, role.RequireMFA))
+	}
+	for _, role := range p.UpdateRoles {
+		changes = append(changes, fmt.Sprintf("~ role %s (require_mfa: %t)", role.Name, role.RequireMFA))
+	}
+	for _, binding := range p.Revoke {
+		changes = append(changes, fmt.Sprintf("- grant %s to role %s", binding.Permission, binding.Role))
+	}
+	for _, binding := range p.Grant {
+		changes = append(changes, fmt.Sprintf("+ grant %s to role %s", binding.Permission, binding.Role))
+	}
+	for _, name := range p.DeleteRoles {
+		changes = append(changes, "- role "+name)
+	}
+	for _, name := range p.DeletePermissions {
+		changes = append(changes, "- permission "+name)
+	}
+	return changes
+}
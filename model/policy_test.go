@@ -0,0 +1,91 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		policy, err := ParsePolicy([]byte(`
+permissions: [manage_users, manage_roles]
+roles:
+  - name: admin
+    require_mfa: true
+    permissions: [manage_users, manage_roles]
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, Policy{
+			Permissions: []string{"manage_users", "manage_roles"},
+			Roles:       []PolicyRole{{Name: "admin", RequireMFA: true, Permissions: []string{"manage_users", "manage_roles"}}},
+		}, policy)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		policy, err := ParsePolicy([]byte(`{"permissions": ["manage_users"], "roles": [{"name": "auditor", "permissions": ["manage_users"]}]}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []PolicyRole{{Name: "auditor", Permissions: []string{"manage_users"}}}, policy.Roles)
+	})
+
+	t.Run("Empty file declares nothing", func(t *testing.T) {
+		policy, err := ParsePolicy(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, Policy{}, policy)
+	})
+
+	t.Run("Unknown keys are rejected", func(t *testing.T) {
+		_, err := ParsePolicy([]byte("roles:\n  - name: admin\n    requires_mfa: true\n"))
+		assert.ErrorContains(t, err, "requires_mfa")
+	})
+}
+
+func TestPolicyValidate(t *testing.T) {
+	valid := Policy{
+		Permissions: []string{"manage_users"},
+		Roles:       []PolicyRole{{Name: "admin", Permissions: []string{"manage_users"}}},
+	}
+	assert.Empty(t, valid.Validate())
+
+	invalid := Policy{
+		Permissions: []string{"manage_users", "manage_users", "", "read;write"},
+		Roles: []PolicyRole{
+			{Name: "admin", Permissions: []string{"manage_users", "manage_roles", "manage_users"}},
+			{Name: "admin"},
+			{Name: " "},
+		},
+	}
+	assert.Equal(t, []FieldError{
+		{Name: "permissions[1]", Reason: "manage_users is declared twice"},
+		{Name: "permissions[2]", Reason: "is required"},
+		{Name: "permissions[3]", Reason: NameReason},
+		{Name: "roles[0].permissions[1]", Reason: "manage_roles isn't a declared permission"},
+		{Name: "roles[0].permissions[2]", Reason: "manage_users is granted twice"},
+		{Name: "roles[1].name", Reason: "admin is declared twice"},
+		{Name: "roles[2].name", Reason: NameReason},
+	}, invalid.Validate())
+}
+
+func TestPolicyPlanChanges(t *testing.T) {
+	assert.True(t, PolicyPlan{}.Empty())
+
+	plan := PolicyPlan{
+		CreatePermissions: []string{"manage_roles"},
+		CreateRoles:       []Role{{Name: "auditor"}},
+		UpdateRoles:       []Role{{Name: "admin", RequireMFA: true}},
+		Revoke:            []PolicyBinding{{Role: "admin", Permission: "manage_users"}},
+		Grant:             []PolicyBinding{{Role: "admin", Permission: "manage_roles"}},
+		DeleteRoles:       []string{"legacy"},
+		DeletePermissions: []string{"old_permission"},
+	}
+	assert.False(t, plan.Empty())
+	assert.Equal(t, []string{
+		"+ permission manage_roles",
+		"+ role auditor (require_mfa: false)",
+		"~ role admin (require_mfa: true)",
+		"- grant manage_users to role admin",
+		"+ grant manage_roles to role admin",
+		"- role legacy",
+		"- permission old_permission",
+	}, plan.Changes())
+}
@@ -4,4 +4,29 @@ type Response struct {
 	StatusCode int         `json:"status_code"`
 	Message    string      `json:"message"`
 	Data       interface{} `json:"data,omitempty"`
+	Page       *PageInfo   `json:"page,omitempty"` // Set by list endpoints
+}
+
+// ProblemContentType is the media type of Problem bodies.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body, used for every error response
+// outside the OAuth endpoints, which follow RFC 6749 instead.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members of specific problems
+	InvalidParams []FieldError      `json:"invalid_params,omitempty"`
+	Violations    []PolicyViolation `json:"violations,omitempty"`
+	RetryAfter    int               `json:"retry_after,omitempty"`
+}
+
+// FieldError explains why a single request field or path parameter was rejected.
+type FieldError struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
 }
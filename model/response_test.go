@@ -65,3 +65,9 @@ func TestResponseDefaultValues(t *testing.T) {
 	assert.Equal(t, "", resp.Message, "Default Message should be an empty string")
 	assert.Nil(t, resp.Data, "Default Data should be nil")
 }
+
+func TestPageLimit(t *testing.T) {
+	assert.Equal(t, DefaultPageLimit, PageRequest{}.PageLimit())
+	assert.Equal(t, 10, PageRequest{Limit: 10}.PageLimit())
+	assert.Equal(t, MaxPageLimit, PageRequest{Limit: 1000}.PageLimit())
+}
@@ -1,7 +1,61 @@
 package model
 
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
 type Role struct {
 	ID          uint         `gorm:"primaryKey"`
 	Name        string       `gorm:"type:varchar(100);uniqueIndex" json:"name"`
 	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions"`
+
+	RequireMFA bool `gorm:"default:false" json:"require_mfa"` // Members must log in with a second factor
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RolePermission is the join table of Role.Permissions, with PermissionID
+// indexed for finding the roles that grant a permission.
+type RolePermission struct {
+	RoleID       uint `gorm:"primaryKey"`
+	PermissionID uint `gorm:"primaryKey;index"`
+}
+
+// RoleFilter holds the query parameters of GET /roles. Roles can be sorted by
+// id, name and created_at.
+type RoleFilter struct {
+	PageRequest
+	CreatedRange
+	NamePrefix string `form:"name_prefix" binding:"max=100"`
+	Permission string `form:"permission" binding:"max=100"` // Grants the permission with this name
+}
+
+// CreateRoleRequest is the body of POST /roles. The role is created, granted
+// the permissions and assigned to the users in one transaction.
+type CreateRoleRequest struct {
+	Name          string `json:"name" binding:"required,max=100,name"`
+	RequireMFA    bool   `json:"require_mfa"`
+	PermissionIDs []uint `json:"permission_ids" binding:"max=100,dive,min=1"`
+	UserIDs       []uint `json:"user_ids" binding:"max=1000,dive,min=1"`
+}
+
+// NameReason explains why a role or permission name is refused.
+const NameReason = "can't contain ';' or control characters, nor start or end with a space"
+
+// ValidName reports whether name can name a role or permission. Names are
+// listed ';'-separated and trimmed in user imports, so they can't contain ';'
+// nor start or end with a space; control characters are refused as well.
+func ValidName(name string) bool {
+	if name == "" || strings.TrimSpace(name) != name || strings.Contains(name, ";") {
+		return false
+	}
+	return strings.IndexFunc(name, unicode.IsControl) < 0
+}
+
+// Role returns the role to create from the request.
+func (r CreateRoleRequest) Role() Role {
+	return Role{Name: r.Name, RequireMFA: r.RequireMFA}
 }
@@ -49,9 +49,11 @@ func TestRoleJSONMarshaling(t *testing.T) {
 		"ID": 1,
 		"name": "Admin",
 		"permissions": [
-			{"ID": 1, "name": "read"},
-			{"ID": 2, "name": "write"}
-		]
+			{"ID": 1, "name": "read", "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"},
+			{"ID": 2, "name": "write", "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"}
+		],
+		"require_mfa": false,
+		"created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"
 	}`
 	actualJSON, err := json.Marshal(role)
 	assert.NoError(t, err, "JSON marshaling should not produce an error")
@@ -65,4 +67,14 @@ func TestRoleDefaultValues(t *testing.T) {
 	assert.Equal(t, uint(0), role.ID, "Default ID should be 0")
 	assert.Equal(t, "", role.Name, "Default Name should be an empty string")
 	assert.Nil(t, role.Permissions, "Default Permissions should be nil")
+	assert.False(t, role.RequireMFA, "Default RequireMFA should be false")
+}
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"admin", "manage_users", "Read Only", "gestión"} {
+		assert.True(t, ValidName(name), "expected %q to be valid", name)
+	}
+	for _, name := range []string{"", "   ", " admin", "admin\t", "admin;auditor", "ad\x00min", "ad\nmin"} {
+		assert.False(t, ValidName(name), "expected %q to be invalid", name)
+	}
 }
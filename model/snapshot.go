@@ -0,0 +1,178 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is the format version of exported snapshots. Imports reject
+// other versions.
+const SnapshotVersion = 1
+
+// Import modes. Merge only adds what's missing; replace also removes the
+// roles, permissions, grants and role assignments the snapshot doesn't have.
+// Users missing from the snapshot keep their account, without roles.
+const (
+	ImportModeMerge   = "merge"
+	ImportModeReplace = "replace"
+)
+
+// Snapshot is a portable copy of the access control state, used to clone
+// environments, back up and migrate from other systems. Entities are referred
+// to by name since ids differ between databases.
+type Snapshot struct {
+	Version     int            `json:"version"`
+	ExportedAt  time.Time      `json:"exported_at"`
+	Permissions []string       `json:"permissions"`
+	Roles       []PolicyRole   `json:"roles"`
+	Users       []SnapshotUser `json:"users"`
+}
+
+// SnapshotUser is a user and the names of its roles. PasswordHash is only
+// exported on request; users imported without one must reset their password.
+type SnapshotUser struct {
+	Username       string   `json:"username"`
+	Email          string   `json:"email"`
+	Status         string   `json:"status"`
+	ServiceAccount bool     `json:"service_account"`
+	PasswordHash   string   `json:"password_hash,omitempty"`
+	Roles          []string `json:"roles"`
+}
+
+// ExportOptions holds the query parameters of GET /admin/export.
+type ExportOptions struct {
+	IncludePasswordHashes bool `form:"include_password_hashes"`
+}
+
+// ImportOptions holds the query parameters of POST /admin/import. Mode
+// defaults to merge.
+type ImportOptions struct {
+	Mode   string `form:"mode" binding:"omitempty,oneof=merge replace"`
+	DryRun bool   `form:"dry_run"`
+}
+
+// Policy returns the roles and permissions of the snapshot.
+func (s Snapshot) Policy() Policy {
+	return Policy{Permissions: s.Permissions, Roles: s.Roles}
+}
+
+// Validate returns the invalid fields of the snapshot: its roles and
+// permissions must form a valid policy, and users must have usernames unique
+// regardless of case and only be assigned declared roles.
+func (s Snapshot) Validate() []FieldError {
+	var fields []FieldError
+	if s.Version != SnapshotVersion {
+		fields = append(fields, FieldError{Name: "version", Reason: fmt.Sprintf("must be %d", SnapshotVersion)})
+	}
+	fields = append(fields, s.Policy().Validate()...)
+
+	roles := map[string]bool{}
+	for _, role := range s.Roles {
+		roles[role.Name] = true
+	}
+	usernames := map[string]bool{}
+	for i, user := range s.Users {
+		field := fmt.Sprintf("users[%d]", i)
+		switch {
+		case user.Username == "":
+			fields = append(fields, FieldError{Name: field + ".username", Reason: "is required"})
+		case len(user.Username) > 100:
+			fields = append(fields, FieldError{Name: field + ".username", Reason: "must contain at most 100 characters"})
+		case usernames[strings.ToLower(user.Username)]:
+			fields = append(fields, FieldError{Name: field + ".username", Reason: fmt.Sprintf("%s is declared twice", user.Username)})
+		}
+		usernames[strings.ToLower(user.Username)] = true
+		if len(user.Email) > 255 {
+			fields = append(fields, FieldError{Name: field + ".email", Reason: "must contain at most 255 characters"})
+		}
+		if _, known := userStatusTransitions[user.Status]; !known && user.Status != "" {
+			fields = append(fields, FieldError{Name: field + ".status", Reason: "must be one of: pending active disabled locked"})
+		}
+
+		assigned := map[string]bool{}
+		for j, name := range user.Roles {
+			roleField := fmt.Sprintf("%s.roles[%d]", field, j)
+			switch {
+			case !roles[name]:
+				fields = append(fields, FieldError{Name: roleField, Reason: fmt.Sprintf("%s isn't a declared role", name)})
+			case assigned[name]:
+				fields = append(fields, FieldError{Name: roleField, Reason: fmt.Sprintf("%s is assigned twice", name)})
+			}
+			assigned[name] = true
+		}
+	}
+	return fields
+}
+
+// UserBinding is a role assigned to a user.
+type UserBinding struct {
+	Username string
+	Role     string
+}
+
+// ImportConflict is a difference between the database and the snapshot that
+// the import leaves as is, such as a user whose email changed.
+type ImportConflict struct {
+	Entity   string `json:"entity"` // role or user
+	Name     string `json:"name"`
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Imported string `json:"imported"`
+}
+
+// ImportPlan lists the changes that import a snapshot. Users are created after
+// the roles and permissions change and before roles are assigned to them.
+type ImportPlan struct {
+	PolicyPlan
+	CreateUsers []SnapshotUser
+	Unassign    []UserBinding
+	Assign      []UserBinding
+	Conflicts   []ImportConflict
+}
+
+// Empty reports whether importing the snapshot changes nothing. Conflicts
+// aren't changes.
+func (p ImportPlan) Empty() bool {
+	return p.PolicyPlan.Empty() && len(p.CreateUsers)+len(p.Unassign)+len(p.Assign) == 0
+}
+
+// Changes describes the plan one change per line, in the order it's applied.
+func (p ImportPlan) Changes() []string {
+	changes := p.PolicyPlan.Changes()
+	for _, user := range p.CreateUsers {
+		changes = append(changes, "+ user "+user.Username)
+	}
+	for _, binding := range p.Unassign {
+		changes = append(changes, fmt.Sprintf("- assign role %s to user %s", binding.Role, binding.Username))
+	}
+	for _, binding := range p.Assign {
+		changes = append(changes, fmt.Sprintf("+ assign role %s to user %s", binding.Role, binding.Username))
+	}
+	return changes
+}
+
+// ImportResult is the response of POST /admin/import.
+type ImportResult struct {
+	Mode      string           `json:"mode"`
+	DryRun    bool             `json:"dry_run"`
+	Changes   []string         `json:"changes"`
+	Conflicts []ImportConflict `json:"conflicts"`
+}
+
+// Result reports the plan as imported with the options.
+func (p ImportPlan) Result(options ImportOptions) ImportResult {
+	result := ImportResult{
+		Mode:      options.Mode,
+		DryRun:    options.DryRun,
+		Changes:   p.Changes(),
+		Conflicts: p.Conflicts,
+	}
+	if result.Changes == nil {
+		result.Changes = []string{}
+	}
+	if result.Conflicts == nil {
+		result.Conflicts = []ImportConflict{}
+	}
+	return result
+}
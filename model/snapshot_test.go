@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotValidate(t *testing.T) {
+	valid := Snapshot{
+		Version:     SnapshotVersion,
+		Permissions: []string{"manage_users"},
+		Roles:       []PolicyRole{{Name: "admin", Permissions: []string{"manage_users"}}},
+		Users:       []SnapshotUser{{Username: "alice", Status: UserStatusActive, Roles: []string{"admin"}}, {Username: "legacy"}},
+	}
+	assert.Empty(t, valid.Validate())
+
+	invalid := Snapshot{
+		Roles: []PolicyRole{{Name: "admin"}},
+		Users: []SnapshotUser{
+			{Username: "alice", Email: strings.Repeat("a", 256), Roles: []string{"admin", "auditor", "admin"}},
+			{Username: "Alice", Status: "deleted"},
+			{},
+		},
+	}
+	assert.Equal(t, []FieldError{
+		{Name: "version", Reason: "must be 1"},
+		{Name: "users[0].email", Reason: "must contain at most 255 characters"},
+		{Name: "users[0].roles[1]", Reason: "auditor isn't a declared role"},
+		{Name: "users[0].roles[2]", Reason: "admin is assigned twice"},
+		{Name: "users[1].username", Reason: "Alice is declared twice"},
+		{Name: "users[1].status", Reason: "must be one of: pending active disabled locked"},
+		{Name: "users[2].username", Reason: "is required"},
+	}, invalid.Validate())
+}
+
+func TestImportPlanResult(t *testing.T) {
+	empty := ImportPlan{Conflicts: []ImportConflict{{Entity: "user", Name: "alice", Field: "email"}}}
+	assert.True(t, empty.Empty(), "Conflicts aren't changes")
+	assert.Equal(t, ImportResult{Mode: ImportModeMerge, Changes: []string{}, Conflicts: empty.Conflicts}, empty.Result(ImportOptions{Mode: ImportModeMerge}))
+
+	plan := ImportPlan{
+		PolicyPlan:  PolicyPlan{CreateRoles: []Role{{Name: "auditor"}}, DeleteRoles: []string{"legacy"}},
+		CreateUsers: []SnapshotUser{{Username: "bob"}},
+		Unassign:    []UserBinding{{Username: "alice", Role: "admin"}},
+		Assign:      []UserBinding{{Username: "bob", Role: "auditor"}},
+	}
+	assert.False(t, plan.Empty())
+	assert.Equal(t, ImportResult{
+		Mode:   ImportModeReplace,
+		DryRun: true,
+		Changes: []string{
+			"+ role auditor (require_mfa: false)",
+			"- role legacy",
+			"+ user bob",
+			"- assign role admin to user alice",
+			"+ assign role auditor to user bob",
+		},
+		Conflicts: []ImportConflict{},
+	}, plan.Result(ImportOptions{Mode: ImportModeReplace, DryRun: true}))
+}
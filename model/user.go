@@ -1,8 +1,106 @@
 package model
 
+import (
+	"regexp"
+	"time"
+)
+
+// usernamePattern is the charset of usernames; they appear in URLs and audit logs.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
 type User struct {
 	ID       uint   `gorm:"primaryKey"`
 	Username string `gorm:"type:varchar(100);uniqueIndex" json:"username"` // Set a length for Username
-	Password string `gorm:"type:varchar(100)" json:"password"`             // Set a length for Username
+	Password string `gorm:"type:varchar(255)" json:"-"`                    // Encoded hash, argon2id PHC strings exceed 100 characters
 	Roles    []Role `gorm:"many2many:user_roles;" json:"roles"`
+	Email    string `gorm:"type:varchar(255);index" json:"email"` // Used to deliver password reset links
+
+	SessionsValidAfter *time.Time `json:"-"` // Tokens issued earlier are rejected, set when the password changes
+
+	Status          string     `gorm:"type:varchar(20);default:active;index" json:"status"`
+	StatusReason    string     `gorm:"type:varchar(255)" json:"status_reason"`
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+
+	ServiceAccount bool `gorm:"default:false" json:"service_account"` // Machine identity authenticating with API keys only
+
+	MFAEnabled   bool   `gorm:"default:false" json:"mfa_enabled"`
+	TOTPSecret   string `gorm:"type:varchar(64)" json:"-"` // Set on enrollment, active once MFAEnabled
+	TOTPLastStep int64  `json:"-"`                         // Last accepted time step, prevents code replay
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"` // Indexed for sorting and filtering lists
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserRole is the join table of User.Roles. Its primary key serves lookups by
+// user; RoleID is indexed for listing the members of a role.
+type UserRole struct {
+	UserID uint `gorm:"primaryKey"`
+	RoleID uint `gorm:"primaryKey;index"`
+}
+
+// UserFilter holds the query parameters of GET /users. Users can be sorted by
+// id, username and created_at.
+type UserFilter struct {
+	PageRequest
+	CreatedRange
+	UsernamePrefix string `form:"username_prefix" binding:"max=100"`
+	Status         string `form:"status" binding:"omitempty,oneof=pending active disabled locked"`
+	Role           string `form:"role" binding:"max=100"`       // Has the role with this name
+	Permission     string `form:"permission" binding:"max=100"` // Has the permission through any role
+}
+
+// CreateUserRequest is the body of POST /users.
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=100,username"`
+	Password string `json:"password" binding:"required,max=128"`
+	Email    string `json:"email" binding:"omitempty,email,max=255"`
+}
+
+// User returns the user to create from the request.
+func (r CreateUserRequest) User() User {
+	return User{Username: r.Username, Password: r.Password, Email: r.Email}
+}
+
+// LoginRequest is the body of POST /users/login.
+type LoginRequest struct {
+	Username string `json:"username" binding:"required,max=255"`
+	Password string `json:"password" binding:"required,max=128"`
+}
+
+// Credentials returns the user to authenticate from the request.
+func (r LoginRequest) Credentials() User {
+	return User{Username: r.Username, Password: r.Password}
+}
+
+// ValidUsername reports whether the username only uses the username charset.
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+// Active reports whether the user may authenticate. Users stored before
+// statuses existed have none and count as active.
+func (u User) Active() bool {
+	return u.Status == "" || u.Status == UserStatusActive
+}
+
+// SessionValid reports whether a token issued at issuedAt is still accepted.
+// Token timestamps have second precision, so the cut-off is rounded down.
+func (u User) SessionValid(issuedAt time.Time) bool {
+	if !u.Active() {
+		return false
+	}
+	if u.SessionsValidAfter == nil {
+		return true
+	}
+	return !issuedAt.Before(u.SessionsValidAfter.Truncate(time.Second))
+}
+
+// RequiresMFA reports whether any of the user's roles enforces MFA. Roles must be loaded.
+func (u User) RequiresMFA() bool {
+	for _, role := range u.Roles {
+		if role.RequireMFA {
+			return true
+		}
+	}
+	return false
 }
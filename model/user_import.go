@@ -0,0 +1,273 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+// MaxUserImportRows bounds the users of one bulk import.
+const MaxUserImportRows = 10000
+
+// Formats of bulk user imports. CSV files start with a header naming the
+// username, email, password and roles columns, in any order; only username is
+// required and roles are separated by semicolons. JSON lines files hold one
+// UserImportRow object per line.
+const (
+	UserImportFormatCSV   = "csv"
+	UserImportFormatJSONL = "jsonl"
+)
+
+// Import modes. A transaction creates every user or none; per row commits each
+// user on its own, so the valid rows are created even if others fail.
+const (
+	UserImportModeTransaction = "transaction"
+	UserImportModePerRow      = "per_row"
+)
+
+// Password handling of bulk imports.
+const (
+	UserImportPasswordProvided = "provided" // Each row sets the password
+	UserImportPasswordGenerate = "generate" // Random passwords, returned once in the report
+	UserImportPasswordInvite   = "invite"   // Pending users sent an invitation to choose one
+)
+
+// Outcomes of a bulk import row.
+const (
+	UserImportCreated = "created"
+	UserImportInvited = "invited"
+	UserImportFailed  = "failed"
+	UserImportSkipped = "skipped" // Valid, but another row failed the transaction
+)
+
+// UserImportOptions holds the query parameters of POST /users/import. They
+// default to a CSV file imported in a transaction with provided passwords.
+type UserImportOptions struct {
+	Format   string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+	Mode     string `form:"mode" binding:"omitempty,oneof=transaction per_row"`
+	Password string `form:"password" binding:"omitempty,oneof=provided generate invite"`
+}
+
+// UserImportRow is a user to create and the names of its roles. Line is the
+// line of the file the row starts on.
+type UserImportRow struct {
+	Line     int      `json:"-"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles"`
+}
+
+// ParseUserImport reads the rows of a bulk import in the given format.
+// Malformed files are rejected as a whole, invalid rows are left to Validate.
+func ParseUserImport(r io.Reader, format string) ([]UserImportRow, error) {
+	var (
+		rows []UserImportRow
+		err  error
+	)
+	switch format {
+	case UserImportFormatCSV, "":
+		rows, err = parseUserImportCSV(r)
+	case UserImportFormatJSONL:
+		rows, err = parseUserImportJSONL(r)
+	default:
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the file contains no users")
+	}
+	if len(rows) > MaxUserImportRows {
+		return nil, fmt.Errorf("an import may contain at most %d users", MaxUserImportRows)
+	}
+	return rows, nil
+}
+
+func parseUserImportCSV(r io.Reader) ([]UserImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "username", "email", "password", "roles":
+		default:
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %q appears twice", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, errors.New("the username column is required")
+	}
+	cell := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []UserImportRow
+	for len(rows) <= MaxUserImportRows {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		row := UserImportRow{
+			Line:     line,
+			Username: cell(record, "username"),
+			Email:    cell(record, "email"),
+			Password: cell(record, "password"),
+		}
+		for _, role := range strings.Split(cell(record, "roles"), ";") {
+			if role = strings.TrimSpace(role); role != "" {
+				row.Roles = append(row.Roles, role)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseUserImportJSONL(r io.Reader) ([]UserImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var rows []UserImportRow
+	for line := 1; scanner.Scan() && len(rows) <= MaxUserImportRows; line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		row := UserImportRow{Line: line}
+		if err := decoder.Decode(&row); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+// Validate returns the invalid fields of the row, which follow the rules of
+// POST /users. Rows set a password only when the password mode is provided,
+// and invitees need an email address to be sent the invitation.
+func (r UserImportRow) Validate(passwordMode string) []FieldError {
+	var fields []FieldError
+	switch {
+	case r.Username == "":
+		fields = append(fields, FieldError{Name: "username", Reason: "is required"})
+	case len(r.Username) < 3:
+		fields = append(fields, FieldError{Name: "username", Reason: "must contain at least 3 characters"})
+	case len(r.Username) > 100:
+		fields = append(fields, FieldError{Name: "username", Reason: "must contain at most 100 characters"})
+	case !ValidUsername(r.Username):
+		fields = append(fields, FieldError{Name: "username", Reason: "may only contain letters, digits, '.', '_' and '-'"})
+	}
+
+	switch {
+	case r.Email == "" && passwordMode == UserImportPasswordInvite:
+		fields = append(fields, FieldError{Name: "email", Reason: "is required"})
+	case r.Email == "":
+	case len(r.Email) > 255:
+		fields = append(fields, FieldError{Name: "email", Reason: "must contain at most 255 characters"})
+	default:
+		if address, err := mail.ParseAddress(r.Email); err != nil || address.Address != r.Email {
+			fields = append(fields, FieldError{Name: "email", Reason: "must be a valid email address"})
+		}
+	}
+
+	switch {
+	case passwordMode != UserImportPasswordProvided && passwordMode != "":
+		if r.Password != "" {
+			fields = append(fields, FieldError{Name: "password", Reason: "must be empty with password=" + passwordMode})
+		}
+	case r.Password == "":
+		fields = append(fields, FieldError{Name: "password", Reason: "is required"})
+	case len(r.Password) > 128:
+		fields = append(fields, FieldError{Name: "password", Reason: "must contain at most 128 characters"})
+	}
+
+	assigned := map[string]bool{}
+	for i, role := range r.Roles {
+		field := fmt.Sprintf("roles[%d]", i)
+		switch {
+		case role == "":
+			fields = append(fields, FieldError{Name: field, Reason: "is required"})
+		case len(role) > 100:
+			fields = append(fields, FieldError{Name: field, Reason: "must contain at most 100 characters"})
+		case assigned[role]:
+			fields = append(fields, FieldError{Name: field, Reason: fmt.Sprintf("%s is assigned twice", role)})
+		}
+		assigned[role] = true
+	}
+	return fields
+}
+
+// UserImport is a validated row to create. User.Password is the plain text
+// password, hashed when the user is stored. Invitees are created pending with
+// their Invitation, which assigns the roles once it's accepted.
+type UserImport struct {
+	User       User
+	RoleIDs    []uint
+	Invitation *Invitation
+}
+
+// UserImportResult reports the outcome of a row. Password holds generated
+// passwords, which can't be retrieved later.
+type UserImportResult struct {
+	Line     int          `json:"line"`
+	Username string       `json:"username"`
+	Status   string       `json:"status"`
+	UserID   uint         `json:"user_id,omitempty"`
+	Password string       `json:"password,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Fields   []FieldError `json:"fields,omitempty"`
+}
+
+// UserImportReport is the response of POST /users/import, with a result per
+// row in file order.
+type UserImportReport struct {
+	Created int                `json:"created"`
+	Invited int                `json:"invited"`
+	Failed  int                `json:"failed"`
+	Skipped int                `json:"skipped"`
+	Rows    []UserImportResult `json:"rows"`
+}
+
+// Add appends the result of the next row and counts its outcome.
+func (r *UserImportReport) Add(result UserImportResult) {
+	switch result.Status {
+	case UserImportCreated:
+		r.Created++
+	case UserImportInvited:
+		r.Invited++
+	case UserImportFailed:
+		r.Failed++
+	case UserImportSkipped:
+		r.Skipped++
+	}
+	r.Rows = append(r.Rows, result)
+}
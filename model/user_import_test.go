@@ -0,0 +1,89 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserImport(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		input := "Roles, username,email\n\"admin; auditor\",alice,alice@example.com\n\n,bob,\n"
+
+		rows, err := ParseUserImport(strings.NewReader(input), UserImportFormatCSV)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []UserImportRow{
+			{Line: 2, Username: "alice", Email: "alice@example.com", Roles: []string{"admin", "auditor"}},
+			{Line: 4, Username: "bob"},
+		}, rows, "Columns may come in any order")
+	})
+
+	t.Run("JSON lines", func(t *testing.T) {
+		rows, err := ParseUserImport(strings.NewReader(`{"username":"alice","password":"Secret-123456","roles":["admin"]}`+"\n\n"+`{"username":"bob"}`), UserImportFormatJSONL)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []UserImportRow{
+			{Line: 1, Username: "alice", Password: "Secret-123456", Roles: []string{"admin"}},
+			{Line: 3, Username: "bob"},
+		}, rows)
+	})
+
+	t.Run("Malformed files", func(t *testing.T) {
+		for input, message := range map[string]string{
+			"email\nalice@example.com\n":       "the username column is required",
+			"username,role\nalice,admin\n":     `unknown column "role"`,
+			"username,username\nalice,alice\n": `column "username" appears twice`,
+			"username\n":                       "the file contains no users",
+			"username,email\nalice\n":          "wrong number of fields",
+		} {
+			_, err := ParseUserImport(strings.NewReader(input), UserImportFormatCSV)
+			assert.ErrorContains(t, err, message)
+		}
+
+		_, err := ParseUserImport(strings.NewReader(`{"username":"alice","role":"admin"}`), UserImportFormatJSONL)
+		assert.ErrorContains(t, err, `line 1: json: unknown field "role"`)
+	})
+
+	t.Run("Too many rows", func(t *testing.T) {
+		input := "username\n" + strings.Repeat("alice\n", MaxUserImportRows+1)
+
+		_, err := ParseUserImport(strings.NewReader(input), UserImportFormatCSV)
+
+		assert.EqualError(t, err, "an import may contain at most 10000 users")
+	})
+}
+
+func TestUserImportRowValidate(t *testing.T) {
+	valid := UserImportRow{Username: "alice", Email: "alice@example.com", Password: "Secret-123456", Roles: []string{"admin"}}
+	assert.Empty(t, valid.Validate(UserImportPasswordProvided))
+
+	invalid := UserImportRow{Username: "a!", Email: "Alice <alice@example.com>", Roles: []string{"admin", "", "admin"}}
+	assert.Equal(t, []FieldError{
+		{Name: "username", Reason: "must contain at least 3 characters"},
+		{Name: "email", Reason: "must be a valid email address"},
+		{Name: "password", Reason: "is required"},
+		{Name: "roles[1]", Reason: "is required"},
+		{Name: "roles[2]", Reason: "admin is assigned twice"},
+	}, invalid.Validate(UserImportPasswordProvided))
+
+	assert.Equal(t, []FieldError{
+		{Name: "email", Reason: "is required"},
+		{Name: "password", Reason: "must be empty with password=invite"},
+	}, UserImportRow{Username: "alice", Password: "Secret-123456"}.Validate(UserImportPasswordInvite))
+	assert.Empty(t, UserImportRow{Username: "alice"}.Validate(UserImportPasswordGenerate))
+}
+
+func TestUserImportReportAdd(t *testing.T) {
+	var report UserImportReport
+	for _, status := range []string{UserImportCreated, UserImportFailed, UserImportCreated, UserImportSkipped, UserImportInvited} {
+		report.Add(UserImportResult{Status: status})
+	}
+
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Invited)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Len(t, report.Rows, 5)
+}
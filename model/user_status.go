@@ -0,0 +1,58 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Lifecycle states of a User. Only active users can log in or use tokens and
+// API keys; every other state keeps the account and its history.
+const (
+	UserStatusPending  = "pending"  // Invited, hasn't set a password yet
+	UserStatusActive   = "active"   // Normal access
+	UserStatusDisabled = "disabled" // Offboarded
+	UserStatusLocked   = "locked"   // Suspended by an administrator, e.g. during an investigation
+)
+
+// userStatusTransitions lists the states each state may move to.
+var userStatusTransitions = map[string][]string{
+	UserStatusPending:  {UserStatusActive, UserStatusDisabled},
+	UserStatusActive:   {UserStatusDisabled, UserStatusLocked},
+	UserStatusLocked:   {UserStatusActive, UserStatusDisabled},
+	UserStatusDisabled: {UserStatusActive},
+}
+
+// ErrInvalidStatusTransition is wrapped by every rejected status change.
+var ErrInvalidStatusTransition = errors.New("invalid user status transition")
+
+// ValidateStatusTransition returns an error wrapping ErrInvalidStatusTransition
+// unless the user may move from one state to the other.
+func ValidateStatusTransition(from string, to string) error {
+	if _, known := userStatusTransitions[to]; !known {
+		return fmt.Errorf("%w: unknown user status %q", ErrInvalidStatusTransition, to)
+	}
+	for _, allowed := range userStatusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot change from %s to %s", ErrInvalidStatusTransition, from, to)
+}
+
+// UserStatusChange records a state transition for auditing.
+type UserStatusChange struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"index" json:"user_id"`
+	FromStatus string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20)" json:"to_status"`
+	Reason     string    `gorm:"type:varchar(255)" json:"reason"`
+	ChangedBy  *uint     `json:"changed_by"` // Nil for changes made by the system
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UserStatusRequest is the body of PUT /users/:userID/status.
+type UserStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending active disabled locked"`
+	Reason string `json:"reason" binding:"max=255"`
+}
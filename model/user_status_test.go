@@ -0,0 +1,26 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	assert.NoError(t, ValidateStatusTransition(UserStatusPending, UserStatusActive), "Invited users should be activated")
+	assert.NoError(t, ValidateStatusTransition(UserStatusActive, UserStatusDisabled), "Active users should be offboarded")
+	assert.NoError(t, ValidateStatusTransition(UserStatusLocked, UserStatusActive), "Locked users should be unlocked")
+	assert.NoError(t, ValidateStatusTransition(UserStatusDisabled, UserStatusActive), "Disabled users should be reactivated")
+
+	assert.EqualError(t, ValidateStatusTransition(UserStatusDisabled, UserStatusPending), "invalid user status transition: cannot change from disabled to pending")
+	assert.EqualError(t, ValidateStatusTransition(UserStatusActive, UserStatusActive), "invalid user status transition: cannot change from active to active")
+	assert.ErrorIs(t, ValidateStatusTransition(UserStatusActive, "deleted"), ErrInvalidStatusTransition)
+}
+
+func TestUserActive(t *testing.T) {
+	assert.True(t, User{}.Active(), "Users without a status should be active")
+	assert.True(t, User{Status: UserStatusActive}.Active())
+	assert.False(t, User{Status: UserStatusPending}.Active())
+	assert.False(t, User{Status: UserStatusLocked}.Active())
+	assert.False(t, User{Status: UserStatusDisabled}.Active())
+}
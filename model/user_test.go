@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -30,8 +31,8 @@ func TestUserStructFields(t *testing.T) {
 	passwordField, passwordFound := userType.FieldByName("Password")
 	assert.True(t, passwordFound, "Password field should be present")
 	assert.Equal(t, "string", passwordField.Type.Name(), "Password field should be of type string")
-	assert.Contains(t, passwordField.Tag.Get("gorm"), "type:varchar(100)", "Password field should have varchar(100) tag")
-	assert.Equal(t, "password", passwordField.Tag.Get("json"), "Password field should have json tag 'password'")
+	assert.Contains(t, passwordField.Tag.Get("gorm"), "type:varchar(255)", "Password field should fit argon2id PHC hashes")
+	assert.Equal(t, "-", passwordField.Tag.Get("json"), "Password hashes should never be serialized")
 
 	// Check the Roles field
 	rolesField, rolesFound := userType.FieldByName("Roles")
@@ -56,11 +57,17 @@ func TestUserJSONMarshaling(t *testing.T) {
 	expectedJSON := `{
 		"ID": 1,
 		"username": "johndoe",
-		"password": "password123",
 		"roles": [
-			{"ID": 1, "name": "Admin", "permissions": null},
-			{"ID": 2, "name": "User", "permissions": null}
-		]
+			{"ID": 1, "name": "Admin", "permissions": null, "require_mfa": false, "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"},
+			{"ID": 2, "name": "User", "permissions": null, "require_mfa": false, "created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"}
+		],
+		"email": "",
+		"status": "",
+		"status_reason": "",
+		"status_changed_at": null,
+		"service_account": false,
+		"mfa_enabled": false,
+		"created_at": "0001-01-01T00:00:00Z", "updated_at": "0001-01-01T00:00:00Z"
 	}`
 	actualJSON, err := json.Marshal(user)
 	assert.NoError(t, err, "JSON marshaling should not produce an error")
@@ -75,4 +82,19 @@ func TestUserDefaultValues(t *testing.T) {
 	assert.Equal(t, "", user.Username, "Default Username should be an empty string")
 	assert.Equal(t, "", user.Password, "Default Password should be an empty string")
 	assert.Nil(t, user.Roles, "Default Roles should be nil")
+	assert.False(t, user.ServiceAccount, "Default ServiceAccount should be false")
+	assert.False(t, user.MFAEnabled, "Default MFAEnabled should be false")
+	assert.True(t, user.SessionValid(time.Unix(0, 0)), "Sessions should be valid until the password changes")
+}
+
+func TestUserSessionValid(t *testing.T) {
+	changedAt := time.Date(2024, 5, 1, 12, 0, 0, 500000000, time.UTC)
+	user := User{SessionsValidAfter: &changedAt}
+
+	assert.False(t, user.SessionValid(changedAt.Add(-time.Minute)), "Tokens issued before the change should be rejected")
+	assert.True(t, user.SessionValid(changedAt.Truncate(time.Second)), "Tokens issued in the second of the change should be accepted")
+	assert.True(t, user.SessionValid(changedAt.Add(time.Minute)), "Tokens issued after the change should be accepted")
+
+	user.Status = UserStatusDisabled
+	assert.False(t, user.SessionValid(changedAt.Add(time.Minute)), "Tokens of disabled users should be rejected")
 }
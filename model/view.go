@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// Views are how models are rendered in API responses. Models keep their storage
+// concerns, such as password hashes and MFA secrets, and are never serialized
+// by the controllers directly, so a new internal field can't leak by accident.
+
+// PermissionView is a permission in API responses.
+type PermissionView struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoleSummary identifies a role embedded in another view.
+type RoleSummary struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// RoleView is a role in API responses.
+type RoleView struct {
+	ID          uint             `json:"id"`
+	Name        string           `json:"name"`
+	RequireMFA  bool             `json:"require_mfa"`
+	Permissions []PermissionView `json:"permissions"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// UserView is a user in API responses.
+type UserView struct {
+	ID              uint          `json:"id"`
+	Username        string        `json:"username"`
+	Email           string        `json:"email"`
+	Status          string        `json:"status"`
+	StatusReason    string        `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time    `json:"status_changed_at,omitempty"`
+	ServiceAccount  bool          `json:"service_account"`
+	MFAEnabled      bool          `json:"mfa_enabled"`
+	Roles           []RoleSummary `json:"roles"`
+	CreatedAt       time.Time     `json:"created_at"`
+}
+
+func NewPermissionView(permission Permission) PermissionView {
+	return PermissionView{ID: permission.ID, Name: permission.Name, CreatedAt: permission.CreatedAt}
+}
+
+// NewRoleView renders the role with its permissions, which must be loaded to
+// be listed.
+func NewRoleView(role Role) RoleView {
+	permissions := make([]PermissionView, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, NewPermissionView(permission))
+	}
+	return RoleView{
+		ID:          role.ID,
+		Name:        role.Name,
+		RequireMFA:  role.RequireMFA,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+// NewUserView renders the user with a summary of their roles, which must be
+// loaded to be listed. Users stored before statuses existed are shown as active.
+func NewUserView(user User) UserView {
+	roles := make([]RoleSummary, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		roles = append(roles, RoleSummary{ID: role.ID, Name: role.Name})
+	}
+	status := user.Status
+	if status == "" {
+		status = UserStatusActive
+	}
+	return UserView{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Status:          status,
+		StatusReason:    user.StatusReason,
+		StatusChangedAt: user.StatusChangedAt,
+		ServiceAccount:  user.ServiceAccount,
+		MFAEnabled:      user.MFAEnabled,
+		Roles:           roles,
+		CreatedAt:       user.CreatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUserView(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	user := User{
+		ID:         1,
+		Username:   "johndoe",
+		Password:   "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$a2V5",
+		TOTPSecret: "JBSWY3DPEHPK3PXP",
+		Roles:      []Role{{ID: 2, Name: "Admin", Permissions: []Permission{{ID: 1, Name: "read"}}}},
+		CreatedAt:  createdAt,
+	}
+
+	actualJSON, err := json.Marshal(NewUserView(user))
+
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.JSONEq(t, `{
+		"id": 1,
+		"username": "johndoe",
+		"email": "",
+		"status": "active",
+		"service_account": false,
+		"mfa_enabled": false,
+		"roles": [{"id": 2, "name": "Admin"}],
+		"created_at": "2024-05-01T12:00:00Z"
+	}`, string(actualJSON), "Only public fields and role summaries should be rendered")
+}
+
+func TestNewUserViewWithoutRoles(t *testing.T) {
+	actualJSON, err := json.Marshal(NewUserView(User{ID: 1, Username: "johndoe", Status: UserStatusDisabled, StatusReason: "offboarding"}))
+
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.Contains(t, string(actualJSON), `"roles":[]`, "Users without roles should render an empty list")
+	assert.Contains(t, string(actualJSON), `"status":"disabled","status_reason":"offboarding"`)
+}
+
+func TestNewRoleView(t *testing.T) {
+	role := Role{ID: 2, Name: "Admin", RequireMFA: true, Permissions: []Permission{{ID: 1, Name: "read"}}}
+
+	actualJSON, err := json.Marshal(NewRoleView(role))
+
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.JSONEq(t, `{
+		"id": 2,
+		"name": "Admin",
+		"require_mfa": true,
+		"permissions": [{"id": 1, "name": "read", "created_at": "0001-01-01T00:00:00Z"}],
+		"created_at": "0001-01-01T00:00:00Z"
+	}`, string(actualJSON))
+}
+
+func TestCreateUserRequest(t *testing.T) {
+	request := CreateUserRequest{Username: "johndoe", Password: "Secret-Password-1", Email: "john@example.com"}
+
+	assert.Equal(t, User{Username: "johndoe", Password: "Secret-Password-1", Email: "john@example.com"}, request.User())
+}
@@ -0,0 +1,75 @@
+package model
+
+import "time"
+
+// Event types recorded in the outbox and delivered to webhooks.
+const (
+	EventUserCreated            = "user.created"
+	EventUserRoleAssigned       = "user.role_assigned"
+	EventUserRoleRevoked        = "user.role_revoked"
+	EventUserStatusChanged      = "user.status_changed"
+	EventUserInvited            = "user.invited"
+	EventRoleCreated            = "role.created"
+	EventRoleUpdated            = "role.updated"
+	EventRoleDeleted            = "role.deleted"
+	EventRolePermissionAssigned = "role.permission_assigned"
+	EventRolePermissionRevoked  = "role.permission_revoked"
+	EventPermissionCreated      = "permission.created"
+	EventPermissionDeleted      = "permission.deleted"
+)
+
+// Delivery states of a WebhookDelivery.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey"`
+	URL       string    `gorm:"type:varchar(255)" json:"url" binding:"required,url,max=255"`
+	Secret    string    `gorm:"type:varchar(100)" json:"secret,omitempty"`
+	Events    []string  `gorm:"type:text;serializer:json" json:"events" binding:"dive,required"` // Empty means every event
+	Active    bool      `gorm:"default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook wants to receive the given event type.
+func (w Webhook) Subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == "*" || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// OutboxEvent is written in the same transaction as the change it describes,
+// so an event is never lost when the process dies right after the commit.
+type OutboxEvent struct {
+	ID          uint       `gorm:"primaryKey"`
+	Type        string     `gorm:"type:varchar(100);index" json:"type"`
+	Payload     string     `gorm:"type:text" json:"payload"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ProcessedAt *time.Time `gorm:"index" json:"processed_at"`
+}
+
+// WebhookDelivery tracks the delivery of one outbox event to one webhook.
+type WebhookDelivery struct {
+	ID             uint        `gorm:"primaryKey"`
+	WebhookID      uint        `gorm:"index;uniqueIndex:idx_webhook_deliveries_webhook_event" json:"webhook_id"`
+	Webhook        Webhook     `json:"-"`
+	EventID        uint        `gorm:"index;uniqueIndex:idx_webhook_deliveries_webhook_event" json:"event_id"`
+	Event          OutboxEvent `json:"-"`
+	EventType      string      `gorm:"type:varchar(100)" json:"event_type"`
+	Status         string      `gorm:"type:varchar(20);index" json:"status"`
+	Attempts       int         `json:"attempts"`
+	NextAttemptAt  time.Time   `gorm:"index" json:"next_attempt_at"`
+	LastStatusCode int         `json:"last_status_code"`
+	LastError      string      `gorm:"type:text" json:"last_error"`
+	DeliveredAt    *time.Time  `json:"delivered_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSubscribes(t *testing.T) {
+	// A webhook without events receives everything
+	all := Webhook{}
+	assert.True(t, all.Subscribes(EventUserCreated), "Webhook without events should receive every event")
+
+	// A wildcard also receives everything
+	wildcard := Webhook{Events: []string{"*"}}
+	assert.True(t, wildcard.Subscribes(EventRoleCreated), "Wildcard webhook should receive every event")
+
+	// Otherwise only the listed events are received
+	filtered := Webhook{Events: []string{EventUserRoleAssigned}}
+	assert.True(t, filtered.Subscribes(EventUserRoleAssigned), "Webhook should receive a listed event")
+	assert.False(t, filtered.Subscribes(EventUserCreated), "Webhook should not receive an unlisted event")
+}
+
+func TestWebhookJSONMarshaling(t *testing.T) {
+	// The secret is omitted once it has been cleared
+	webhook := Webhook{ID: 1, URL: "https://hr.example.com/hook", Events: []string{EventUserCreated}, Active: true}
+
+	actualJSON, err := json.Marshal(webhook)
+	assert.NoError(t, err, "JSON marshaling should not produce an error")
+	assert.NotContains(t, string(actualJSON), "secret", "Empty secret should be omitted")
+	assert.Contains(t, string(actualJSON), `"events":["user.created"]`, "Events should be marshaled as a list")
+}
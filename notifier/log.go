@@ -0,0 +1,55 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type logNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogNotifier is a stand-in for a real delivery channel during development.
+// Notifications are appended to the file at path as JSON lines, or written to
+// the default logger at info level when path is empty.
+func NewLogNotifier(path string) domain.Notifier {
+	return &logNotifier{path: path}
+}
+
+type logEntry struct {
+	Time time.Time `json:"time"`
+	model.Notification
+}
+
+// Notify implements domain.Notifier.
+func (l *logNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	line, err := json.Marshal(logEntry{Time: time.Now(), Notification: notification})
+	if err != nil {
+		return err
+	}
+
+	if l.path == "" {
+		slog.Info("notification", "notification", string(line))
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not open notification log: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"go-multirole/model"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogNotifierAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	logNotifier := NewLogNotifier(path)
+
+	assert.NoError(t, logNotifier.Notify(context.Background(), model.Notification{To: "john@example.com", Subject: "First", Body: "one"}))
+	assert.NoError(t, logNotifier.Notify(context.Background(), model.Notification{To: "john@example.com", Subject: "Second", Body: "two"}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2, "expected one line per notification")
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, "john@example.com", entry["to"])
+	assert.Equal(t, "Second", entry["subject"])
+	assert.Equal(t, "two", entry["body"])
+}
+
+func TestBuildMessage(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	message := string(buildMessage("noreply@example.com", model.Notification{
+		To:      "john@example.com",
+		Subject: "Reset\r\nBcc: attacker@example.com",
+		Body:    "line one\nline two",
+	}, date))
+
+	assert.Contains(t, message, "From: noreply@example.com\r\n")
+	assert.Contains(t, message, "To: john@example.com\r\n")
+	assert.Contains(t, message, "Subject: ResetBcc: attacker@example.com\r\n", "expected line breaks to be stripped from headers")
+	assert.NotContains(t, message, "\r\nBcc:", "expected no injected header")
+	assert.Contains(t, message, "Date: Wed, 01 May 2024 12:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(message, "\r\n\r\nline one\r\nline two\r\n"), "expected CRLF line endings in the body")
+}
@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type smtpNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPNotifier sends notifications as plain text emails. Authentication is
+// skipped when username is empty, e.g. for a local relay.
+func NewSMTPNotifier(host string, port string, username string, password string, from string) domain.Notifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpNotifier{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+// Notify implements domain.Notifier. SendMail can't be interrupted, so only a
+// context that is already done stops the email.
+func (s *smtpNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(s.addr, s.auth, s.from, []string{notification.To}, buildMessage(s.from, notification, time.Now())); err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders an RFC 5322 message. Header values are stripped of line
+// breaks so user supplied values can't inject headers.
+func buildMessage(from string, notification model.Notification, date time.Time) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&message, "To: %s\r\n", headerValue(notification.To))
+	fmt.Fprintf(&message, "Subject: %s\r\n", headerValue(notification.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.WriteString(strings.ReplaceAll(notification.Body, "\n", "\r\n"))
+	message.WriteString("\r\n")
+	return message.Bytes()
+}
+
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,177 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"go-multirole/config"
+	"go-multirole/db"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testDatabases int64
+
+// testPasswordHasher keeps the repository tests fast.
+var testPasswordHasher = utils.NewBcryptHasher(4)
+
+// newTestDB opens an empty database migrated to the latest version.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	conn := openTestDB(t)
+	migrator, err := db.NewMigrator(conn)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	return conn
+}
+
+// openTestDB opens an empty database without any table. It's an
+// in-memory SQLite database unless TEST_SQL_DRIVER selects mysql or postgres, which are
+// configured like the application with TEST_SQL_HOST, TEST_SQL_PORT,
+// TEST_SQL_USER, TEST_SQL_PASSWORD, TEST_SQL_DB and TEST_SQL_SSL_MODE. Their
+// tables are dropped before every test, so use a database of their own.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	cfg := config.Config{
+		DBDriver:   os.Getenv("TEST_SQL_DRIVER"),
+		DBHost:     os.Getenv("TEST_SQL_HOST"),
+		DBPort:     os.Getenv("TEST_SQL_PORT"),
+		DBUsername: os.Getenv("TEST_SQL_USER"),
+		DBPassword: os.Getenv("TEST_SQL_PASSWORD"),
+		DBName:     os.Getenv("TEST_SQL_DB"),
+		DBSSLMode:  os.Getenv("TEST_SQL_SSL_MODE"),
+	}
+	if cfg.DBDriver == "" || cfg.DBDriver == "sqlite" {
+		cfg = config.Config{
+			DBDriver: "sqlite",
+			DBName:   fmt.Sprintf("file:repo_test_%d?mode=memory&cache=shared", atomic.AddInt64(&testDatabases, 1)),
+		}
+	}
+
+	dialector, err := db.Dialector(&cfg)
+	require.NoError(t, err)
+	conn, err := db.Open(dialector)
+	require.NoError(t, err)
+
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if cfg.DBDriver == "sqlite" {
+		// A single connection keeps SQLite from reporting its lock as busy
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		tables := append(db.Models(), "user_roles", "role_permissions", "invitation_roles", "oauth_client_roles", "schema_migrations")
+		require.NoError(t, conn.Migrator().DropTable(tables...))
+	}
+	return conn
+}
+
+// assertModelColumns checks there's a column for every model field.
+func assertModelColumns(t *testing.T, conn *gorm.DB) {
+	t.Helper()
+
+	for _, model := range db.Models() {
+		stmt := &gorm.Statement{DB: conn}
+		require.NoError(t, stmt.Parse(model))
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName != "" {
+				assert.True(t, conn.Migrator().HasColumn(model, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	conn := newTestDB(t)
+	migrator, err := db.NewMigrator(conn)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Check())
+
+	// The migrations create a column for every model field
+	assertModelColumns(t, conn)
+
+	require.NoError(t, migrator.To(0))
+	assert.ErrorIs(t, migrator.Check(), db.ErrSchemaOutdated)
+	assert.False(t, conn.Migrator().HasTable("users"), "Reverting every migration drops the tables")
+
+	require.NoError(t, migrator.To(migrator.Latest()))
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "%d_%s", status.Version, status.Name)
+	}
+
+	assert.Error(t, migrator.To(migrator.Latest()+1), "Unknown versions are rejected")
+}
+
+// The models of the first release, whose tables AutoMigrate created before
+// migrations were versioned.
+type baselineUser struct {
+	ID       uint           `gorm:"primaryKey"`
+	Username string         `gorm:"type:varchar(100);uniqueIndex"`
+	Password string         `gorm:"type:varchar(100)"`
+	Roles    []baselineRole `gorm:"many2many:user_roles;joinForeignKey:UserID;joinReferences:RoleID"`
+}
+
+type baselineRole struct {
+	ID          uint                 `gorm:"primaryKey"`
+	Name        string               `gorm:"type:varchar(100);uniqueIndex"`
+	Permissions []baselinePermission `gorm:"many2many:role_permissions;joinForeignKey:RoleID;joinReferences:PermissionID"`
+}
+
+type baselinePermission struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"type:varchar(100);uniqueIndex"`
+}
+
+func (baselineUser) TableName() string       { return "users" }
+func (baselineRole) TableName() string       { return "roles" }
+func (baselinePermission) TableName() string { return "permissions" }
+
+func TestMigrations_PreVersioningDatabase(t *testing.T) {
+	conn := openTestDB(t)
+	require.NoError(t, conn.AutoMigrate(&baselineUser{}, &baselineRole{}, &baselinePermission{}))
+	legacy := baselineUser{
+		Username: "legacy",
+		Password: "hash",
+		Roles:    []baselineRole{{Name: "admin", Permissions: []baselinePermission{{Name: "manage_users"}}}},
+	}
+	require.NoError(t, conn.Create(&legacy).Error)
+
+	migrator, err := db.NewMigrator(conn)
+	require.NoError(t, err)
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Check())
+
+	// The old tables get the columns of every later model field
+	assertModelColumns(t, conn)
+
+	repository := NewUserRepository(conn, testPasswordHasher)
+	user, err := repository.FindUserByIdentifier(context.Background(), "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, model.UserStatusActive, user.Status, "Existing users stay active")
+	permissions, err := repository.ListUserPermissions(context.Background(), legacy.ID)
+	require.NoError(t, err)
+	assert.Len(t, permissions, 1, "Existing assignments are kept")
+
+	// The password column is widened for argon2id hashes, SQLite ignores lengths
+	if conn.Dialector.Name() != "sqlite" {
+		columnTypes, err := conn.Migrator().ColumnTypes("users")
+		require.NoError(t, err)
+		for _, columnType := range columnTypes {
+			if columnType.Name() == "password" {
+				length, _ := columnType.Length()
+				assert.Equal(t, int64(255), length)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"errors"
+	"go-multirole/domain"
+
+	"gorm.io/gorm"
+)
+
+// findByID loads the record with the given primary key, naming the entity
+// when it doesn't exist.
+func findByID(db *gorm.DB, dest interface{}, id uint, entity string) error {
+	return notFound(db.First(dest, id).Error, "%s not found", entity)
+}
+
+// findByName loads the record with the given unique name, naming the entity
+// and the name when it doesn't exist.
+func findByName(db *gorm.DB, dest interface{}, name string, entity string) error {
+	return notFound(db.Where("name = ?", name).First(dest).Error, "%s %s not found", entity, name)
+}
+
+// notFound maps a missing record to a domain.ErrNotFound error and returns
+// every other error unchanged.
+func notFound(err error, format string, args ...interface{}) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.NotFound(format, args...)
+	}
+	return err
+}
+
+// conflict maps a unique constraint violation to a domain.ErrConflict error.
+// The database must be opened with TranslateError for gorm to report them.
+func conflict(err error, format string, args ...interface{}) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return domain.Conflict(format, args...)
+	}
+	return err
+}
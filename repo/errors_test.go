@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"errors"
+	"go-multirole/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestNotFound(t *testing.T) {
+	err := notFound(gorm.ErrRecordNotFound, "%s not found", "role")
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.EqualError(t, err, "role not found")
+
+	other := errors.New("connection refused")
+	assert.Equal(t, other, notFound(other, "role not found"), "Other errors should be returned unchanged")
+}
+
+func TestConflict(t *testing.T) {
+	err := conflict(gorm.ErrDuplicatedKey, "role %s already exists", "Admin")
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.EqualError(t, err, "role Admin already exists")
+	assert.NoError(t, conflict(nil, "role already exists"))
+}
@@ -0,0 +1,233 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// errInvitationSuperseded rolls back an acceptance whose user left the pending state.
+var errInvitationSuperseded = errors.New("invitation superseded")
+
+type invitationRepository struct {
+	db             *gorm.DB
+	passwordHasher utils.PasswordHasher
+}
+
+func NewInvitationRepository(db *gorm.DB, passwordHasher utils.PasswordHasher) domain.InvitationRepo {
+	return &invitationRepository{
+		db:             db,
+		passwordHasher: passwordHasher,
+	}
+}
+
+// CreateInvitation creates the invited user in the pending state together with
+// the invitation holding the roles to assign on acceptance.
+func (r *invitationRepository) CreateInvitation(ctx context.Context, invitation model.Invitation, roleIDs []uint) (model.Invitation, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createInvitation(tx, &invitation, roleIDs)
+	})
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	return invitation, nil
+}
+
+// createInvitation creates the pending user of the invitation and stores the
+// invitation with its roles.
+func createInvitation(tx *gorm.DB, invitation *model.Invitation, roleIDs []uint) error {
+	var roles []model.Role
+	if len(roleIDs) > 0 {
+		if err := tx.Find(&roles, roleIDs).Error; err != nil {
+			return err
+		}
+		if len(roles) != len(roleIDs) {
+			return domain.NotFound("role not found")
+		}
+	}
+
+	user := model.User{Username: invitation.Username, Email: invitation.Email, Status: model.UserStatusPending}
+	if err := tx.Create(&user).Error; err != nil {
+		return conflict(err, "username %s is already taken", user.Username)
+	}
+	err := tx.Create(&model.UserStatusChange{
+		UserID: user.ID, ToStatus: model.UserStatusPending, Reason: "invited", ChangedBy: invitation.InvitedBy,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	invitation.UserID = user.ID
+	invitation.Roles = roles
+	if err := tx.Omit("Roles.*").Create(invitation).Error; err != nil {
+		return err
+	}
+	return enqueueEvent(tx, model.EventUserInvited, map[string]interface{}{
+		"user_id":       user.ID,
+		"username":      user.Username,
+		"invitation_id": invitation.ID,
+	})
+}
+
+// ListInvitations implements domain.InvitationRepo.
+func (r *invitationRepository) ListInvitations(ctx context.Context) ([]model.Invitation, error) {
+	var invitations []model.Invitation
+	if err := r.db.WithContext(ctx).Preload("Roles").Order("id DESC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// FindInvitation implements domain.InvitationRepo.
+func (r *invitationRepository) FindInvitation(ctx context.Context, invitationID uint) (model.Invitation, error) {
+	var invitation model.Invitation
+	if err := findByID(r.db.WithContext(ctx).Preload("Roles"), &invitation, invitationID, "invitation"); err != nil {
+		return model.Invitation{}, err
+	}
+	return invitation, nil
+}
+
+// FindInvitationByToken implements domain.InvitationRepo.
+func (r *invitationRepository) FindInvitationByToken(ctx context.Context, tokenHash string) (model.Invitation, error) {
+	var invitation model.Invitation
+	if err := r.db.WithContext(ctx).Preload("Roles").Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Invitation{}, domain.ErrInvalidInvitation
+		}
+		return model.Invitation{}, err
+	}
+	return invitation, nil
+}
+
+// RenewInvitation replaces the token of an open invitation, invalidating the old one.
+func (r *invitationRepository) RenewInvitation(ctx context.Context, invitationID uint, tokenHash string, expiresAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+		Updates(map[string]interface{}{"token_hash": tokenHash, "expires_at": expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeInvitation closes an open invitation and disables the pending user,
+// reporting false when the invitation was already accepted or revoked.
+func (r *invitationRepository) RevokeInvitation(ctx context.Context, invitationID uint, revokedAt time.Time) (bool, error) {
+	revoked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invitation model.Invitation
+		if err := tx.First(&invitation, invitationID).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitationID).
+			Update("revoked_at", revokedAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		revoked = true
+
+		// A user activated by other means keeps their status
+		err := changePendingUserStatus(tx, invitation.UserID, model.UserStatusDisabled, "invitation revoked", revokedAt, nil)
+		if errors.Is(err, errInvitationSuperseded) {
+			return nil
+		}
+		return err
+	})
+	return revoked, err
+}
+
+// AcceptInvitation sets the invitee's password, activates the user and assigns
+// the invited roles. It reports false when the invitation was closed or the
+// user left the pending state in the meantime.
+func (r *invitationRepository) AcceptInvitation(ctx context.Context, invitation model.Invitation, password string, acceptedAt time.Time) (bool, error) {
+	hashedPassword, err := r.passwordHasher.Hash(password)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invitation.ID, acceptedAt).
+			Update("accepted_at", acceptedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationSuperseded
+		}
+
+		passwordUpdate := map[string]interface{}{"password": hashedPassword}
+		if err := changePendingUserStatus(tx, invitation.UserID, model.UserStatusActive, "invitation accepted", acceptedAt, passwordUpdate); err != nil {
+			return err
+		}
+		if err := tx.Create(&model.PasswordHistory{UserID: invitation.UserID, PasswordHash: hashedPassword}).Error; err != nil {
+			return err
+		}
+
+		if len(invitation.Roles) == 0 {
+			return nil
+		}
+		if err := tx.Model(&model.User{ID: invitation.UserID}).Association("Roles").Append(invitation.Roles); err != nil {
+			return err
+		}
+		for _, role := range invitation.Roles {
+			err := enqueueEvent(tx, model.EventUserRoleAssigned, map[string]interface{}{
+				"user_id":   invitation.UserID,
+				"username":  invitation.Username,
+				"role_id":   role.ID,
+				"role_name": role.Name,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errInvitationSuperseded) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// changePendingUserStatus moves an invited user out of the pending state and
+// records the change. Extra column updates are applied in the same statement.
+func changePendingUserStatus(tx *gorm.DB, userID uint, status string, reason string, changedAt time.Time, extra map[string]interface{}) error {
+	updates := map[string]interface{}{
+		"status":            status,
+		"status_reason":     reason,
+		"status_changed_at": changedAt,
+	}
+	for column, value := range extra {
+		updates[column] = value
+	}
+
+	result := tx.Model(&model.User{}).
+		Where("id = ? AND status = ?", userID, model.UserStatusPending).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvitationSuperseded
+	}
+
+	err := tx.Create(&model.UserStatusChange{
+		UserID: userID, FromStatus: model.UserStatusPending, ToStatus: status, Reason: reason, CreatedAt: changedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return enqueueEvent(tx, model.EventUserStatusChanged, map[string]interface{}{
+		"user_id":     userID,
+		"from_status": model.UserStatusPending,
+		"to_status":   status,
+		"reason":      reason,
+	})
+}
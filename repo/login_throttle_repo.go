@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) domain.LoginThrottleRepo {
+	return &loginThrottleRepository{
+		db: db,
+	}
+}
+
+// FindThrottles implements domain.LoginThrottleRepo.
+func (l *loginThrottleRepository) FindThrottles(ctx context.Context, keys []string) ([]model.LoginThrottle, error) {
+	var throttles []model.LoginThrottle
+	if err := l.db.WithContext(ctx).Where("throttle_key IN ?", keys).Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// RecordFailure increments the failure count of the key, starting over when the
+// previous failure is older than windowStart, and locks the key once the count
+// reaches lockAfter. The row is locked so concurrent attempts are all counted.
+func (l *loginThrottleRepository) RecordFailure(ctx context.Context, key string, now time.Time, windowStart time.Time, lockAfter int, lockUntil time.Time) (model.LoginThrottle, error) {
+	var throttle model.LoginThrottle
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("throttle_key = ?", key).First(&throttle).Error; err != nil {
+			return err
+		}
+
+		if throttle.LastFailedAt == nil || throttle.LastFailedAt.Before(windowStart) {
+			throttle.FailedAttempts = 0
+			throttle.LockedUntil = nil
+		}
+		throttle.FailedAttempts++
+		throttle.LastFailedAt = &now
+		if throttle.FailedAttempts >= lockAfter {
+			throttle.LockedUntil = &lockUntil
+		}
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return model.LoginThrottle{}, err
+	}
+	return throttle, nil
+}
+
+// DeleteThrottle implements domain.LoginThrottleRepo.
+func (l *loginThrottleRepository) DeleteThrottle(ctx context.Context, key string) error {
+	return l.db.WithContext(ctx).Where("throttle_key = ?", key).Delete(&model.LoginThrottle{}).Error
+}
+
+// ListLockedThrottles implements domain.LoginThrottleRepo.
+func (l *loginThrottleRepository) ListLockedThrottles(ctx context.Context, now time.Time) ([]model.LoginThrottle, error) {
+	var throttles []model.LoginThrottle
+	if err := l.db.WithContext(ctx).Where("locked_until > ?", now).Order("locked_until DESC").Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// DeleteStaleThrottles removes keys without a failure or lock since before.
+func (l *loginThrottleRepository) DeleteStaleThrottles(ctx context.Context, before time.Time) error {
+	return l.db.WithContext(ctx).
+		Where("(last_failed_at IS NULL OR last_failed_at < ?) AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&model.LoginThrottle{}).Error
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) domain.MFARepo {
+	return &mfaRepository{
+		db: db,
+	}
+}
+
+// FindUser implements domain.MFARepo.
+func (m *mfaRepository) FindUser(ctx context.Context, userID uint) (model.User, error) {
+	var user model.User
+	if err := findByID(m.db.WithContext(ctx).Preload("Roles"), &user, userID, "user"); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// SaveTOTPSecret stores a new secret for a user that hasn't enabled MFA yet.
+func (m *mfaRepository) SaveTOTPSecret(ctx context.Context, userID uint, secret string) error {
+	return m.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND mfa_enabled = ?", userID, false).
+		Update("totp_secret", secret).Error
+}
+
+// EnableMFA turns MFA on and replaces any previous recovery codes.
+func (m *mfaRepository) EnableMFA(ctx context.Context, userID uint, step int64, recoveryCodes []model.RecoveryCode) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"mfa_enabled":    true,
+			"totp_last_step": step,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+// DisableMFA implements domain.MFARepo.
+func (m *mfaRepository) DisableMFA(ctx context.Context, userID uint) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"mfa_enabled":    false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+	})
+}
+
+// AdvanceTOTPStep records the accepted time step. It reports false when the
+// step, or a later one, was already used so a code can't be replayed.
+func (m *mfaRepository) AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := m.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseRecoveryCode marks a matching unused code as used, reporting whether one was found.
+func (m *mfaRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	result := m.db.WithContext(ctx).Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected >= 1, nil
+}
+
+// SetRoleRequireMFA implements domain.MFARepo.
+func (m *mfaRepository) SetRoleRequireMFA(ctx context.Context, roleID uint, required bool) error {
+	var role model.Role
+	if err := findByID(m.db.WithContext(ctx), &role, roleID, "role"); err != nil {
+		return err
+	}
+	return m.db.WithContext(ctx).Model(&role).Update("require_mfa", required).Error
+}
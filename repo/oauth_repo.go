@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepository(db *gorm.DB) domain.OAuthRepo {
+	return &oauthRepository{
+		db: db,
+	}
+}
+
+// CreateClient implements domain.OAuthRepo.
+func (o *oauthRepository) CreateClient(ctx context.Context, client model.OAuthClient) (model.OAuthClient, error) {
+	if err := o.db.WithContext(ctx).Create(&client).Error; err != nil {
+		return client, conflict(err, "client_id %s is already registered", client.ClientID)
+	}
+	return client, nil
+}
+
+// FindClientByClientID returns the client with its roles and their permissions.
+func (o *oauthRepository) FindClientByClientID(ctx context.Context, clientID string) (model.OAuthClient, error) {
+	var client model.OAuthClient
+	if err := o.db.WithContext(ctx).Preload("Roles.Permissions").Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return model.OAuthClient{}, err
+	}
+	return client, nil
+}
+
+// ListClients implements domain.OAuthRepo.
+func (o *oauthRepository) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	var clients []model.OAuthClient
+	if err := o.db.WithContext(ctx).Preload("Roles").Order("id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// AssignRoleToClient implements domain.OAuthRepo.
+func (o *oauthRepository) AssignRoleToClient(ctx context.Context, clientID string, roleID uint) error {
+	var client model.OAuthClient
+	var role model.Role
+
+	if err := o.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return notFound(err, "oauth client not found")
+	}
+	if err := findByID(o.db.WithContext(ctx), &role, roleID, "role"); err != nil {
+		return err
+	}
+
+	return o.db.WithContext(ctx).Model(&client).Association("Roles").Append(&role)
+}
+
+// RevokeToken implements domain.OAuthRepo. Revoking twice is not an error.
+func (o *oauthRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	return o.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// IsTokenRevoked implements domain.OAuthRepo.
+func (o *oauthRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	if err := o.db.WithContext(ctx).Model(&model.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type oidcRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) domain.OIDCRepo {
+	return &oidcRepository{
+		db: db,
+	}
+}
+
+// CreateAuthorizationCode implements domain.OIDCRepo.
+func (o *oidcRepository) CreateAuthorizationCode(ctx context.Context, code model.AuthorizationCode) (model.AuthorizationCode, error) {
+	if err := o.db.WithContext(ctx).Create(&code).Error; err != nil {
+		return code, err
+	}
+	return code, nil
+}
+
+// FindAuthorizationCode implements domain.OIDCRepo.
+func (o *oidcRepository) FindAuthorizationCode(ctx context.Context, codeHash string) (model.AuthorizationCode, error) {
+	var code model.AuthorizationCode
+	if err := o.db.WithContext(ctx).Where("code_hash = ?", codeHash).First(&code).Error; err != nil {
+		return model.AuthorizationCode{}, err
+	}
+	return code, nil
+}
+
+// MarkAuthorizationCodeUsed consumes the code and reports false when it was
+// already consumed, so concurrent redemptions can't both succeed.
+func (o *oidcRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeID uint, usedAt time.Time) (bool, error) {
+	result := o.db.WithContext(ctx).Model(&model.AuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", codeID).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FindUserWithRoles implements domain.OIDCRepo.
+func (o *oidcRepository) FindUserWithRoles(ctx context.Context, userID uint) (model.User, error) {
+	var user model.User
+	if err := findByID(o.db.WithContext(ctx).Preload("Roles"), &user, userID, "user"); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
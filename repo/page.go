@@ -0,0 +1,175 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sortField is a column a list can be ordered by.
+type sortField struct {
+	column string
+	isTime bool
+}
+
+// sortFields maps the sort options of a list to columns. Every list is also
+// ordered by id, which breaks ties and makes the position of a row unique.
+type sortFields map[string]sortField
+
+var (
+	userSortFields = sortFields{
+		"id":         {column: "id"},
+		"username":   {column: "username"},
+		"created_at": {column: "created_at", isTime: true},
+	}
+	nameSortFields = sortFields{
+		"id":         {column: "id"},
+		"name":       {column: "name"},
+		"created_at": {column: "created_at", isTime: true},
+	}
+)
+
+// cursor is the position of the last row of a page, encoded opaquely for
+// clients. It remembers the sort so it can't be reused with another one.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint   `json:"id"`
+}
+
+// pageQuery is a keyset paginated query: rather than skipping rows with an
+// offset, each page starts after the last row of the previous one, so deep
+// pages cost the same as the first.
+type pageQuery struct {
+	sort  string
+	field sortField
+	desc  bool
+	limit int
+}
+
+// paginate applies the sort, cursor and limit of the request to the query.
+// It fetches one extra row to tell whether another page follows.
+func paginate(query *gorm.DB, page model.PageRequest, fields sortFields) (*gorm.DB, pageQuery, error) {
+	p := pageQuery{sort: page.Sort, limit: page.PageLimit()}
+	if p.sort == "" {
+		p.sort = "id"
+	}
+	name := strings.TrimPrefix(p.sort, "-")
+	p.desc = name != p.sort
+	field, ok := fields[name]
+	if !ok {
+		return nil, p, domain.InvalidFields(model.FieldError{Name: "sort", Reason: "must be one of " + strings.Join(sortOptions(fields), ", ")})
+	}
+	p.field = field
+
+	direction, comparison := "ASC", ">"
+	if p.desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.Cursor != "" {
+		after, err := decodeCursor(page.Cursor, p.sort)
+		if err != nil {
+			return nil, p, err
+		}
+		if field.column == "id" {
+			query = query.Where("id "+comparison+" ?", after.ID)
+		} else {
+			value, err := p.cursorValue(after.Value)
+			if err != nil {
+				return nil, p, err
+			}
+			query = query.Where("("+field.column+" "+comparison+" ? OR ("+field.column+" = ? AND id "+comparison+" ?))", value, value, after.ID)
+		}
+	}
+
+	if field.column != "id" {
+		query = query.Order(field.column + " " + direction)
+	}
+	return query.Order("id " + direction).Limit(p.limit + 1), p, nil
+}
+
+// pageInfo trims the extra row fetched by paginate and describes the page.
+// position returns the sort value and id of the row at index i.
+func (p pageQuery) pageInfo(count int, position func(i int) (interface{}, uint)) (int, model.PageInfo) {
+	info := model.PageInfo{Limit: p.limit}
+	if count <= p.limit {
+		return count, info
+	}
+
+	value, id := position(p.limit - 1)
+	last := cursor{Sort: p.sort, ID: id}
+	switch v := value.(type) {
+	case string:
+		last.Value = v
+	case time.Time:
+		last.Value = v.UTC().Format(time.RFC3339Nano)
+	}
+	info.HasMore = true
+	info.NextCursor = encodeCursor(last)
+	return p.limit, info
+}
+
+func (p pageQuery) cursorValue(value string) (interface{}, error) {
+	if !p.field.isTime {
+		return value, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, invalidCursor()
+	}
+	return t, nil
+}
+
+// applyCreatedRange filters on the creation time of the listed rows.
+func applyCreatedRange(query *gorm.DB, created model.CreatedRange) *gorm.DB {
+	if created.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *created.CreatedAfter)
+	}
+	if created.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *created.CreatedBefore)
+	}
+	return query
+}
+
+// prefixPattern matches values starting with prefix in a LIKE ... ESCAPE '!'
+// clause, with the wildcards of the prefix itself escaped.
+func prefixPattern(prefix string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(prefix) + "%"
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string, sort string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID == 0 {
+		return cursor{}, invalidCursor()
+	}
+	if c.Sort != sort {
+		return cursor{}, domain.InvalidFields(model.FieldError{Name: "cursor", Reason: "was issued for another sort order"})
+	}
+	return c, nil
+}
+
+func invalidCursor() error {
+	return domain.InvalidFields(model.FieldError{Name: "cursor", Reason: "is invalid"})
+}
+
+func sortOptions(fields sortFields) []string {
+	options := make([]string, 0, len(fields)*2)
+	for name := range fields {
+		options = append(options, name, "-"+name)
+	}
+	sort.Strings(options)
+	return options
+}
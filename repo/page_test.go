@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	encoded := encodeCursor(cursor{Sort: "-username", Value: "john_doe", ID: 42})
+
+	decoded, err := decodeCursor(encoded, "-username")
+	assert.NoError(t, err)
+	assert.Equal(t, cursor{Sort: "-username", Value: "john_doe", ID: 42}, decoded)
+
+	_, err = decodeCursor(encoded, "username")
+	assert.ErrorIs(t, err, domain.ErrValidation, "A cursor only continues the sort it was issued for")
+
+	_, err = decodeCursor("not a cursor", "username")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestPaginateRejectsUnknownSort(t *testing.T) {
+	// The sort is checked before the query is used
+	_, _, err := paginate(nil, model.PageRequest{Sort: "password"}, userSortFields)
+
+	var domainErr *domain.Error
+	assert.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, []model.FieldError{{Name: "sort", Reason: "must be one of -created_at, -id, -username, created_at, id, username"}}, domainErr.Fields)
+}
+
+func TestPageInfo(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	page := pageQuery{sort: "created_at", field: userSortFields["created_at"], limit: 2}
+	position := func(i int) (interface{}, uint) { return createdAt, uint(i + 1) }
+
+	count, info := page.pageInfo(3, position)
+	assert.Equal(t, 2, count, "The extra row should be trimmed")
+	assert.True(t, info.HasMore)
+
+	next, err := decodeCursor(info.NextCursor, "created_at")
+	assert.NoError(t, err)
+	assert.Equal(t, cursor{Sort: "created_at", Value: "2024-05-01T12:00:00Z", ID: 2}, next)
+
+	count, info = page.pageInfo(2, position)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, model.PageInfo{Limit: 2}, info, "The last page has no cursor")
+}
+
+func TestPrefixPattern(t *testing.T) {
+	assert.Equal(t, "john%", prefixPattern("john"))
+	assert.Equal(t, "a!_b!%c!!%", prefixPattern("a_b%c!"))
+}
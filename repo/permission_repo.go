@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"go-multirole/domain"
 	"go-multirole/model"
 
@@ -18,9 +19,55 @@ func NewPermissionRepository(db *gorm.DB) domain.PermissionRepo {
 }
 
 // CreatePermission implements domain.PermissionRepo.
-func (p *permissionRepository) CreatePermission(permission model.Permission) (model.Permission, error) {
-	if err := p.db.Create(&permission).Error; err != nil {
+func (p *permissionRepository) CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error) {
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&permission).Error; err != nil {
+			return conflict(err, "permission %s already exists", permission.Name)
+		}
+		return enqueueEvent(tx, model.EventPermissionCreated, map[string]interface{}{
+			"permission_id":   permission.ID,
+			"permission_name": permission.Name,
+		})
+	})
+	if err != nil {
 		return permission, err
 	}
 	return permission, nil
 }
+
+// ListPermissions implements domain.PermissionRepo.
+func (p *permissionRepository) ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error) {
+	query := applyCreatedRange(p.db.WithContext(ctx).Model(&model.Permission{}), filter.CreatedRange)
+	if filter.NamePrefix != "" {
+		query = query.Where("name LIKE ? ESCAPE '!'", prefixPattern(filter.NamePrefix))
+	}
+
+	query, page, err := paginate(query, filter.PageRequest, nameSortFields)
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+	var permissions []model.Permission
+	if err := query.Find(&permissions).Error; err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	count, info := page.pageInfo(len(permissions), func(i int) (interface{}, uint) {
+		switch page.field.column {
+		case "name":
+			return permissions[i].Name, permissions[i].ID
+		case "created_at":
+			return permissions[i].CreatedAt, permissions[i].ID
+		}
+		return nil, permissions[i].ID
+	})
+	return permissions[:count], info, nil
+}
+
+// FindPermissionByName implements domain.PermissionRepo.
+func (p *permissionRepository) FindPermissionByName(ctx context.Context, name string) (model.Permission, error) {
+	var permission model.Permission
+	if err := findByName(p.db.WithContext(ctx), &permission, name, "permission"); err != nil {
+		return model.Permission{}, err
+	}
+	return permission, nil
+}
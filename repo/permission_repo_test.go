@@ -1,73 +1,73 @@
 package repo
 
 import (
-	"errors"
+	"context"
+	"go-multirole/domain"
 	"go-multirole/model"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-type PermissionRepositoryMock struct {
-	Mock mock.Mock
-}
-
-func (repository *PermissionRepositoryMock) CreatePermission(permission model.Permission) (model.Permission, error) {
-	args := repository.Mock.Called(permission)
-	// Return the arguments as model.Role and error.
-	return args.Get(0).(model.Permission), args.Error(1)
-}
-
 func TestCreatePermission_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(PermissionRepositoryMock)
-	testPermission := model.Permission{Name: "read_permission"}
+	repository := NewPermissionRepository(newTestDB(t))
 
-	// Simulate successful permission creation with no error
-	repoMock.Mock.On("CreatePermission", testPermission).Return(testPermission, nil)
+	createdPermission, err := repository.CreatePermission(context.Background(), model.Permission{Name: "read_permission"})
 
-	// Act
-	createdPermission, err := repoMock.CreatePermission(testPermission)
-
-	// Assert
-	assert.NoError(t, err)                                       // No error should occur
-	assert.Equal(t, testPermission.Name, createdPermission.Name) // Permission name should match
-	repoMock.Mock.AssertExpectations(t)                          // Check that all expectations were met
+	assert.NoError(t, err)
+	assert.NotZero(t, createdPermission.ID)
+	assert.Equal(t, "read_permission", createdPermission.Name)
 }
 
-func TestCreatePermission_Failure(t *testing.T) {
-	// Arrange
-	repoMock := new(PermissionRepositoryMock)
-	testPermission := model.Permission{Name: "read_permission"}
-
-	// Simulate a database error during permission creation
-	repoMock.Mock.On("CreatePermission", testPermission).Return(model.Permission{}, errors.New("database error"))
+func TestCreatePermission_Duplicate(t *testing.T) {
+	repository := NewPermissionRepository(newTestDB(t))
+	_, err := repository.CreatePermission(context.Background(), model.Permission{Name: "read_permission"})
+	require.NoError(t, err)
 
-	// Act
-	createdPermission, err := repoMock.CreatePermission(testPermission)
+	_, err = repository.CreatePermission(context.Background(), model.Permission{Name: "read_permission"})
 
-	// Assert
-	assert.Error(t, err)                                   // Error should occur
-	assert.EqualError(t, err, "database error")            // Error message should be "database error"
-	assert.Equal(t, model.Permission{}, createdPermission) // Permission should be empty
-	repoMock.Mock.AssertExpectations(t)                    // Check that all expectations were met
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.EqualError(t, err, "permission read_permission already exists")
 }
 
-func TestCreatePermission_EmptyName(t *testing.T) {
-	// Arrange
-	repoMock := new(PermissionRepositoryMock)
-	testPermission := model.Permission{Name: ""} // Empty name
+func TestFindPermissionByName(t *testing.T) {
+	repository := NewPermissionRepository(newTestDB(t))
+	created, err := repository.CreatePermission(context.Background(), model.Permission{Name: "read_permission"})
+	require.NoError(t, err)
 
-	// Simulate that the method works with empty name
-	repoMock.Mock.On("CreatePermission", testPermission).Return(model.Permission{}, errors.New("empty name"))
+	permission, err := repository.FindPermissionByName(context.Background(), "read_permission")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, permission.ID)
 
-	// Act
-	createdPermission, err := repoMock.CreatePermission(testPermission)
+	_, err = repository.FindPermissionByName(context.Background(), "write_permission")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
 
-	// Assert
-	assert.Error(t, err)                                   // Error should occur
-	assert.EqualError(t, err, "empty name")                // Error message should be "empty name"
-	assert.Equal(t, model.Permission{}, createdPermission) // Permission should be empty
-	repoMock.Mock.AssertExpectations(t)                    // Check that all expectations were met
+func TestListPermissions(t *testing.T) {
+	repository := NewPermissionRepository(newTestDB(t))
+	for _, name := range []string{"read_users", "read_roles", "write_users"} {
+		_, err := repository.CreatePermission(context.Background(), model.Permission{Name: name})
+		require.NoError(t, err)
+	}
+
+	permissions, info, err := repository.ListPermissions(context.Background(), model.PermissionFilter{
+		NamePrefix:  "read",
+		PageRequest: model.PageRequest{Limit: 1, Sort: "name"},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, permissions, 1) {
+		assert.Equal(t, "read_roles", permissions[0].Name)
+	}
+	assert.True(t, info.HasMore)
+
+	permissions, info, err = repository.ListPermissions(context.Background(), model.PermissionFilter{
+		NamePrefix:  "read",
+		PageRequest: model.PageRequest{Limit: 1, Sort: "name", Cursor: info.NextCursor},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, permissions, 1) {
+		assert.Equal(t, "read_users", permissions[0].Name)
+	}
+	assert.False(t, info.HasMore)
 }
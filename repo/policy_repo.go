@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// roleJoinTables reference roles and are cleared when a role is deleted.
+var roleJoinTables = []string{"user_roles", "role_permissions", "invitation_roles", "oauth_client_roles"}
+
+type policyRepository struct {
+	db *gorm.DB
+}
+
+func NewPolicyRepository(db *gorm.DB) domain.PolicyRepo {
+	return &policyRepository{
+		db: db,
+	}
+}
+
+// ApplyPolicyPlan implements domain.PolicyRepo. Roles and permissions are
+// created before they're granted and deleted after their grants are revoked,
+// and every change records the same event as its API counterpart.
+func (p *policyRepository) ApplyPolicyPlan(ctx context.Context, plan model.PolicyPlan) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return applyPolicyPlan(tx, plan)
+	})
+}
+
+func applyPolicyPlan(tx *gorm.DB, plan model.PolicyPlan) error {
+	for _, name := range plan.CreatePermissions {
+		permission := model.Permission{Name: name}
+		if err := tx.Create(&permission).Error; err != nil {
+			return conflict(err, "permission %s already exists", name)
+		}
+		if err := enqueueEvent(tx, model.EventPermissionCreated, map[string]interface{}{
+			"permission_id":   permission.ID,
+			"permission_name": permission.Name,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, role := range plan.CreateRoles {
+		role := model.Role{Name: role.Name, RequireMFA: role.RequireMFA}
+		if err := tx.Create(&role).Error; err != nil {
+			return conflict(err, "role %s already exists", role.Name)
+		}
+		if err := enqueueEvent(tx, model.EventRoleCreated, map[string]interface{}{
+			"role_id":   role.ID,
+			"role_name": role.Name,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, update := range plan.UpdateRoles {
+		var role model.Role
+		if err := findByName(tx, &role, update.Name, "role"); err != nil {
+			return err
+		}
+		if err := tx.Model(&role).Update("require_mfa", update.RequireMFA).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, model.EventRoleUpdated, map[string]interface{}{
+			"role_id":     role.ID,
+			"role_name":   role.Name,
+			"require_mfa": update.RequireMFA,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, binding := range plan.Revoke {
+		role, permission, err := findBinding(tx, binding)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ? AND permission_id = ?", role.ID, permission.ID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, model.EventRolePermissionRevoked, bindingPayload(role, permission)); err != nil {
+			return err
+		}
+	}
+
+	for _, binding := range plan.Grant {
+		role, permission, err := findBinding(tx, binding)
+		if err != nil {
+			return err
+		}
+		grant := model.RolePermission{RoleID: role.ID, PermissionID: permission.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grant).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, model.EventRolePermissionAssigned, bindingPayload(role, permission)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range plan.DeleteRoles {
+		var role model.Role
+		if err := findByName(tx, &role, name, "role"); err != nil {
+			return err
+		}
+		for _, table := range roleJoinTables {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE role_id = ?", role.ID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&role).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, model.EventRoleDeleted, map[string]interface{}{
+			"role_id":   role.ID,
+			"role_name": role.Name,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range plan.DeletePermissions {
+		var permission model.Permission
+		if err := findByName(tx, &permission, name, "permission"); err != nil {
+			return err
+		}
+		if err := tx.Where("permission_id = ?", permission.ID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&permission).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, model.EventPermissionDeleted, map[string]interface{}{
+			"permission_id":   permission.ID,
+			"permission_name": permission.Name,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findBinding(tx *gorm.DB, binding model.PolicyBinding) (model.Role, model.Permission, error) {
+	var role model.Role
+	var permission model.Permission
+	if err := findByName(tx, &role, binding.Role, "role"); err != nil {
+		return role, permission, err
+	}
+	err := findByName(tx, &permission, binding.Permission, "permission")
+	return role, permission, err
+}
+
+func bindingPayload(role model.Role, permission model.Permission) map[string]interface{} {
+	return map[string]interface{}{
+		"role_id":         role.ID,
+		"role_name":       role.Name,
+		"permission_id":   permission.ID,
+		"permission_name": permission.Name,
+	}
+}
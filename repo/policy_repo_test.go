@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPolicyPlan(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := seedAccess(t, conn, "testuser", "legacy", "old_permission")
+	_, err := NewRoleRepository(conn).CreateRole(context.Background(), model.Role{Name: "admin"})
+	require.NoError(t, err)
+	repository := NewPolicyRepository(conn)
+
+	err = repository.ApplyPolicyPlan(context.Background(), model.PolicyPlan{
+		CreatePermissions: []string{"manage_users"},
+		CreateRoles:       []model.Role{{Name: "auditor"}},
+		UpdateRoles:       []model.Role{{Name: "admin", RequireMFA: true}},
+		Grant:             []model.PolicyBinding{{Role: "admin", Permission: "manage_users"}, {Role: "auditor", Permission: "manage_users"}},
+		DeleteRoles:       []string{"legacy"},
+		DeletePermissions: []string{"old_permission"},
+	})
+	require.NoError(t, err)
+
+	roles, _, err := NewRoleRepository(conn).ListRoles(context.Background(), model.RoleFilter{PageRequest: model.PageRequest{Sort: "name"}})
+	require.NoError(t, err)
+	if assert.Len(t, roles, 2) {
+		assert.Equal(t, "admin", roles[0].Name)
+		assert.True(t, roles[0].RequireMFA)
+		assert.Len(t, roles[0].Permissions, 1)
+		assert.Equal(t, "auditor", roles[1].Name)
+	}
+	_, err = NewPermissionRepository(conn).FindPermissionByName(context.Background(), "old_permission")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// The deleted role is no longer assigned
+	permissions, err := NewUserRepository(conn, testPasswordHasher).ListUserPermissions(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	var events int64
+	conn.Model(&model.OutboxEvent{}).Where("type IN ?", []string{model.EventRoleDeleted, model.EventPermissionDeleted, model.EventRoleUpdated}).Count(&events)
+	assert.Equal(t, int64(3), events)
+
+	err = repository.ApplyPolicyPlan(context.Background(), model.PolicyPlan{Revoke: []model.PolicyBinding{{Role: "admin", Permission: "manage_users"}}})
+	require.NoError(t, err)
+	roles, _, err = NewRoleRepository(conn).ListRoles(context.Background(), model.RoleFilter{NamePrefix: "admin"})
+	require.NoError(t, err)
+	assert.Empty(t, roles[0].Permissions)
+}
+
+func TestApplyPolicyPlan_RollsBack(t *testing.T) {
+	conn := newTestDB(t)
+	repository := NewPolicyRepository(conn)
+
+	err := repository.ApplyPolicyPlan(context.Background(), model.PolicyPlan{
+		CreatePermissions: []string{"manage_users"},
+		Grant:             []model.PolicyBinding{{Role: "missing", Permission: "manage_users"}},
+	})
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = NewPermissionRepository(conn).FindPermissionByName(context.Background(), "manage_users")
+	assert.ErrorIs(t, err, domain.ErrNotFound, "Changes before the failure are rolled back")
+}
@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"context"
 	"go-multirole/domain"
 	"go-multirole/model"
 
@@ -18,28 +19,112 @@ func NewRoleRepository(db *gorm.DB) domain.RoleRepo {
 }
 
 // CreateRole implements domain.RoleRepo.
-func (r *roleRepository) CreateRole(role model.Role) (model.Role, error) {
-	if err := r.db.Create(&role).Error; err != nil {
+func (r *roleRepository) CreateRole(ctx context.Context, role model.Role) (model.Role, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&role).Error; err != nil {
+			return conflict(err, "role %s already exists", role.Name)
+		}
+		return enqueueEvent(tx, model.EventRoleCreated, map[string]interface{}{
+			"role_id":   role.ID,
+			"role_name": role.Name,
+		})
+	})
+	if err != nil {
 		return role, err
 	}
 	return role, nil
 }
 
-// AssignPermissionToRole implements domain.RoleRepo.
-func (r *roleRepository) AssignPermissionToRole(roleID string, permissionID string) error {
-	var role model.Role
-	var permission model.Permission
+// ListRoles implements domain.RoleRepo.
+func (r *roleRepository) ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error) {
+	query := applyCreatedRange(r.db.WithContext(ctx).Model(&model.Role{}), filter.CreatedRange)
+	if filter.NamePrefix != "" {
+		query = query.Where("name LIKE ? ESCAPE '!'", prefixPattern(filter.NamePrefix))
+	}
+	if filter.Permission != "" {
+		query = query.Where("id IN (?)", r.db.Table("role_permissions").
+			Select("role_permissions.role_id").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+			Where("permissions.name = ?", filter.Permission))
+	}
 
-	if err := r.db.First(&role, roleID).Error; err != nil {
-		return err
+	query, page, err := paginate(query, filter.PageRequest, nameSortFields)
+	if err != nil {
+		return nil, model.PageInfo{}, err
 	}
-	if err := r.db.First(&permission, permissionID).Error; err != nil {
-		return err
+	var roles []model.Role
+	if err := query.Preload("Permissions").Find(&roles).Error; err != nil {
+		return nil, model.PageInfo{}, err
 	}
 
-	if err := r.db.Model(&role).Association("Permissions").Append(&permission); err != nil {
-		return err
+	count, info := page.pageInfo(len(roles), func(i int) (interface{}, uint) {
+		switch page.field.column {
+		case "name":
+			return roles[i].Name, roles[i].ID
+		case "created_at":
+			return roles[i].CreatedAt, roles[i].ID
+		}
+		return nil, roles[i].ID
+	})
+	return roles[:count], info, nil
+}
+
+// FindRoleByName implements domain.RoleRepo. The role is loaded with its
+// permissions.
+func (r *roleRepository) FindRoleByName(ctx context.Context, name string) (model.Role, error) {
+	var role model.Role
+	if err := findByName(r.db.WithContext(ctx).Preload("Permissions"), &role, name, "role"); err != nil {
+		return model.Role{}, err
 	}
+	return role, nil
+}
+
+// AssignPermissionToRole implements domain.RoleRepo.
+func (r *roleRepository) AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		var permission model.Permission
+		if err := findByID(tx, &role, roleID, "role"); err != nil {
+			return err
+		}
+		if err := findByID(tx, &permission, permissionID, "permission"); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&role).Association("Permissions").Append(&permission); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, model.EventRolePermissionAssigned, map[string]interface{}{
+			"role_id":         role.ID,
+			"role_name":       role.Name,
+			"permission_id":   permission.ID,
+			"permission_name": permission.Name,
+		})
+	})
+}
+
+// RevokePermissionFromRole implements domain.RoleRepo. Revoking a permission
+// the role doesn't grant does nothing.
+func (r *roleRepository) RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		var permission model.Permission
+		if err := findByID(tx, &role, roleID, "role"); err != nil {
+			return err
+		}
+		if err := findByID(tx, &permission, permissionID, "permission"); err != nil {
+			return err
+		}
 
-	return nil
+		result := tx.Where("role_id = ? AND permission_id = ?", role.ID, permission.ID).Delete(&model.RolePermission{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return enqueueEvent(tx, model.EventRolePermissionRevoked, map[string]interface{}{
+			"role_id":         role.ID,
+			"role_name":       role.Name,
+			"permission_id":   permission.ID,
+			"permission_name": permission.Name,
+		})
+	})
 }
@@ -1,135 +1,125 @@
 package repo
 
 import (
-	"errors"
+	"context"
+	"go-multirole/domain"
 	"go-multirole/model"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
-type RoleRepositoryMock struct {
-	Mock mock.Mock
-}
+func TestCreateRole_Success(t *testing.T) {
+	repository := NewRoleRepository(newTestDB(t))
 
-func (repository *RoleRepositoryMock) CreateRole(role model.Role) (model.Role, error) {
-	args := repository.Mock.Called(role)
-	// Return the arguments as model.Role and error.
-	return args.Get(0).(model.Role), args.Error(1)
-}
+	createdRole, err := repository.CreateRole(context.Background(), model.Role{Name: "Admin"})
 
-func (repository *RoleRepositoryMock) AssignPermissionToRole(roleID string, permissionID string) error {
-	args := repository.Mock.Called(roleID, permissionID)
-	return args.Error(0)
+	assert.NoError(t, err)
+	assert.NotZero(t, createdRole.ID)
+	assert.Equal(t, "Admin", createdRole.Name)
 }
 
-func TestCreateRole_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(RoleRepositoryMock)
-	testRole := model.Role{Name: "Admin"}
-
-	// Mock the behavior: return the test role and no error on CreateRole
-	repoMock.Mock.On("CreateRole", testRole).Return(testRole, nil)
+func TestCreateRole_Duplicate(t *testing.T) {
+	repository := NewRoleRepository(newTestDB(t))
+	_, err := repository.CreateRole(context.Background(), model.Role{Name: "Admin"})
+	require.NoError(t, err)
 
-	// Act
-	createdRole, err := repoMock.CreateRole(testRole)
+	_, err = repository.CreateRole(context.Background(), model.Role{Name: "Admin"})
 
-	// Assert
-	assert.NoError(t, err)                           // No error should occur
-	assert.Equal(t, testRole.Name, createdRole.Name) // Role name should match
-	repoMock.Mock.AssertExpectations(t)              // Check all expectations were met
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.EqualError(t, err, "role Admin already exists")
 }
 
-func TestCreateRole_Failure(t *testing.T) {
-	// Arrange
-	repoMock := new(RoleRepositoryMock)
-	testRole := model.Role{Name: "Admin"}
-	expectedError := errors.New("database error")
+func TestAssignPermissionToRole_Success(t *testing.T) {
+	conn := newTestDB(t)
+	repository := NewRoleRepository(conn)
+	role, err := repository.CreateRole(context.Background(), model.Role{Name: "Admin"})
+	require.NoError(t, err)
+	permission, err := NewPermissionRepository(conn).CreatePermission(context.Background(), model.Permission{Name: "manage_users"})
+	require.NoError(t, err)
+
+	err = repository.AssignPermissionToRole(context.Background(), role.ID, permission.ID)
+
+	assert.NoError(t, err)
+	roles, _, err := repository.ListRoles(context.Background(), model.RoleFilter{})
+	require.NoError(t, err)
+	if assert.Len(t, roles, 1) && assert.Len(t, roles[0].Permissions, 1) {
+		assert.Equal(t, "manage_users", roles[0].Permissions[0].Name)
+	}
+}
 
-	// Mock the behavior: return an error on CreateRole
-	repoMock.Mock.On("CreateRole", testRole).Return(model.Role{}, expectedError)
+func TestAssignPermissionToRole_Failure_RoleNotFound(t *testing.T) {
+	conn := newTestDB(t)
+	permission, err := NewPermissionRepository(conn).CreatePermission(context.Background(), model.Permission{Name: "manage_users"})
+	require.NoError(t, err)
 
-	// Act
-	createdRole, err := repoMock.CreateRole(testRole)
+	err = NewRoleRepository(conn).AssignPermissionToRole(context.Background(), 1, permission.ID)
 
-	// Assert
-	assert.Error(t, err)                        // An error should occur
-	assert.EqualError(t, err, "database error") // Error message should match
-	assert.Equal(t, model.Role{}, createdRole)  // Created role should be empty
-	repoMock.Mock.AssertExpectations(t)         // Check all expectations were met
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.EqualError(t, err, "role not found")
 }
 
-func TestAssignPermissionToRole_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(RoleRepositoryMock)
-	roleID := "1"
-	permissionID := "10"
-
-	// Mock the behavior: no error returned on AssignPermissionToRole
-	repoMock.Mock.On("AssignPermissionToRole", roleID, permissionID).Return(nil)
+func TestAssignPermissionToRole_Failure_PermissionNotFound(t *testing.T) {
+	repository := NewRoleRepository(newTestDB(t))
+	role, err := repository.CreateRole(context.Background(), model.Role{Name: "Admin"})
+	require.NoError(t, err)
 
-	// Act
-	err := repoMock.AssignPermissionToRole(roleID, permissionID)
+	err = repository.AssignPermissionToRole(context.Background(), role.ID, 10)
 
-	// Assert
-	assert.NoError(t, err)              // Expect no error
-	repoMock.Mock.AssertExpectations(t) // Check all expectations were met
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.EqualError(t, err, "permission not found")
 }
 
-func TestAssignPermissionToRole_Failure_RoleNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(RoleRepositoryMock)
-	roleID := "1"
-	permissionID := "10"
-	expectedError := errors.New("role not found")
-
-	// Mock the behavior: return "role not found" error
-	repoMock.Mock.On("AssignPermissionToRole", roleID, permissionID).Return(expectedError)
-
-	// Act
-	err := repoMock.AssignPermissionToRole(roleID, permissionID)
-
-	// Assert
-	assert.Error(t, err)                        // Expect an error
-	assert.EqualError(t, err, "role not found") // Error message should match
-	repoMock.Mock.AssertExpectations(t)         // Check all expectations were met
-}
+func TestRevokePermissionFromRole(t *testing.T) {
+	conn := newTestDB(t)
+	user, role := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewRoleRepository(conn)
+	permission, err := NewPermissionRepository(conn).FindPermissionByName(context.Background(), "manage_users")
+	require.NoError(t, err)
 
-func TestAssignPermissionToRole_Failure_PermissionNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(RoleRepositoryMock)
-	roleID := "1"
-	permissionID := "10"
-	expectedError := errors.New("permission not found")
-
-	// Mock the behavior: return "permission not found" error
-	repoMock.Mock.On("AssignPermissionToRole", roleID, permissionID).Return(expectedError)
-
-	// Act
-	err := repoMock.AssignPermissionToRole(roleID, permissionID)
-
-	// Assert
-	assert.Error(t, err)                              // Expect an error
-	assert.EqualError(t, err, "permission not found") // Error message should match
-	repoMock.Mock.AssertExpectations(t)               // Check all expectations were met
+	require.NoError(t, repository.RevokePermissionFromRole(context.Background(), role.ID, permission.ID))
+
+	hasPermission, err := NewUserRepository(conn, testPasswordHasher).CheckUserPermission(context.Background(), user.ID, "manage_users")
+	assert.NoError(t, err)
+	assert.False(t, hasPermission)
+	assert.NoError(t, repository.RevokePermissionFromRole(context.Background(), role.ID, permission.ID), "Revoking a permission the role doesn't grant is a no-op")
+	assert.EqualError(t, repository.RevokePermissionFromRole(context.Background(), role.ID, 99), "permission not found")
 }
 
-func TestAssignPermissionToRole_Failure_AssociationError(t *testing.T) {
-	// Arrange
-	repoMock := new(RoleRepositoryMock)
-	roleID := "1"
-	permissionID := "10"
-	expectedError := errors.New("failed to associate permission with role")
+func TestFindRoleByName(t *testing.T) {
+	repository := NewRoleRepository(newTestDB(t))
+	created, err := repository.CreateRole(context.Background(), model.Role{Name: "Admin"})
+	require.NoError(t, err)
 
-	// Mock the behavior: return "association error" when appending the permission
-	repoMock.Mock.On("AssignPermissionToRole", roleID, permissionID).Return(expectedError)
+	role, err := repository.FindRoleByName(context.Background(), "Admin")
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, role.ID)
 
-	// Act
-	err := repoMock.AssignPermissionToRole(roleID, permissionID)
+	_, err = repository.FindRoleByName(context.Background(), "Auditor")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.EqualError(t, err, "role Auditor not found")
+}
 
-	// Assert
-	assert.Error(t, err)                                                  // Expect an error
-	assert.EqualError(t, err, "failed to associate permission with role") // Error message should match
-	repoMock.Mock.AssertExpectations(t)                                   // Check all expectations were met
+func TestListRoles(t *testing.T) {
+	conn := newTestDB(t)
+	seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewRoleRepository(conn)
+	for _, name := range []string{"auditor", "admin_readonly"} {
+		_, err := repository.CreateRole(context.Background(), model.Role{Name: name})
+		require.NoError(t, err)
+	}
+
+	roles, _, err := repository.ListRoles(context.Background(), model.RoleFilter{NamePrefix: "admin", PageRequest: model.PageRequest{Sort: "name"}})
+	assert.NoError(t, err)
+	if assert.Len(t, roles, 2) {
+		assert.Equal(t, "admin", roles[0].Name)
+		assert.Equal(t, "admin_readonly", roles[1].Name)
+	}
+
+	roles, _, err = repository.ListRoles(context.Background(), model.RoleFilter{Permission: "manage_users"})
+	assert.NoError(t, err)
+	if assert.Len(t, roles, 1) {
+		assert.Equal(t, "admin", roles[0].Name)
+	}
 }
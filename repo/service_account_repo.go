@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type serviceAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewServiceAccountRepository(db *gorm.DB) domain.ServiceAccountRepo {
+	return &serviceAccountRepository{
+		db: db,
+	}
+}
+
+// CreateServiceAccount implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) CreateServiceAccount(ctx context.Context, user model.User) (model.User, error) {
+	user.ServiceAccount = true
+	user.Password = ""
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return conflict(err, "username %s is already taken", user.Username)
+		}
+		return enqueueEvent(tx, model.EventUserCreated, map[string]interface{}{
+			"user_id":         user.ID,
+			"username":        user.Username,
+			"service_account": true,
+		})
+	})
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// FindServiceAccount implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) FindServiceAccount(ctx context.Context, userID uint) (model.User, error) {
+	var user model.User
+	if err := findByID(s.db.WithContext(ctx).Where("service_account = ?", true), &user, userID, "service account"); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// CreateAPIKey implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error) {
+	if err := s.db.WithContext(ctx).Create(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+	return apiKey, nil
+}
+
+// FindAPIKeyByPrefix implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) FindAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	var apiKey model.APIKey
+	if err := s.db.WithContext(ctx).Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		return model.APIKey{}, err
+	}
+	return apiKey, nil
+}
+
+// ListAPIKeys implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	var apiKeys []model.APIKey
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// RevokeAPIKey implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) RevokeAPIKey(ctx context.Context, userID uint, keyID uint, revokedAt time.Time) error {
+	var apiKey model.APIKey
+	if err := findByID(s.db.WithContext(ctx).Where("user_id = ?", userID), &apiKey, keyID, "api key"); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&apiKey).Update("revoked_at", revokedAt).Error
+}
+
+// TouchAPIKey implements domain.ServiceAccountRepo.
+func (s *serviceAccountRepository) TouchAPIKey(ctx context.Context, keyID uint, usedAt time.Time) error {
+	return s.db.WithContext(ctx).Model(&model.APIKey{ID: keyID}).Update("last_used_at", usedAt).Error
+}
@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type snapshotRepository struct {
+	db *gorm.DB
+}
+
+func NewSnapshotRepository(db *gorm.DB) domain.SnapshotRepo {
+	return &snapshotRepository{
+		db: db,
+	}
+}
+
+// ExportSnapshot implements domain.SnapshotRepo. Entities are sorted by name
+// so exports of the same state are identical.
+func (s *snapshotRepository) ExportSnapshot(ctx context.Context, includePasswordHashes bool) (model.Snapshot, error) {
+	snapshot := model.Snapshot{
+		Version:     model.SnapshotVersion,
+		Permissions: []string{},
+		Roles:       []model.PolicyRole{},
+		Users:       []model.SnapshotUser{},
+	}
+	byName := func(column string) func(db *gorm.DB) *gorm.DB {
+		return func(db *gorm.DB) *gorm.DB { return db.Order(column) }
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var permissions []model.Permission
+		if err := tx.Order("name").Find(&permissions).Error; err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			snapshot.Permissions = append(snapshot.Permissions, permission.Name)
+		}
+
+		var roles []model.Role
+		if err := tx.Preload("Permissions", byName("permissions.name")).Order("name").Find(&roles).Error; err != nil {
+			return err
+		}
+		for _, role := range roles {
+			exported := model.PolicyRole{Name: role.Name, RequireMFA: role.RequireMFA, Permissions: []string{}}
+			for _, permission := range role.Permissions {
+				exported.Permissions = append(exported.Permissions, permission.Name)
+			}
+			snapshot.Roles = append(snapshot.Roles, exported)
+		}
+
+		var users []model.User
+		if err := tx.Preload("Roles", byName("roles.name")).Order("username").Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			exported := model.SnapshotUser{
+				Username:       user.Username,
+				Email:          user.Email,
+				Status:         user.Status,
+				ServiceAccount: user.ServiceAccount,
+				Roles:          []string{},
+			}
+			if includePasswordHashes {
+				exported.PasswordHash = user.Password
+			}
+			for _, role := range user.Roles {
+				exported.Roles = append(exported.Roles, role.Name)
+			}
+			snapshot.Users = append(snapshot.Users, exported)
+		}
+		return nil
+	})
+	if err != nil {
+		return model.Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// ApplyImportPlan implements domain.SnapshotRepo. Users are created with the
+// same events as their API counterparts; a role assignment the user already
+// has is kept.
+func (s *snapshotRepository) ApplyImportPlan(ctx context.Context, plan model.ImportPlan) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := applyPolicyPlan(tx, plan.PolicyPlan); err != nil {
+			return err
+		}
+
+		for _, imported := range plan.CreateUsers {
+			user := model.User{
+				Username:       imported.Username,
+				Email:          imported.Email,
+				Status:         imported.Status,
+				ServiceAccount: imported.ServiceAccount,
+				Password:       imported.PasswordHash,
+			}
+			if err := createUser(tx, &user); err != nil {
+				return err
+			}
+		}
+
+		for _, binding := range plan.Unassign {
+			user, role, err := findUserBinding(tx, binding)
+			if err != nil {
+				return err
+			}
+			result := tx.Where("user_id = ? AND role_id = ?", user.ID, role.ID).Delete(&model.UserRole{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if err := enqueueEvent(tx, model.EventUserRoleRevoked, userBindingPayload(user, role)); err != nil {
+				return err
+			}
+		}
+
+		for _, binding := range plan.Assign {
+			user, role, err := findUserBinding(tx, binding)
+			if err != nil {
+				return err
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserRole{UserID: user.ID, RoleID: role.ID})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue // Already assigned
+			}
+			if err := enqueueEvent(tx, model.EventUserRoleAssigned, userBindingPayload(user, role)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func findUserBinding(tx *gorm.DB, binding model.UserBinding) (model.User, model.Role, error) {
+	var user model.User
+	var role model.Role
+	if err := notFound(whereUsername(tx, binding.Username).First(&user).Error, "user %s not found", binding.Username); err != nil {
+		return user, role, err
+	}
+	err := findByName(tx, &role, binding.Role, "role")
+	return user, role, err
+}
+
+func userBindingPayload(user model.User, role model.Role) map[string]interface{} {
+	return map[string]interface{}{
+		"user_id":   user.ID,
+		"username":  user.Username,
+		"role_id":   role.ID,
+		"role_name": role.Name,
+	}
+}
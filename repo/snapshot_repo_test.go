@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportSnapshot(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	_, err := NewRoleRepository(conn).CreateRole(context.Background(), model.Role{Name: "auditor", RequireMFA: true})
+	require.NoError(t, err)
+	repository := NewSnapshotRepository(conn)
+
+	snapshot, err := repository.ExportSnapshot(context.Background(), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.Snapshot{
+		Version:     model.SnapshotVersion,
+		Permissions: []string{"manage_users"},
+		Roles: []model.PolicyRole{
+			{Name: "admin", Permissions: []string{"manage_users"}},
+			{Name: "auditor", RequireMFA: true, Permissions: []string{}},
+		},
+		Users: []model.SnapshotUser{{Username: "testuser", Status: model.UserStatusActive, Roles: []string{"admin"}}},
+	}, snapshot)
+
+	snapshot, err = repository.ExportSnapshot(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, user.Password, snapshot.Users[0].PasswordHash)
+}
+
+func TestApplyImportPlan(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := seedAccess(t, conn, "testuser", "legacy", "manage_users")
+	repository := NewSnapshotRepository(conn)
+
+	err := repository.ApplyImportPlan(context.Background(), model.ImportPlan{
+		PolicyPlan: model.PolicyPlan{
+			CreateRoles: []model.Role{{Name: "admin"}},
+			Grant:       []model.PolicyBinding{{Role: "admin", Permission: "manage_users"}},
+			DeleteRoles: []string{"legacy"},
+		},
+		CreateUsers: []model.SnapshotUser{
+			{Username: "clone", Email: "clone@example.com", PasswordHash: user.Password},
+			{Username: "robot", Status: model.UserStatusDisabled, ServiceAccount: true},
+		},
+		Assign: []model.UserBinding{{Username: "testuser", Role: "admin"}, {Username: "clone", Role: "admin"}},
+	})
+	require.NoError(t, err)
+
+	snapshot, err := repository.ExportSnapshot(context.Background(), true)
+	require.NoError(t, err)
+	assert.Equal(t, []model.SnapshotUser{
+		{Username: "clone", Email: "clone@example.com", Status: model.UserStatusActive, PasswordHash: user.Password, Roles: []string{"admin"}},
+		{Username: "robot", Status: model.UserStatusDisabled, ServiceAccount: true, Roles: []string{}},
+		{Username: "testuser", Status: model.UserStatusActive, PasswordHash: user.Password, Roles: []string{"admin"}},
+	}, snapshot.Users)
+
+	// The imported hash verifies like the original
+	clone, err := NewUserRepository(conn, testPasswordHasher).FindUserByIdentifier(context.Background(), "clone")
+	require.NoError(t, err)
+	assert.True(t, testPasswordHasher.Verify(clone.Password, "password123"))
+
+	err = repository.ApplyImportPlan(context.Background(), model.ImportPlan{Unassign: []model.UserBinding{{Username: "clone", Role: "admin"}}})
+	require.NoError(t, err)
+	permissions, err := NewUserRepository(conn, testPasswordHasher).ListUserPermissions(context.Background(), clone.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, permissions)
+}
+
+func TestApplyImportPlan_RollsBack(t *testing.T) {
+	conn := newTestDB(t)
+	repository := NewSnapshotRepository(conn)
+
+	err := repository.ApplyImportPlan(context.Background(), model.ImportPlan{
+		CreateUsers: []model.SnapshotUser{{Username: "testuser"}},
+		Assign:      []model.UserBinding{{Username: "testuser", Role: "missing"}},
+	})
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	snapshot, err := repository.ExportSnapshot(context.Background(), false)
+	require.NoError(t, err)
+	assert.Empty(t, snapshot.Users, "Changes before the failure are rolled back")
+}
@@ -0,0 +1,34 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/utils"
+
+	"gorm.io/gorm"
+)
+
+type unitOfWork struct {
+	db             *gorm.DB
+	passwordHasher utils.PasswordHasher
+}
+
+func NewUnitOfWork(db *gorm.DB, passwordHasher utils.PasswordHasher) domain.UnitOfWork {
+	return &unitOfWork{
+		db:             db,
+		passwordHasher: passwordHasher,
+	}
+}
+
+// Do implements domain.UnitOfWork. The repositories are built on the
+// transaction, so the transactions they start themselves become savepoints
+// within it.
+func (u *unitOfWork) Do(ctx context.Context, fn func(repos domain.Repos) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(domain.Repos{
+			Users:       NewUserRepository(tx, u.passwordHasher),
+			Roles:       NewRoleRepository(tx),
+			Permissions: NewPermissionRepository(tx),
+		})
+	})
+}
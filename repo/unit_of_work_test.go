@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	unitOfWork := NewUnitOfWork(conn, testPasswordHasher)
+
+	t.Run("Commit", func(t *testing.T) {
+		var role model.Role
+		err := unitOfWork.Do(context.Background(), func(repos domain.Repos) error {
+			var err error
+			if role, err = repos.Roles.CreateRole(context.Background(), model.Role{Name: "auditor"}); err != nil {
+				return err
+			}
+			return repos.Users.AssignRoleToUser(context.Background(), user.ID, role.ID)
+		})
+
+		require.NoError(t, err)
+		var count int64
+		conn.Model(&model.UserRole{}).Where("user_id = ? AND role_id = ?", user.ID, role.ID).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		err := unitOfWork.Do(context.Background(), func(repos domain.Repos) error {
+			role, err := repos.Roles.CreateRole(context.Background(), model.Role{Name: "support"})
+			if err != nil {
+				return err
+			}
+			if _, err := repos.Permissions.CreatePermission(context.Background(), model.Permission{Name: "read_tickets"}); err != nil {
+				return err
+			}
+			return repos.Users.AssignRoleToUser(context.Background(), 999, role.ID)
+		})
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		_, err = NewRoleRepository(conn).FindRoleByName(context.Background(), "support")
+		assert.ErrorIs(t, err, domain.ErrNotFound, "The role is rolled back")
+		_, err = NewPermissionRepository(conn).FindPermissionByName(context.Background(), "read_tickets")
+		assert.ErrorIs(t, err, domain.ErrNotFound, "The permission is rolled back")
+
+		var events int64
+		conn.Model(&model.OutboxEvent{}).Where("type = ?", model.EventRoleCreated).Count(&events)
+		assert.Equal(t, int64(2), events, "The events of the rolled back work are dropped")
+	})
+}
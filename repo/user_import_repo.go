@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+
+	"gorm.io/gorm"
+)
+
+type userImportRepository struct {
+	db             *gorm.DB
+	passwordHasher utils.PasswordHasher
+}
+
+func NewUserImportRepository(db *gorm.DB, passwordHasher utils.PasswordHasher) domain.UserImportRepo {
+	return &userImportRepository{
+		db:             db,
+		passwordHasher: passwordHasher,
+	}
+}
+
+// ImportUsers implements domain.UserImportRepo. Passwords are hashed before
+// any transaction starts, since hashing takes far longer than the inserts.
+func (r *userImportRepository) ImportUsers(ctx context.Context, imports []model.UserImport, perRow bool) ([]model.User, []error) {
+	users := make([]model.User, len(imports))
+	errs := make([]error, len(imports))
+	for i, imported := range imports {
+		users[i] = imported.User
+		if imported.Invitation == nil {
+			users[i].Password, errs[i] = r.passwordHasher.Hash(imported.User.Password)
+		}
+	}
+
+	if perRow {
+		for i := range imports {
+			if errs[i] == nil {
+				errs[i] = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+					return importUser(tx, &users[i], imports[i])
+				})
+			}
+			if errs[i] != nil {
+				users[i] = model.User{}
+			}
+		}
+		return users, errs
+	}
+
+	failed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range imports {
+			if errs[i] == nil {
+				errs[i] = importUser(tx, &users[i], imports[i])
+			}
+			if errs[i] != nil {
+				failed = true
+				return errs[i]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range users {
+			users[i] = model.User{}
+			// A failed commit belongs to no row in particular
+			if !failed {
+				errs[i] = err
+			}
+		}
+	}
+	return users, errs
+}
+
+// importUser creates the user with its roles, or the invitation that assigns
+// them on acceptance.
+func importUser(tx *gorm.DB, user *model.User, imported model.UserImport) error {
+	if imported.Invitation != nil {
+		invitation := *imported.Invitation
+		if err := createInvitation(tx, &invitation, imported.RoleIDs); err != nil {
+			return err
+		}
+		return tx.First(user, invitation.UserID).Error
+	}
+
+	var roles []model.Role
+	if len(imported.RoleIDs) > 0 {
+		if err := tx.Find(&roles, imported.RoleIDs).Error; err != nil {
+			return err
+		}
+		if len(roles) != len(imported.RoleIDs) {
+			return domain.NotFound("role not found")
+		}
+	}
+	if err := createUser(tx, user); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := tx.Create(&model.UserRole{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, model.EventUserRoleAssigned, userBindingPayload(*user, role)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
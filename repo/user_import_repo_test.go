@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportUsers(t *testing.T) {
+	conn := newTestDB(t)
+	_, role := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserImportRepository(conn, testPasswordHasher)
+
+	users, errs := repository.ImportUsers(context.Background(), []model.UserImport{
+		{User: model.User{Username: "alice", Password: "Secret-123456"}, RoleIDs: []uint{role.ID}},
+		{
+			User:       model.User{Username: "bob", Email: "bob@example.com"},
+			RoleIDs:    []uint{role.ID},
+			Invitation: &model.Invitation{Username: "bob", Email: "bob@example.com", TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)},
+		},
+	}, false)
+
+	assert.Equal(t, []error{nil, nil}, errs)
+	require.NotZero(t, users[0].ID)
+	assert.True(t, testPasswordHasher.Verify(users[0].Password, "Secret-123456"), "The password is stored hashed")
+	assert.Equal(t, model.UserStatusPending, users[1].Status)
+
+	permissions, err := NewUserRepository(conn, testPasswordHasher).ListUserPermissions(context.Background(), users[0].ID)
+	require.NoError(t, err)
+	assert.Len(t, permissions, 1, "The roles are assigned")
+
+	var invitation model.Invitation
+	require.NoError(t, conn.Preload("Roles").Where("user_id = ?", users[1].ID).First(&invitation).Error)
+	assert.Equal(t, "admin", invitation.Roles[0].Name, "The roles are assigned on acceptance")
+}
+
+func TestImportUsers_Transaction(t *testing.T) {
+	conn := newTestDB(t)
+	seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserImportRepository(conn, testPasswordHasher)
+
+	users, errs := repository.ImportUsers(context.Background(), []model.UserImport{
+		{User: model.User{Username: "alice", Password: "Secret-123456"}},
+		{User: model.User{Username: "testuser", Password: "Secret-123456"}},
+		{User: model.User{Username: "carol", Password: "Secret-123456"}},
+	}, false)
+
+	assert.Equal(t, []model.User{{}, {}, {}}, users)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], domain.ErrConflict)
+	assert.NoError(t, errs[2])
+
+	var count int64
+	conn.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(1), count, "The whole import is rolled back")
+}
+
+func TestImportUsers_PerRow(t *testing.T) {
+	conn := newTestDB(t)
+	seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserImportRepository(conn, testPasswordHasher)
+
+	users, errs := repository.ImportUsers(context.Background(), []model.UserImport{
+		{User: model.User{Username: "alice", Password: "Secret-123456"}},
+		{User: model.User{Username: "testuser", Password: "Secret-123456"}},
+		{User: model.User{Username: "carol", Password: "Secret-123456"}, RoleIDs: []uint{999}},
+	}, true)
+
+	assert.NotZero(t, users[0].ID)
+	assert.NoError(t, errs[0])
+	assert.Zero(t, users[1].ID)
+	assert.ErrorIs(t, errs[1], domain.ErrConflict)
+	assert.Zero(t, users[2].ID)
+	assert.ErrorIs(t, errs[2], domain.ErrNotFound)
+
+	var count int64
+	conn.Model(&model.User{}).Count(&count)
+	assert.Equal(t, int64(2), count, "Only the failed rows are rolled back")
+}
@@ -1,41 +1,71 @@
 package repo
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go-multirole/domain"
 	"go-multirole/model"
 	"go-multirole/utils"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type userRepository struct {
-	db *gorm.DB
+	db             *gorm.DB
+	passwordHasher utils.PasswordHasher
 }
 
-func NewUserRepository(db *gorm.DB) domain.UserRepo {
+func NewUserRepository(db *gorm.DB, passwordHasher utils.PasswordHasher) domain.UserRepo {
 	return &userRepository{
-		db: db,
+		db:             db,
+		passwordHasher: passwordHasher,
 	}
 }
 
 // CreateUser implements domain.UserRepo.
-func (d *userRepository) CreateUser(user model.User) (model.User, error) {
-	user.Password, _ = utils.HashPassword(user.Password)
-	if err := d.db.Create(&user).Error; err != nil {
+func (d *userRepository) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	hashedPassword, err := d.passwordHasher.Hash(user.Password)
+	if err != nil {
+		return user, err
+	}
+	user.Password = hashedPassword
+
+	err = d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createUser(tx, &user)
+	})
+	if err != nil {
 		return user, err
 	}
 	return user, nil
 }
 
+// createUser stores a user whose password is already hashed, starting its
+// password history. Users without a password have no history.
+func createUser(tx *gorm.DB, user *model.User) error {
+	if err := tx.Create(user).Error; err != nil {
+		return conflict(err, "username %s is already taken", user.Username)
+	}
+	if user.Password != "" {
+		if err := tx.Create(&model.PasswordHistory{UserID: user.ID, PasswordHash: user.Password}).Error; err != nil {
+			return err
+		}
+	}
+	return enqueueEvent(tx, model.EventUserCreated, map[string]interface{}{
+		"user_id":  user.ID,
+		"username": user.Username,
+	})
+}
+
 // LoginUser checks credentials and returns the authenticated user with roles.
-func (d *userRepository) LoginUser(inputUser model.User) (model.User, error) {
+func (d *userRepository) LoginUser(ctx context.Context, inputUser model.User) (model.User, error) {
 	var dbUser model.User
 
-	if err := d.db.Where("username = ?", inputUser.Username).First(&dbUser).Error; err != nil {
+	if err := whereUsername(d.db.WithContext(ctx).Preload("Roles"), inputUser.Username).First(&dbUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return model.User{}, errors.New("user not found")
+			return model.User{}, domain.ErrUserNotFound
 		}
 		return model.User{}, fmt.Errorf("database error: %w", err)
 	}
@@ -43,29 +73,256 @@ func (d *userRepository) LoginUser(inputUser model.User) (model.User, error) {
 	return dbUser, nil
 }
 
-// AssignRoleToUser implements domain.UserRepo.
-func (d *userRepository) AssignRoleToUser(userId string, roleID string) error {
+// ListPasswordHistory returns the user's most recent password hashes, newest first.
+func (d *userRepository) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]model.PasswordHistory, error) {
+	var history []model.PasswordHistory
+	if err := d.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Limit(limit).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// ListUsers implements domain.UserRepo.
+func (d *userRepository) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	query := applyCreatedRange(d.db.WithContext(ctx).Model(&model.User{}), filter.CreatedRange)
+	if filter.UsernamePrefix != "" {
+		query = query.Where("username LIKE ? ESCAPE '!'", prefixPattern(filter.UsernamePrefix))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	// Subqueries rather than joins, so users with several matching roles are listed once
+	if filter.Role != "" {
+		query = query.Where("id IN (?)", d.db.Table("user_roles").
+			Select("user_roles.user_id").
+			Joins("JOIN roles ON roles.id = user_roles.role_id").
+			Where("roles.name = ?", filter.Role))
+	}
+	if filter.Permission != "" {
+		query = query.Where("id IN (?)", d.db.Table("user_roles").
+			Select("user_roles.user_id").
+			Joins("JOIN role_permissions ON role_permissions.role_id = user_roles.role_id").
+			Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+			Where("permissions.name = ?", filter.Permission))
+	}
+
+	query, page, err := paginate(query, filter.PageRequest, userSortFields)
+	if err != nil {
+		return nil, model.PageInfo{}, err
+	}
+	var users []model.User
+	if err := query.Preload("Roles").Find(&users).Error; err != nil {
+		return nil, model.PageInfo{}, err
+	}
+
+	count, info := page.pageInfo(len(users), func(i int) (interface{}, uint) {
+		switch page.field.column {
+		case "username":
+			return users[i].Username, users[i].ID
+		case "created_at":
+			return users[i].CreatedAt, users[i].ID
+		}
+		return nil, users[i].ID
+	})
+	return users[:count], info, nil
+}
+
+// FindUserByID implements domain.UserRepo.
+func (d *userRepository) FindUserByID(ctx context.Context, userID uint) (model.User, error) {
 	var user model.User
-	var role model.Role
+	if err := findByID(d.db.WithContext(ctx), &user, userID, "user"); err != nil {
+		return model.User{}, err
+	}
+	return user, nil
+}
 
-	if err := d.db.First(&user, userId).Error; err != nil {
-		return err
+// FindUserByIdentifier looks a user up by username or email address.
+func (d *userRepository) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	var user model.User
+	err := whereUsername(d.db.WithContext(ctx), identifier).
+		Or("email <> '' AND email = ?", identifier).
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.User{}, domain.ErrUserNotFound
+		}
+		return model.User{}, err
 	}
-	if err := d.db.First(&role, roleID).Error; err != nil {
+	return user, nil
+}
+
+// UpdatePassword stores the new password, records it in the history and ends
+// all sessions issued before changedAt. Pending reset tokens are discarded.
+func (d *userRepository) UpdatePassword(ctx context.Context, userID uint, newPassword string, changedAt time.Time) error {
+	hashedPassword, err := d.passwordHasher.Hash(newPassword)
+	if err != nil {
 		return err
 	}
-	if err := d.db.Model(&user).Association("Roles").Append(&role); err != nil {
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password":             hashedPassword,
+			"sessions_valid_after": changedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&model.PasswordHistory{UserID: userID, PasswordHash: hashedPassword}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND used_at IS NULL", userID).Delete(&model.PasswordResetToken{}).Error
+	})
+}
+
+// RehashPassword upgrades the stored hash of an unchanged password. The update
+// is skipped if the password changed since currentHash was read.
+func (d *userRepository) RehashPassword(ctx context.Context, userID uint, currentHash string, password string) error {
+	hashedPassword, err := d.passwordHasher.Hash(password)
+	if err != nil {
 		return err
 	}
+	return d.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND password = ?", userID, currentHash).
+		Update("password", hashedPassword).Error
+}
+
+// UpdateStatus moves the user to change.ToStatus and records the change. It
+// fails if the status is no longer change.FromStatus. Leaving the active state
+// ends all sessions, so reactivating the user doesn't revive old tokens.
+func (d *userRepository) UpdateStatus(ctx context.Context, change model.UserStatusChange, changedAt time.Time) error {
+	updates := map[string]interface{}{
+		"status":            change.ToStatus,
+		"status_reason":     change.Reason,
+		"status_changed_at": changedAt,
+	}
+	if change.ToStatus != model.UserStatusActive {
+		updates["sessions_valid_after"] = changedAt
+	}
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND status = ?", change.UserID, change.FromStatus).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.Conflict("%w: the status changed concurrently", model.ErrInvalidStatusTransition)
+		}
+
+		change.CreatedAt = changedAt
+		if err := tx.Create(&change).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, model.EventUserStatusChanged, map[string]interface{}{
+			"user_id":     change.UserID,
+			"from_status": change.FromStatus,
+			"to_status":   change.ToStatus,
+			"reason":      change.Reason,
+		})
+	})
+}
+
+// ListStatusChanges returns the user's status history, oldest first.
+func (d *userRepository) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	var changes []model.UserStatusChange
+	if err := d.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at, id").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// CreatePasswordResetToken stores a reset token, replacing the user's unused ones.
+func (d *userRepository) CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND used_at IS NULL", token.UserID).Delete(&model.PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+}
+
+// FindPasswordResetToken implements domain.UserRepo.
+func (d *userRepository) FindPasswordResetToken(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	if err := d.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.PasswordResetToken{}, domain.ErrInvalidResetToken
+		}
+		return model.PasswordResetToken{}, err
+	}
+	return token, nil
+}
+
+// MarkPasswordResetTokenUsed consumes the token, reporting false when it was
+// already used so concurrent resets can't both succeed.
+func (d *userRepository) MarkPasswordResetTokenUsed(ctx context.Context, tokenID uint, usedAt time.Time) (bool, error) {
+	result := d.db.WithContext(ctx).Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", tokenID).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// AssignRoleToUser implements domain.UserRepo. Assigning a role the user
+// already holds does nothing.
+func (d *userRepository) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		var role model.Role
+		if err := findByID(tx, &user, userID, "user"); err != nil {
+			return err
+		}
+		if err := findByID(tx, &role, roleID, "role"); err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserRole{UserID: user.ID, RoleID: role.ID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return enqueueEvent(tx, model.EventUserRoleAssigned, map[string]interface{}{
+			"user_id":   user.ID,
+			"username":  user.Username,
+			"role_id":   role.ID,
+			"role_name": role.Name,
+		})
+	})
+}
+
+// RevokeRoleFromUser implements domain.UserRepo. Revoking a role the user
+// doesn't hold does nothing.
+func (d *userRepository) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		var role model.Role
+		if err := findByID(tx, &user, userID, "user"); err != nil {
+			return err
+		}
+		if err := findByID(tx, &role, roleID, "role"); err != nil {
+			return err
+		}
 
-	return nil
+		result := tx.Where("user_id = ? AND role_id = ?", user.ID, role.ID).Delete(&model.UserRole{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return enqueueEvent(tx, model.EventUserRoleRevoked, map[string]interface{}{
+			"user_id":   user.ID,
+			"username":  user.Username,
+			"role_id":   role.ID,
+			"role_name": role.Name,
+		})
+	})
 }
 
 // CheckUserPermission implements domain.UserRepo.
-func (d *userRepository) CheckUserPermission(userID string, permissionName string) (bool, error) {
+func (d *userRepository) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
 	var user model.User
 
-	if err := d.db.Preload("Roles.Permissions").First(&user, userID).Error; err != nil {
+	if err := findByID(d.db.WithContext(ctx).Preload("Roles.Permissions"), &user, userID, "user"); err != nil {
 		return false, err
 	}
 
@@ -84,3 +341,35 @@ func (d *userRepository) CheckUserPermission(userID string, permissionName strin
 
 	return hasPermission, nil
 }
+
+// ListUserPermissions implements domain.UserRepo. Permissions granted by
+// several roles are listed once.
+func (d *userRepository) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	var user model.User
+	if err := findByID(d.db.WithContext(ctx), &user, userID, "user"); err != nil {
+		return nil, err
+	}
+
+	var permissions []model.Permission
+	err := d.db.WithContext(ctx).Where("id IN (?)", d.db.Table("role_permissions").
+		Select("role_permissions.permission_id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", user.ID)).
+		Order("name").
+		Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// whereUsername matches the username regardless of case on every database.
+// MySQL's default collation already does, so its index on the column is used
+// as is; the others compare lower-cased usernames, covered by
+// idx_users_username_lower.
+func whereUsername(db *gorm.DB, username string) *gorm.DB {
+	if db.Dialector.Name() == "mysql" {
+		return db.Where("username = ?", username)
+	}
+	return db.Where("LOWER(username) = LOWER(?)", username)
+}
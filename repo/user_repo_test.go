@@ -1,273 +1,292 @@
 package repo
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"go-multirole/domain"
 	"go-multirole/model"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
-type UserRepositoryMock struct {
-	Mock mock.Mock
-}
-
-func (d *UserRepositoryMock) CreateUser(user model.User) (model.User, error) {
-	args := d.Mock.Called(user)
-	return args.Get(0).(model.User), args.Error(1)
-}
-
-func (d *UserRepositoryMock) LoginUser(inputUser model.User) (model.User, error) {
-	args := d.Mock.Called(inputUser)
-	return args.Get(0).(model.User), args.Error(1)
-}
-
-func (d *UserRepositoryMock) CheckUserPermission(userID string, permissionName string) (bool, error) {
-	args := d.Mock.Called(userID, permissionName)
-	return args.Bool(0), args.Error(1)
-}
-
-// AssignRoleToUser mocks the AssignRoleToUser method of the UserRepository
-func (d *UserRepositoryMock) AssignRoleToUser(userId string, roleID string) error {
-	args := d.Mock.Called(userId, roleID)
-	return args.Error(0)
+// seedAccess creates a user holding a role with a permission.
+func seedAccess(t *testing.T, conn *gorm.DB, username string, roleName string, permissionName string) (model.User, model.Role) {
+	t.Helper()
+	users := NewUserRepository(conn, testPasswordHasher)
+	roles := NewRoleRepository(conn)
+
+	user, err := users.CreateUser(context.Background(), model.User{Username: username, Password: "password123"})
+	require.NoError(t, err)
+	role, err := roles.CreateRole(context.Background(), model.Role{Name: roleName})
+	require.NoError(t, err)
+	permission, err := NewPermissionRepository(conn).CreatePermission(context.Background(), model.Permission{Name: permissionName})
+	require.NoError(t, err)
+	require.NoError(t, roles.AssignPermissionToRole(context.Background(), role.ID, permission.ID))
+	require.NoError(t, users.AssignRoleToUser(context.Background(), user.ID, role.ID))
+	return user, role
 }
 
 func TestCreateUser_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	testUser := model.User{
-		Username: "testuser",
-		Password: "password123", // The password will be hashed internally in CreateUser
-	}
+	conn := newTestDB(t)
+	repository := NewUserRepository(conn, testPasswordHasher)
 
-	// Mock the behavior: return the test user and no error on CreateUser
-	repoMock.Mock.On("CreateUser", testUser).Return(testUser, nil)
+	createdUser, err := repository.CreateUser(context.Background(), model.User{Username: "testuser", Password: "password123"})
 
-	// Act
-	createdUser, err := repoMock.CreateUser(testUser)
+	assert.NoError(t, err)
+	assert.NotZero(t, createdUser.ID)
+	assert.Equal(t, "testuser", createdUser.Username)
+	assert.True(t, testPasswordHasher.Verify(createdUser.Password, "password123"), "The password is stored hashed")
 
-	// Assert
-	assert.NoError(t, err)                                   // No error should occur
-	assert.Equal(t, testUser.Username, createdUser.Username) // Usernames should match
-	repoMock.Mock.AssertExpectations(t)                      // Check all expectations were met
-}
+	history, err := repository.ListPasswordHistory(context.Background(), createdUser.ID, 5)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1, "The first password starts the history")
 
-func TestCreateUser_Failure(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	testUser := model.User{
-		Username: "testuser",
-		Password: "password123", // The password will be hashed internally in CreateUser
-	}
+	var events int64
+	conn.Model(&model.OutboxEvent{}).Where("type = ?", model.EventUserCreated).Count(&events)
+	assert.Equal(t, int64(1), events)
+}
 
-	// Mock the behavior: simulate a database error on CreateUser
-	repoMock.Mock.On("CreateUser", testUser).Return(testUser, errors.New("database error"))
+func TestCreateUser_DuplicateUsername(t *testing.T) {
+	repository := NewUserRepository(newTestDB(t), testPasswordHasher)
+	_, err := repository.CreateUser(context.Background(), model.User{Username: "testuser", Password: "password123"})
+	require.NoError(t, err)
 
-	// Act
-	createdUser, err := repoMock.CreateUser(testUser)
+	_, err = repository.CreateUser(context.Background(), model.User{Username: "testuser", Password: "password456"})
 
-	// Assert
-	assert.Error(t, err)                                     // Error should occur
-	assert.EqualError(t, err, "database error")              // Error should match expected message
-	assert.Equal(t, testUser.Username, createdUser.Username) // Usernames should match
-	repoMock.Mock.AssertExpectations(t)                      // Check all expectations were met
+	assert.ErrorIs(t, err, domain.ErrConflict)
 }
 
 func TestLoginUser_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	testUser := model.User{
-		Username: "testuser",
-		Password: "hashedPassword123", // Assume the password is already hashed
-	}
+	conn := newTestDB(t)
+	seedAccess(t, conn, "testuser", "admin", "manage_users")
 
-	// Simulate the behavior: return the test user and no error when the username is found
-	repoMock.Mock.On("LoginUser", testUser).Return(testUser, nil)
+	loggedInUser, err := NewUserRepository(conn, testPasswordHasher).LoginUser(context.Background(), model.User{Username: "testuser"})
 
-	// Act
-	returnedUser, err := repoMock.LoginUser(testUser)
-
-	// Assert
-	assert.NoError(t, err)                                    // No error should occur
-	assert.Equal(t, testUser.Username, returnedUser.Username) // Usernames should match
-	repoMock.Mock.AssertExpectations(t)                       // Check all expectations were met
-}
-
-func TestLoginUser_UserNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	testUser := model.User{
-		Username: "testuser",
-		Password: "hashedPassword123", // Assume the password is already hashed
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", loggedInUser.Username)
+	if assert.Len(t, loggedInUser.Roles, 1) {
+		assert.Equal(t, "admin", loggedInUser.Roles[0].Name)
 	}
+}
 
-	// Simulate the behavior: user is not found (gorm.ErrRecordNotFound) and return an error
-	repoMock.Mock.On("LoginUser", testUser).Return(model.User{}, errors.New("user not found"))
+func TestCreateUser_DuplicateUsernameOtherCase(t *testing.T) {
+	repository := NewUserRepository(newTestDB(t), testPasswordHasher)
+	_, err := repository.CreateUser(context.Background(), model.User{Username: "testuser", Password: "password123"})
+	require.NoError(t, err)
 
-	// Act
-	returnedUser, err := repoMock.LoginUser(testUser)
+	_, err = repository.CreateUser(context.Background(), model.User{Username: "TestUser", Password: "password456"})
 
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "user not found") // Error message should be "user not found"
-	assert.Equal(t, model.User{}, returnedUser) // Returned user should be empty
-	repoMock.Mock.AssertExpectations(t)         // Check all expectations were met
+	assert.ErrorIs(t, err, domain.ErrConflict)
 }
 
-func TestLoginUser_DatabaseError(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	testUser := model.User{
-		Username: "testuser",
-		Password: "hashedPassword123", // Assume the password is already hashed
-	}
-
-	// Simulate a database error when querying the user
-	repoMock.Mock.On("LoginUser", testUser).Return(model.User{}, fmt.Errorf("database error"))
+func TestLoginUser_OtherCase(t *testing.T) {
+	conn := newTestDB(t)
+	seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserRepository(conn, testPasswordHasher)
 
-	// Act
-	returnedUser, err := repoMock.LoginUser(testUser)
+	loggedInUser, err := repository.LoginUser(context.Background(), model.User{Username: "TestUser"})
+	assert.NoError(t, err)
+	assert.Equal(t, "testuser", loggedInUser.Username)
 
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "database error") // Error message should be "database error"
-	assert.Equal(t, model.User{}, returnedUser) // Returned user should be empty
-	repoMock.Mock.AssertExpectations(t)         // Check all expectations were met
+	found, err := repository.FindUserByIdentifier(context.Background(), "TESTUSER")
+	assert.NoError(t, err)
+	assert.Equal(t, loggedInUser.ID, found.ID)
 }
 
-func TestAssignRoleToUser_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-
-	// Simulate behavior: the user and role are found and the role is assigned successfully
-	repoMock.Mock.On("AssignRoleToUser", "1", "1").Return(nil) // Expect the method to succeed
+func TestLoginUser_UserNotFound(t *testing.T) {
+	repository := NewUserRepository(newTestDB(t), testPasswordHasher)
 
-	// Act
-	err := repoMock.AssignRoleToUser("1", "1")
+	_, err := repository.LoginUser(context.Background(), model.User{Username: "nonexistentuser"})
 
-	// Assert
-	assert.NoError(t, err)              // No error should occur
-	repoMock.Mock.AssertExpectations(t) // Check that all expectations were met
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
 }
 
-func TestAssignRoleToUser_UserNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-
-	// Simulate behavior: user not found in the database
-	repoMock.Mock.On("AssignRoleToUser", "1", "1").Return(errors.New("user not found"))
+func TestLoginUser_CanceledContext(t *testing.T) {
+	conn := newTestDB(t)
+	seedAccess(t, conn, "testuser", "admin", "manage_users")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	// Act
-	err := repoMock.AssignRoleToUser("1", "1")
+	_, err := NewUserRepository(conn, testPasswordHasher).LoginUser(ctx, model.User{Username: "testuser"})
 
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "user not found") // Error message should be "user not found"
-	repoMock.Mock.AssertExpectations(t)         // Check that all expectations were met
+	assert.ErrorIs(t, err, context.Canceled, "A canceled request doesn't query the database")
 }
 
-func TestAssignRoleToUser_RoleNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
+func TestAssignRoleToUser_NotFound(t *testing.T) {
+	conn := newTestDB(t)
+	repository := NewUserRepository(conn, testPasswordHasher)
+	user, err := repository.CreateUser(context.Background(), model.User{Username: "testuser", Password: "password123"})
+	require.NoError(t, err)
 
-	// Simulate behavior: role not found in the database
-	repoMock.Mock.On("AssignRoleToUser", "1", "1").Return(errors.New("role not found"))
+	err = repository.AssignRoleToUser(context.Background(), user.ID, 99)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.EqualError(t, err, "role not found")
 
-	// Act
-	err := repoMock.AssignRoleToUser("1", "1")
-
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "role not found") // Error message should be "role not found"
-	repoMock.Mock.AssertExpectations(t)         // Check that all expectations were met
+	err = repository.AssignRoleToUser(context.Background(), 99, 1)
+	assert.EqualError(t, err, "user not found")
 }
 
-func TestAssignRoleToUser_DatabaseError(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
+func TestAssignRoleToUser_Twice(t *testing.T) {
+	conn := newTestDB(t)
+	user, role := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserRepository(conn, testPasswordHasher)
 
-	// Simulate behavior: database error while assigning role
-	repoMock.Mock.On("AssignRoleToUser", "1", "1").Return(errors.New("database error"))
+	assert.NoError(t, repository.AssignRoleToUser(context.Background(), user.ID, role.ID), "Assigning a held role is a no-op")
 
-	// Act
-	err := repoMock.AssignRoleToUser("1", "1")
+	loggedInUser, err := repository.LoginUser(context.Background(), model.User{Username: "testuser"})
+	assert.NoError(t, err)
+	assert.Len(t, loggedInUser.Roles, 1)
 
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "database error") // Error message should be "database error"
-	repoMock.Mock.AssertExpectations(t)         // Check that all expectations were met
-}
+	var events int64
+	conn.Model(&model.OutboxEvent{}).Where("type = ?", model.EventUserRoleAssigned).Count(&events)
+	assert.Equal(t, int64(1), events, "Only the seeded assignment records an event")
 
-func TestCheckUserPermission_Success(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	permissionName := "read_permission"
+	roles := NewRoleRepository(conn)
+	auditor, err := roles.CreateRole(context.Background(), model.Role{Name: "auditor"})
+	require.NoError(t, err)
+	require.NoError(t, repository.AssignRoleToUser(context.Background(), user.ID, auditor.ID))
+	conn.Model(&model.OutboxEvent{}).Where("type = ?", model.EventUserRoleAssigned).Count(&events)
+	assert.Equal(t, int64(2), events)
+}
 
-	// Simulate behavior: the user exists and the permission is found
-	repoMock.Mock.On("CheckUserPermission", "1", permissionName).Return(true, nil)
+func TestRevokeRoleFromUser(t *testing.T) {
+	conn := newTestDB(t)
+	user, role := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserRepository(conn, testPasswordHasher)
 
-	// Act
-	hasPermission, err := repoMock.CheckUserPermission("1", permissionName)
+	require.NoError(t, repository.RevokeRoleFromUser(context.Background(), user.ID, role.ID))
 
-	// Assert
-	assert.NoError(t, err)              // No error should occur
-	assert.True(t, hasPermission)       // The user should have the permission
-	repoMock.Mock.AssertExpectations(t) // Check that all expectations were met
-}
+	hasPermission, err := repository.CheckUserPermission(context.Background(), user.ID, "manage_users")
+	assert.NoError(t, err)
+	assert.False(t, hasPermission)
 
-func TestCheckUserPermission_PermissionNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
+	var events int64
+	conn.Model(&model.OutboxEvent{}).Where("type = ?", model.EventUserRoleRevoked).Count(&events)
+	assert.Equal(t, int64(1), events)
 
-	permissionName := "read_permission"
+	assert.NoError(t, repository.RevokeRoleFromUser(context.Background(), user.ID, role.ID), "Revoking a role the user doesn't hold is a no-op")
+	conn.Model(&model.OutboxEvent{}).Where("type = ?", model.EventUserRoleRevoked).Count(&events)
+	assert.Equal(t, int64(1), events, "A no-op revoke records no event")
 
-	// Simulate behavior: the user exists but the permission is not found
-	repoMock.Mock.On("CheckUserPermission", "1", permissionName).Return(false, nil)
+	assert.ErrorIs(t, repository.RevokeRoleFromUser(context.Background(), user.ID, 99), domain.ErrNotFound)
+}
 
-	// Act
-	hasPermission, err := repoMock.CheckUserPermission("1", permissionName)
+func TestListUserPermissions(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	users := NewUserRepository(conn, testPasswordHasher)
+	roles := NewRoleRepository(conn)
+	permissions := NewPermissionRepository(conn)
+
+	// A second role granting the same permission and another one
+	auditor, err := roles.CreateRole(context.Background(), model.Role{Name: "auditor"})
+	require.NoError(t, err)
+	audit, err := permissions.CreatePermission(context.Background(), model.Permission{Name: "audit_logs"})
+	require.NoError(t, err)
+	manageUsers, err := permissions.FindPermissionByName(context.Background(), "manage_users")
+	require.NoError(t, err)
+	require.NoError(t, roles.AssignPermissionToRole(context.Background(), auditor.ID, audit.ID))
+	require.NoError(t, roles.AssignPermissionToRole(context.Background(), auditor.ID, manageUsers.ID))
+	require.NoError(t, users.AssignRoleToUser(context.Background(), user.ID, auditor.ID))
+
+	granted, err := users.ListUserPermissions(context.Background(), user.ID)
+	assert.NoError(t, err)
+	names := make([]string, 0, len(granted))
+	for _, permission := range granted {
+		names = append(names, permission.Name)
+	}
+	assert.Equal(t, []string{"audit_logs", "manage_users"}, names)
 
-	// Assert
-	assert.NoError(t, err)              // No error should occur
-	assert.False(t, hasPermission)      // The user should not have the permission
-	repoMock.Mock.AssertExpectations(t) // Check that all expectations were met
+	_, err = users.ListUserPermissions(context.Background(), 99)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
 }
 
-func TestCheckUserPermission_UserNotFound(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	permissionName := "read_permission"
+func TestCheckUserPermission(t *testing.T) {
+	conn := newTestDB(t)
+	user, _ := seedAccess(t, conn, "testuser", "admin", "manage_users")
+	repository := NewUserRepository(conn, testPasswordHasher)
 
-	// Simulate behavior: user not found in the database
-	repoMock.Mock.On("CheckUserPermission", "1", permissionName).Return(false, errors.New("user not found"))
+	hasPermission, err := repository.CheckUserPermission(context.Background(), user.ID, "manage_users")
+	assert.NoError(t, err)
+	assert.True(t, hasPermission)
 
-	// Act
-	hasPermission, err := repoMock.CheckUserPermission("1", permissionName)
+	hasPermission, err = repository.CheckUserPermission(context.Background(), user.ID, "manage_roles")
+	assert.NoError(t, err)
+	assert.False(t, hasPermission)
 
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "user not found") // Error message should be "user not found"
-	assert.False(t, hasPermission)              // The user should not have the permission
-	repoMock.Mock.AssertExpectations(t)         // Check that all expectations were met
+	_, err = repository.CheckUserPermission(context.Background(), 99, "manage_users")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
 }
 
-func TestCheckUserPermission_DatabaseError(t *testing.T) {
-	// Arrange
-	repoMock := new(UserRepositoryMock)
-	permissionName := "read_permission"
-
-	// Simulate behavior: database error while checking permission
-	repoMock.Mock.On("CheckUserPermission", "1", permissionName).Return(false, errors.New("database error"))
-
-	// Act
-	hasPermission, err := repoMock.CheckUserPermission("1", permissionName)
+func TestListUsers(t *testing.T) {
+	conn := newTestDB(t)
+	seedAccess(t, conn, "admin", "admin", "manage_users")
+	repository := NewUserRepository(conn, testPasswordHasher)
+	for i := 1; i <= 4; i++ {
+		_, err := repository.CreateUser(context.Background(), model.User{Username: fmt.Sprintf("user_%d", i), Password: "password123"})
+		require.NoError(t, err)
+	}
+	_, err := repository.CreateUser(context.Background(), model.User{Username: "userx", Password: "password123"})
+	require.NoError(t, err)
+
+	usernames := func(users []model.User) []string {
+		names := make([]string, 0, len(users))
+		for _, user := range users {
+			names = append(names, user.Username)
+		}
+		return names
+	}
 
-	// Assert
-	assert.Error(t, err)                        // Error should occur
-	assert.EqualError(t, err, "database error") // Error message should be "database error"
-	assert.False(t, hasPermission)              // The user should not have the permission
-	repoMock.Mock.AssertExpectations(t)         // Check that all expectations were met
+	t.Run("Pages follow each other", func(t *testing.T) {
+		filter := model.UserFilter{PageRequest: model.PageRequest{Limit: 2, Sort: "-username"}}
+		var listed []string
+		for pages := 0; pages < 5; pages++ {
+			users, info, err := repository.ListUsers(context.Background(), filter)
+			require.NoError(t, err)
+			listed = append(listed, usernames(users)...)
+			if !info.HasMore {
+				break
+			}
+			filter.Cursor = info.NextCursor
+		}
+		assert.Equal(t, []string{"userx", "user_4", "user_3", "user_2", "user_1", "admin"}, listed)
+	})
+
+	t.Run("Prefix wildcards are literal", func(t *testing.T) {
+		users, info, err := repository.ListUsers(context.Background(), model.UserFilter{UsernamePrefix: "user_"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"user_1", "user_2", "user_3", "user_4"}, usernames(users))
+		assert.False(t, info.HasMore)
+	})
+
+	t.Run("Filter by role and permission", func(t *testing.T) {
+		users, _, err := repository.ListUsers(context.Background(), model.UserFilter{Role: "admin"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, usernames(users))
+
+		users, _, err = repository.ListUsers(context.Background(), model.UserFilter{Permission: "manage_users"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, usernames(users))
+
+		users, _, err = repository.ListUsers(context.Background(), model.UserFilter{Permission: "manage_roles"})
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("Sort by creation time", func(t *testing.T) {
+		filter := model.UserFilter{PageRequest: model.PageRequest{Limit: 4, Sort: "created_at"}}
+		users, info, err := repository.ListUsers(context.Background(), filter)
+		require.NoError(t, err)
+		assert.Len(t, users, 4)
+		assert.True(t, info.HasMore)
+
+		filter.Cursor = info.NextCursor
+		users, info, err = repository.ListUsers(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Len(t, users, 2)
+		assert.False(t, info.HasMore)
+	})
 }
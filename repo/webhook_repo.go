@@ -0,0 +1,179 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) domain.WebhookRepo {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+// enqueueEvent records an outbox event using the caller's transaction.
+func enqueueEvent(tx *gorm.DB, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.OutboxEvent{Type: eventType, Payload: string(payload)}).Error
+}
+
+// CreateWebhook implements domain.WebhookRepo.
+func (w *webhookRepository) CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	if err := w.db.WithContext(ctx).Create(&webhook).Error; err != nil {
+		return webhook, err
+	}
+	return webhook, nil
+}
+
+// ListWebhooks implements domain.WebhookRepo.
+func (w *webhookRepository) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := w.db.WithContext(ctx).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook implements domain.WebhookRepo.
+func (w *webhookRepository) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	var webhook model.Webhook
+	if err := findByID(w.db.WithContext(ctx), &webhook, webhookID, "webhook"); err != nil {
+		return err
+	}
+	return w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&webhook).Error
+	})
+}
+
+// FanOutPendingEvents creates a pending delivery for every active webhook
+// subscribed to each unprocessed outbox event, marking the events processed.
+// Each event is claimed by marking it first, so when instances fan out at the
+// same time only one of them creates its deliveries.
+func (w *webhookRepository) FanOutPendingEvents(ctx context.Context, limit int) (int, error) {
+	processed := 0
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []model.OutboxEvent
+		if err := tx.Where("processed_at IS NULL").Order("id").Limit(limit).Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		var webhooks []model.Webhook
+		if err := tx.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, event := range events {
+			claim := tx.Model(&model.OutboxEvent{}).Where("id = ? AND processed_at IS NULL", event.ID).Update("processed_at", now)
+			if claim.Error != nil {
+				return claim.Error
+			}
+			if claim.RowsAffected == 0 {
+				continue // Fanned out by another instance
+			}
+
+			for _, webhook := range webhooks {
+				if !webhook.Subscribes(event.Type) {
+					continue
+				}
+				delivery := model.WebhookDelivery{
+					WebhookID:     webhook.ID,
+					EventID:       event.ID,
+					EventType:     event.Type,
+					Status:        model.DeliveryPending,
+					NextAttemptAt: now,
+				}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery).Error; err != nil {
+					return err
+				}
+			}
+			processed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return processed, nil
+}
+
+// ClaimDueDeliveries returns the pending deliveries due at now, claimed by
+// moving their next attempt to leaseUntil. A delivery another instance claims
+// first is left out, so every due delivery is sent by one instance only.
+func (w *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	db := w.db.WithContext(ctx)
+	var due []uint
+	err := db.Model(&model.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at").Limit(limit).Pluck("id", &due).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var claimed []uint
+	for _, id := range due {
+		result := db.Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", id, model.DeliveryPending, now).
+			Update("next_attempt_at", leaseUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, id)
+		}
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []model.WebhookDelivery
+	if err := db.Preload("Webhook").Preload("Event").Where("id IN ?", claimed).Order("id").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery implements domain.WebhookRepo.
+func (w *webhookRepository) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	return w.db.WithContext(ctx).Model(&model.WebhookDelivery{ID: delivery.ID}).Updates(map[string]interface{}{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+	}).Error
+}
+
+// ListDeliveries implements domain.WebhookRepo.
+func (w *webhookRepository) ListDeliveries(ctx context.Context, webhookID uint) ([]model.WebhookDelivery, error) {
+	var webhook model.Webhook
+	if err := findByID(w.db.WithContext(ctx), &webhook, webhookID, "webhook"); err != nil {
+		return nil, err
+	}
+
+	var deliveries []model.WebhookDelivery
+	if err := w.db.WithContext(ctx).Where("webhook_id = ?", webhook.ID).Order("id desc").Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
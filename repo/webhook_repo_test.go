@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedDelivery creates a webhook for every event and fans out one event to it.
+func seedDelivery(t *testing.T, conn *gorm.DB, repository domain.WebhookRepo) model.WebhookDelivery {
+	t.Helper()
+	webhook, err := repository.CreateWebhook(context.Background(), model.Webhook{URL: "https://hooks.example.com", Secret: "secret", Active: true})
+	require.NoError(t, err)
+	require.NoError(t, enqueueEvent(conn, model.EventUserCreated, map[string]interface{}{"user_id": 1}))
+
+	processed, err := repository.FanOutPendingEvents(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, processed)
+
+	deliveries, err := repository.ListDeliveries(context.Background(), webhook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	return deliveries[0]
+}
+
+func TestFanOutPendingEvents(t *testing.T) {
+	conn := newTestDB(t)
+	repository := NewWebhookRepository(conn)
+	delivery := seedDelivery(t, conn, repository)
+
+	processed, err := repository.FanOutPendingEvents(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Zero(t, processed, "A processed event isn't fanned out again")
+
+	duplicate := model.WebhookDelivery{WebhookID: delivery.WebhookID, EventID: delivery.EventID, Status: model.DeliveryPending}
+	assert.ErrorIs(t, conn.Create(&duplicate).Error, gorm.ErrDuplicatedKey, "An event is delivered to a webhook once")
+}
+
+func TestClaimDueDeliveries(t *testing.T) {
+	conn := newTestDB(t)
+	repository := NewWebhookRepository(conn)
+	delivery := seedDelivery(t, conn, repository)
+	now := time.Now()
+	leaseUntil := now.Add(time.Minute)
+
+	claimed, err := repository.ClaimDueDeliveries(context.Background(), now, leaseUntil, 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, delivery.ID, claimed[0].ID)
+	assert.Equal(t, model.EventUserCreated, claimed[0].Event.Type, "The event is loaded to be sent")
+	assert.Equal(t, "https://hooks.example.com", claimed[0].Webhook.URL)
+
+	// Another instance polling at the same time gets nothing
+	claimed, err = repository.ClaimDueDeliveries(context.Background(), now, leaseUntil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// A delivery whose outcome was never recorded is claimed again after the lease
+	claimed, err = repository.ClaimDueDeliveries(context.Background(), leaseUntil, leaseUntil.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+}
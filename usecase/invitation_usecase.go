@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"time"
+)
+
+var (
+	errInvitationClosed  = domain.Conflict("invitation was already accepted or revoked")
+	errUsernameTaken     = domain.Conflict("username is already taken")
+	errEmailAlreadyInUse = domain.Conflict("email is already in use")
+)
+
+type invitationUseCase struct {
+	invitationRepo domain.InvitationRepo
+	userRepo       domain.UserRepo
+	userUseCase    domain.UserUseCase
+	notifier       domain.Notifier
+	ttl            time.Duration
+	acceptURL      string
+}
+
+// NewInvitationUseCase creates the invitation use case. Invitation tokens are
+// sent through the notifier and expire after ttl; acceptURL is the page the
+// token is appended to, the bare token is sent when it is empty. Passwords
+// chosen by invitees are checked against the user use case's policy.
+func NewInvitationUseCase(invitationRepo domain.InvitationRepo, userRepo domain.UserRepo, userUseCase domain.UserUseCase, notifier domain.Notifier, ttl time.Duration, acceptURL string) domain.InvitationUseCase {
+	return &invitationUseCase{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		userUseCase:    userUseCase,
+		notifier:       notifier,
+		ttl:            ttl,
+		acceptURL:      acceptURL,
+	}
+}
+
+// Invite creates a pending user and sends them a single-use invitation. The
+// roles are only assigned once the invitation is accepted.
+func (i *invitationUseCase) Invite(ctx context.Context, request model.InvitationRequest, inviterID uint) (model.Invitation, error) {
+	if _, err := i.userRepo.FindUserByIdentifier(ctx, request.Username); !errors.Is(err, domain.ErrUserNotFound) {
+		if err != nil {
+			return model.Invitation{}, err
+		}
+		return model.Invitation{}, errUsernameTaken
+	}
+	if _, err := i.userRepo.FindUserByIdentifier(ctx, request.Email); !errors.Is(err, domain.ErrUserNotFound) {
+		if err != nil {
+			return model.Invitation{}, err
+		}
+		return model.Invitation{}, errEmailAlreadyInUse
+	}
+
+	token, err := utils.GenerateRandomHex(32)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	invitation := model.Invitation{
+		Username:  request.Username,
+		Email:     request.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(i.ttl),
+	}
+	if inviterID != 0 {
+		invitation.InvitedBy = &inviterID
+	}
+
+	invitation, err = i.invitationRepo.CreateInvitation(ctx, invitation, request.RoleIDs)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	invitation.Status = model.InvitationPending
+
+	return invitation, i.sendInvitation(ctx, invitation, token)
+}
+
+// ListInvitations implements domain.InvitationUseCase.
+func (i *invitationUseCase) ListInvitations(ctx context.Context) ([]model.Invitation, error) {
+	invitations, err := i.invitationRepo.ListInvitations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for index := range invitations {
+		invitations[index].Status = invitations[index].StatusAt(now)
+	}
+	return invitations, nil
+}
+
+// Resend issues a new token for an open invitation, including an expired one,
+// and restarts its expiry. The previous token stops working.
+func (i *invitationUseCase) Resend(ctx context.Context, invitationID uint) (model.Invitation, error) {
+	invitation, err := i.invitationRepo.FindInvitation(ctx, invitationID)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+
+	token, err := utils.GenerateRandomHex(32)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	invitation.TokenHash = utils.HashToken(token)
+	invitation.ExpiresAt = time.Now().Add(i.ttl)
+
+	renewed, err := i.invitationRepo.RenewInvitation(ctx, invitation.ID, invitation.TokenHash, invitation.ExpiresAt)
+	if err != nil {
+		return model.Invitation{}, err
+	}
+	if !renewed {
+		return model.Invitation{}, errInvitationClosed
+	}
+	invitation.Status = model.InvitationPending
+
+	return invitation, i.sendInvitation(ctx, invitation, token)
+}
+
+// Revoke closes an open invitation and disables the pending user.
+func (i *invitationUseCase) Revoke(ctx context.Context, invitationID uint) error {
+	invitation, err := i.invitationRepo.FindInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := i.invitationRepo.RevokeInvitation(ctx, invitation.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errInvitationClosed
+	}
+	return nil
+}
+
+// Accept sets the invitee's password, activates the account and assigns the
+// invited roles. Every unusable token returns domain.ErrInvalidInvitation.
+func (i *invitationUseCase) Accept(ctx context.Context, request model.AcceptInvitationRequest) error {
+	invitation, err := i.invitationRepo.FindInvitationByToken(ctx, utils.HashToken(request.Token))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if invitation.StatusAt(now) != model.InvitationPending {
+		return domain.ErrInvalidInvitation
+	}
+
+	if err := i.userUseCase.ValidatePassword(ctx, model.User{Username: invitation.Username}, request.Password); err != nil {
+		return err
+	}
+
+	accepted, err := i.invitationRepo.AcceptInvitation(ctx, invitation, request.Password, now)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return domain.ErrInvalidInvitation
+	}
+	return nil
+}
+
+func (i *invitationUseCase) sendInvitation(ctx context.Context, invitation model.Invitation, token string) error {
+	return i.notifier.Notify(ctx, invitationNotification(invitation, i.acceptURL, token))
+}
+
+// invitationNotification is the message sending the invitation token to the
+// invitee. acceptURL is the page the token is appended to, if any.
+func invitationNotification(invitation model.Invitation, acceptURL string, token string) model.Notification {
+	link := "the invitation token " + token
+	if acceptURL != "" {
+		link = tokenLink(acceptURL, token)
+	}
+	return model.Notification{
+		To:      invitation.Email,
+		Subject: "You have been invited",
+		Body: fmt.Sprintf("You have been invited to sign in as %s.\n\nUse %s before %s to choose your password.",
+			invitation.Username, link, invitation.ExpiresAt.UTC().Format(time.RFC1123)),
+	}
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInvitationRepo struct {
+	mock.Mock
+}
+
+func (m *MockInvitationRepo) CreateInvitation(ctx context.Context, invitation model.Invitation, roleIDs []uint) (model.Invitation, error) {
+	args := m.Called(invitation, roleIDs)
+	return args.Get(0).(model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) ListInvitations(ctx context.Context) ([]model.Invitation, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) FindInvitation(ctx context.Context, invitationID uint) (model.Invitation, error) {
+	args := m.Called(invitationID)
+	return args.Get(0).(model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) FindInvitationByToken(ctx context.Context, tokenHash string) (model.Invitation, error) {
+	args := m.Called(tokenHash)
+	return args.Get(0).(model.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepo) RenewInvitation(ctx context.Context, invitationID uint, tokenHash string, expiresAt time.Time) (bool, error) {
+	args := m.Called(invitationID, tokenHash, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInvitationRepo) RevokeInvitation(ctx context.Context, invitationID uint, revokedAt time.Time) (bool, error) {
+	args := m.Called(invitationID, revokedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInvitationRepo) AcceptInvitation(ctx context.Context, invitation model.Invitation, password string, acceptedAt time.Time) (bool, error) {
+	args := m.Called(invitation, password, acceptedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestInvite(t *testing.T) {
+	request := model.InvitationRequest{Username: "jane_doe", Email: "jane@example.com", RoleIDs: []uint{2}}
+
+	t.Run("Create a pending user and send the token", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		userRepo := new(MockUserRepo)
+		notifier := new(MockNotifier)
+		userRepo.On("FindUserByIdentifier", "jane_doe").Return(model.User{}, domain.ErrUserNotFound).Once()
+		userRepo.On("FindUserByIdentifier", "jane@example.com").Return(model.User{}, domain.ErrUserNotFound).Once()
+
+		var stored model.Invitation
+		invitationRepo.On("CreateInvitation", mock.AnythingOfType("model.Invitation"), []uint{2}).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(model.Invitation) }).
+			Return(model.Invitation{ID: 4, UserID: 9, Username: "jane_doe", Email: "jane@example.com", ExpiresAt: time.Now().Add(72 * time.Hour)}, nil).Once()
+		var sent model.Notification
+		notifier.On("Notify", mock.AnythingOfType("model.Notification")).
+			Run(func(args mock.Arguments) { sent = args.Get(0).(model.Notification) }).
+			Return(nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, userRepo, new(MockUserUseCase), notifier, 72*time.Hour, "https://app.example.com/accept")
+		invitation, err := useCase.Invite(context.Background(), request, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.InvitationPending, invitation.Status)
+		assert.Equal(t, uint(1), *stored.InvitedBy)
+		assert.WithinDuration(t, time.Now().Add(72*time.Hour), stored.ExpiresAt, time.Minute)
+		assert.Equal(t, "jane@example.com", sent.To)
+
+		// The link carries the token whose hash was stored
+		start := strings.Index(sent.Body, "token=") + len("token=")
+		assert.Equal(t, stored.TokenHash, utils.HashToken(sent.Body[start:start+64]))
+	})
+
+	t.Run("Reject a taken username", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		userRepo := new(MockUserRepo)
+		userRepo.On("FindUserByIdentifier", "jane_doe").Return(model.User{ID: 3, Username: "jane_doe"}, nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, userRepo, new(MockUserUseCase), new(MockNotifier), 72*time.Hour, "")
+		_, err := useCase.Invite(context.Background(), request, 1)
+
+		assert.EqualError(t, err, "username is already taken")
+		invitationRepo.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResendAndRevokeInvitation(t *testing.T) {
+	t.Run("Resend with a fresh token", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		notifier := new(MockNotifier)
+		invitationRepo.On("FindInvitation", uint(4)).Return(model.Invitation{ID: 4, Username: "jane_doe", Email: "jane@example.com", TokenHash: "old"}, nil).Once()
+		invitationRepo.On("RenewInvitation", uint(4), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		notifier.On("Notify", mock.AnythingOfType("model.Notification")).Return(nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, new(MockUserRepo), new(MockUserUseCase), notifier, 72*time.Hour, "")
+		invitation, err := useCase.Resend(context.Background(), 4)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "old", invitation.TokenHash)
+		notifier.AssertExpectations(t)
+	})
+
+	t.Run("Closed invitations can't be resent", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		notifier := new(MockNotifier)
+		invitationRepo.On("FindInvitation", uint(4)).Return(model.Invitation{ID: 4}, nil).Once()
+		invitationRepo.On("RenewInvitation", uint(4), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, new(MockUserRepo), new(MockUserUseCase), notifier, 72*time.Hour, "")
+		_, err := useCase.Resend(context.Background(), 4)
+
+		assert.EqualError(t, err, "invitation was already accepted or revoked")
+		notifier.AssertNotCalled(t, "Notify", mock.Anything)
+	})
+
+	t.Run("Revoke an open invitation", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		invitationRepo.On("FindInvitation", uint(4)).Return(model.Invitation{ID: 4}, nil).Once()
+		invitationRepo.On("RevokeInvitation", uint(4), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, new(MockUserRepo), new(MockUserUseCase), new(MockNotifier), 72*time.Hour, "")
+
+		assert.NoError(t, useCase.Revoke(context.Background(), 4))
+		invitationRepo.AssertExpectations(t)
+	})
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	tokenHash := utils.HashToken("invite-token")
+	open := model.Invitation{ID: 4, UserID: 9, Username: "jane_doe", Roles: []model.Role{{ID: 2, Name: "User"}}, ExpiresAt: time.Now().Add(time.Hour)}
+	request := model.AcceptInvitationRequest{Token: "invite-token", Password: "Chosen-Password-1"}
+
+	t.Run("Activate the user with the chosen password", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		userUseCase := new(MockUserUseCase)
+		invitationRepo.On("FindInvitationByToken", tokenHash).Return(open, nil).Once()
+		userUseCase.On("ValidatePassword", model.User{Username: "jane_doe"}, "Chosen-Password-1").Return(nil).Once()
+		invitationRepo.On("AcceptInvitation", open, "Chosen-Password-1", mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, new(MockUserRepo), userUseCase, new(MockNotifier), 72*time.Hour, "")
+
+		assert.NoError(t, useCase.Accept(context.Background(), request))
+		invitationRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reject expired invitations", func(t *testing.T) {
+		expired := open
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		invitationRepo := new(MockInvitationRepo)
+		invitationRepo.On("FindInvitationByToken", tokenHash).Return(expired, nil).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, new(MockUserRepo), new(MockUserUseCase), new(MockNotifier), 72*time.Hour, "")
+
+		assert.ErrorIs(t, useCase.Accept(context.Background(), request), domain.ErrInvalidInvitation)
+		invitationRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("The password must meet the policy", func(t *testing.T) {
+		invitationRepo := new(MockInvitationRepo)
+		userUseCase := new(MockUserUseCase)
+		invitationRepo.On("FindInvitationByToken", tokenHash).Return(open, nil).Once()
+		userUseCase.On("ValidatePassword", model.User{Username: "jane_doe"}, "Chosen-Password-1").
+			Return(&model.PasswordPolicyError{Violations: []model.PolicyViolation{{Rule: "symbol", Message: "password must contain a symbol"}}}).Once()
+
+		useCase := NewInvitationUseCase(invitationRepo, new(MockUserRepo), userUseCase, new(MockNotifier), 72*time.Hour, "")
+
+		var policyErr *model.PasswordPolicyError
+		assert.ErrorAs(t, useCase.Accept(context.Background(), request), &policyErr)
+		invitationRepo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
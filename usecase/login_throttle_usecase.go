@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxDelayShift caps the exponent of the progressive delay to avoid overflow.
+const maxDelayShift = 16
+
+type loginThrottleUseCase struct {
+	throttleRepo domain.LoginThrottleRepo
+	limits       atomic.Pointer[loginLimits]
+}
+
+type loginLimits struct {
+	maxAttempts     int
+	ipMaxAttempts   int
+	lockoutDuration time.Duration
+	delay           time.Duration
+}
+
+// NewLoginThrottleUseCase creates the login throttle. An account is locked for
+// lockoutDuration after maxAttempts failures, and a client IP after
+// ipMaxAttempts failures across all accounts. Before that, every failure of an
+// account after the first doubles the wait before the next try, starting at
+// delay. Failures older than lockoutDuration are forgotten.
+func NewLoginThrottleUseCase(throttleRepo domain.LoginThrottleRepo, maxAttempts int, ipMaxAttempts int, lockoutDuration time.Duration, delay time.Duration) domain.LoginThrottleUseCase {
+	l := &loginThrottleUseCase{throttleRepo: throttleRepo}
+	l.SetLimits(maxAttempts, ipMaxAttempts, lockoutDuration, delay)
+	return l
+}
+
+// SetLimits swaps the limits while the throttle is in use. Failures already
+// recorded are judged by the new limits.
+func (l *loginThrottleUseCase) SetLimits(maxAttempts int, ipMaxAttempts int, lockoutDuration time.Duration, delay time.Duration) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if ipMaxAttempts < 1 {
+		ipMaxAttempts = 1
+	}
+	l.limits.Store(&loginLimits{
+		maxAttempts:     maxAttempts,
+		ipMaxAttempts:   ipMaxAttempts,
+		lockoutDuration: lockoutDuration,
+		delay:           delay,
+	})
+}
+
+// Check returns a *model.LoginThrottledError when the account or IP has to
+// wait. It must run before the credentials are verified.
+func (l *loginThrottleUseCase) Check(ctx context.Context, username string, clientIP string) error {
+	userKey := userThrottleKey(username)
+	throttles, err := l.throttleRepo.FindThrottles(ctx, []string{userKey, ipThrottleKey(clientIP)})
+	if err != nil {
+		return err
+	}
+
+	limits := l.limits.Load()
+	now := time.Now()
+	var wait time.Duration
+	for _, throttle := range throttles {
+		retryAfter := limits.retryAfter(throttle, now, throttle.Key == userKey)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+	}
+	if wait > 0 {
+		return &model.LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordFailure implements domain.LoginThrottleUseCase.
+func (l *loginThrottleUseCase) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	limits := l.limits.Load()
+	now := time.Now()
+	windowStart := now.Add(-limits.lockoutDuration)
+	lockUntil := now.Add(limits.lockoutDuration)
+
+	if _, err := l.throttleRepo.RecordFailure(ctx, userThrottleKey(username), now, windowStart, limits.maxAttempts, lockUntil); err != nil {
+		return err
+	}
+	if clientIP == "" {
+		return nil
+	}
+	_, err := l.throttleRepo.RecordFailure(ctx, ipThrottleKey(clientIP), now, windowStart, limits.ipMaxAttempts, lockUntil)
+	return err
+}
+
+// RecordSuccess clears the account's failures. IP failures are kept so an
+// attacker can't reset them by logging in to their own account.
+func (l *loginThrottleUseCase) RecordSuccess(ctx context.Context, username string) error {
+	return l.throttleRepo.DeleteThrottle(ctx, userThrottleKey(username))
+}
+
+// LimitPasswordReset counts a password reset request and returns a
+// *model.LoginThrottledError once the identifier made maxAttempts requests or
+// the client IP ipMaxAttempts requests within lockoutDuration. Requests are
+// counted apart from login failures, so they can't lock anyone out of logging
+// in.
+func (l *loginThrottleUseCase) LimitPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	userKey := model.ThrottleKeyResetPrefix + userThrottleKey(identifier)
+	ipKey := model.ThrottleKeyResetPrefix + ipThrottleKey(clientIP)
+	throttles, err := l.throttleRepo.FindThrottles(ctx, []string{userKey, ipKey})
+	if err != nil {
+		return err
+	}
+
+	limits := l.limits.Load()
+	now := time.Now()
+	var wait time.Duration
+	for _, throttle := range throttles {
+		if retryAfter := limits.retryAfter(throttle, now, false); retryAfter > wait {
+			wait = retryAfter
+		}
+	}
+	if wait > 0 {
+		return &model.LoginThrottledError{RetryAfter: wait}
+	}
+
+	windowStart := now.Add(-limits.lockoutDuration)
+	lockUntil := now.Add(limits.lockoutDuration)
+	if _, err := l.throttleRepo.RecordFailure(ctx, userKey, now, windowStart, limits.maxAttempts, lockUntil); err != nil {
+		return err
+	}
+	_, err = l.throttleRepo.RecordFailure(ctx, ipKey, now, windowStart, limits.ipMaxAttempts, lockUntil)
+	return err
+}
+
+// ListLocked implements domain.LoginThrottleUseCase.
+func (l *loginThrottleUseCase) ListLocked(ctx context.Context) ([]model.LoginThrottle, error) {
+	return l.throttleRepo.ListLockedThrottles(ctx, time.Now())
+}
+
+// UnlockUser implements domain.LoginThrottleUseCase.
+func (l *loginThrottleUseCase) UnlockUser(ctx context.Context, username string) error {
+	return l.throttleRepo.DeleteThrottle(ctx, userThrottleKey(username))
+}
+
+// UnlockIP implements domain.LoginThrottleUseCase.
+func (l *loginThrottleUseCase) UnlockIP(ctx context.Context, clientIP string) error {
+	return l.throttleRepo.DeleteThrottle(ctx, ipThrottleKey(clientIP))
+}
+
+// PurgeStale removes keys whose failures have expired.
+func (l *loginThrottleUseCase) PurgeStale(ctx context.Context) error {
+	return l.throttleRepo.DeleteStaleThrottles(ctx, time.Now().Add(-l.limits.Load().lockoutDuration))
+}
+
+// retryAfter returns how long the key must wait. Progressive delays only
+// apply to accounts; IPs are only locked out, so users behind a shared address
+// aren't slowed down by each other's typos.
+func (l *loginLimits) retryAfter(throttle model.LoginThrottle, now time.Time, progressive bool) time.Duration {
+	if throttle.Locked(now) {
+		return throttle.LockedUntil.Sub(now)
+	}
+	if !progressive || throttle.LastFailedAt == nil || throttle.FailedAttempts < 2 {
+		return 0
+	}
+	if throttle.LastFailedAt.Before(now.Add(-l.lockoutDuration)) {
+		return 0
+	}
+
+	shift := throttle.FailedAttempts - 2
+	if shift > maxDelayShift {
+		shift = maxDelayShift
+	}
+	delay := l.delay << shift
+	if delay > l.lockoutDuration {
+		delay = l.lockoutDuration
+	}
+	return throttle.LastFailedAt.Add(delay).Sub(now)
+}
+
+// Usernames are unique and matched regardless of case, so are the keys.
+func userThrottleKey(username string) string {
+	return model.ThrottleKeyUserPrefix + strings.ToLower(strings.TrimSpace(username))
+}
+
+func ipThrottleKey(clientIP string) string {
+	return model.ThrottleKeyIPPrefix + clientIP
+}
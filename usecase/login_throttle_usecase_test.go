@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"go-multirole/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock the LoginThrottleRepo interface
+type MockLoginThrottleRepo struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleRepo) FindThrottles(ctx context.Context, keys []string) ([]model.LoginThrottle, error) {
+	args := m.Called(keys)
+	return args.Get(0).([]model.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepo) RecordFailure(ctx context.Context, key string, now time.Time, windowStart time.Time, lockAfter int, lockUntil time.Time) (model.LoginThrottle, error) {
+	args := m.Called(key, now, windowStart, lockAfter, lockUntil)
+	return args.Get(0).(model.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepo) DeleteThrottle(ctx context.Context, key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepo) ListLockedThrottles(ctx context.Context, now time.Time) ([]model.LoginThrottle, error) {
+	args := m.Called(now)
+	return args.Get(0).([]model.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepo) DeleteStaleThrottles(ctx context.Context, before time.Time) error {
+	args := m.Called(before)
+	return args.Error(0)
+}
+
+// Mock the LoginThrottleUseCase interface
+type MockLoginThrottleUseCase struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleUseCase) Check(ctx context.Context, username string, clientIP string) error {
+	args := m.Called(username, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	args := m.Called(username, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) RecordSuccess(ctx context.Context, username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) LimitPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	args := m.Called(identifier, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) ListLocked(ctx context.Context) ([]model.LoginThrottle, error) {
+	args := m.Called()
+	return args.Get(0).([]model.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleUseCase) UnlockUser(ctx context.Context, username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) UnlockIP(ctx context.Context, clientIP string) error {
+	args := m.Called(clientIP)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) PurgeStale(ctx context.Context) error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleUseCase) SetLimits(maxAttempts int, ipMaxAttempts int, lockoutDuration time.Duration, delay time.Duration) {
+	m.Called(maxAttempts, ipMaxAttempts, lockoutDuration, delay)
+}
+
+func TestLoginThrottleCheck(t *testing.T) {
+	keys := []string{"user:john_doe", "ip:203.0.113.9"}
+
+	t.Run("Allows keys without failures", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{}, nil)
+
+		assert.NoError(t, useCase.Check(context.Background(), "John_Doe", "203.0.113.9"))
+	})
+
+	t.Run("Delays an account progressively", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		lastFailedAt := time.Now()
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{
+			{Key: "user:john_doe", FailedAttempts: 4, LastFailedAt: &lastFailedAt},
+		}, nil)
+
+		err := useCase.Check(context.Background(), "john_doe", "203.0.113.9")
+
+		// The fourth failure doubles the one second delay twice
+		var throttled *model.LoginThrottledError
+		assert.ErrorAs(t, err, &throttled)
+		assert.Equal(t, 4, throttled.RetryAfterSeconds())
+	})
+
+	t.Run("Doesn't delay an IP below its lockout threshold", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		lastFailedAt := time.Now()
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{
+			{Key: "ip:203.0.113.9", FailedAttempts: 20, LastFailedAt: &lastFailedAt},
+		}, nil)
+
+		assert.NoError(t, useCase.Check(context.Background(), "john_doe", "203.0.113.9"))
+	})
+
+	t.Run("Rejects a locked IP", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{
+			{Key: "ip:203.0.113.9", FailedAttempts: 50, LockedUntil: &lockedUntil},
+		}, nil)
+
+		err := useCase.Check(context.Background(), "john_doe", "203.0.113.9")
+
+		var throttled *model.LoginThrottledError
+		assert.ErrorAs(t, err, &throttled)
+		assert.InDelta(t, 600, throttled.RetryAfterSeconds(), 1)
+	})
+
+	t.Run("Forgets failures outside the window", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		lastFailedAt := time.Now().Add(-time.Hour)
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{
+			{Key: "user:john_doe", FailedAttempts: 4, LastFailedAt: &lastFailedAt},
+		}, nil)
+
+		assert.NoError(t, useCase.Check(context.Background(), "john_doe", "203.0.113.9"))
+	})
+}
+
+func TestLoginThrottleRecordFailure(t *testing.T) {
+	mockRepo := new(MockLoginThrottleRepo)
+	useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+
+	mockRepo.On("RecordFailure", "user:john_doe", mock.Anything, mock.Anything, 5, mock.Anything).Return(model.LoginThrottle{}, nil).Once()
+	mockRepo.On("RecordFailure", "ip:203.0.113.9", mock.Anything, mock.Anything, 50, mock.Anything).Return(model.LoginThrottle{}, nil).Once()
+
+	assert.NoError(t, useCase.RecordFailure(context.Background(), "John_Doe", "203.0.113.9"))
+	mockRepo.AssertExpectations(t)
+
+	// The lock lasts as long as the window in which failures are counted
+	call := mockRepo.Calls[0]
+	now, windowStart, lockUntil := call.Arguments.Get(1).(time.Time), call.Arguments.Get(2).(time.Time), call.Arguments.Get(4).(time.Time)
+	assert.Equal(t, 15*time.Minute, now.Sub(windowStart))
+	assert.Equal(t, 15*time.Minute, lockUntil.Sub(now))
+}
+
+func TestLoginThrottleSetLimits(t *testing.T) {
+	mockRepo := new(MockLoginThrottleRepo)
+	useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+	useCase.SetLimits(3, 0, 5*time.Minute, time.Second)
+
+	// The new limits apply to the next failure, with the same lower bounds
+	mockRepo.On("RecordFailure", "user:john_doe", mock.Anything, mock.Anything, 3, mock.Anything).Return(model.LoginThrottle{}, nil).Once()
+	mockRepo.On("RecordFailure", "ip:203.0.113.9", mock.Anything, mock.Anything, 1, mock.Anything).Return(model.LoginThrottle{}, nil).Once()
+
+	assert.NoError(t, useCase.RecordFailure(context.Background(), "john_doe", "203.0.113.9"))
+	mockRepo.AssertExpectations(t)
+
+	call := mockRepo.Calls[0]
+	now, lockUntil := call.Arguments.Get(1).(time.Time), call.Arguments.Get(4).(time.Time)
+	assert.Equal(t, 5*time.Minute, lockUntil.Sub(now))
+}
+
+func TestLoginThrottleRecordSuccessKeepsIPFailures(t *testing.T) {
+	mockRepo := new(MockLoginThrottleRepo)
+	useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+
+	mockRepo.On("DeleteThrottle", "user:john_doe").Return(nil).Once()
+
+	assert.NoError(t, useCase.RecordSuccess(context.Background(), "john_doe"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLoginThrottleLimitPasswordReset(t *testing.T) {
+	keys := []string{"reset:user:john_doe", "reset:ip:203.0.113.9"}
+
+	t.Run("Counts requests apart from login failures", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		// Earlier requests don't delay the next one like login failures do
+		lastFailedAt := time.Now()
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{{Key: "reset:user:john_doe", FailedAttempts: 4, LastFailedAt: &lastFailedAt}}, nil).Once()
+		mockRepo.On("RecordFailure", "reset:user:john_doe", mock.Anything, mock.Anything, 5, mock.Anything).Return(model.LoginThrottle{}, nil).Once()
+		mockRepo.On("RecordFailure", "reset:ip:203.0.113.9", mock.Anything, mock.Anything, 50, mock.Anything).Return(model.LoginThrottle{}, nil).Once()
+
+		assert.NoError(t, useCase.LimitPasswordReset(context.Background(), "John_Doe", "203.0.113.9"))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses locked keys without counting", func(t *testing.T) {
+		mockRepo := new(MockLoginThrottleRepo)
+		useCase := NewLoginThrottleUseCase(mockRepo, 5, 50, 15*time.Minute, time.Second)
+		lockedUntil := time.Now().Add(10 * time.Minute)
+		mockRepo.On("FindThrottles", keys).Return([]model.LoginThrottle{{Key: "reset:ip:203.0.113.9", LockedUntil: &lockedUntil}}, nil).Once()
+
+		err := useCase.LimitPasswordReset(context.Background(), "john_doe", "203.0.113.9")
+
+		var throttled *model.LoginThrottledError
+		assert.ErrorAs(t, err, &throttled)
+		assert.InDelta(t, 10*time.Minute, throttled.RetryAfter, float64(time.Second))
+		mockRepo.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"strings"
+	"time"
+)
+
+const (
+	// mfaTokenTTL bounds how long an intermediate login token stays valid.
+	mfaTokenTTL       = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	errInvalidMFACode          = domain.Validation("invalid verification code")
+	errInvalidMFAToken         = domain.Unauthorized("invalid or expired mfa token")
+	errMFANotEnabled           = domain.Unauthorized("mfa is not enabled")
+	errMFAEnrollmentIncomplete = domain.Conflict("mfa enrollment has not been started")
+	errMFAAlreadyEnabled       = domain.Conflict("mfa is already enabled")
+	errMFARequiredByRole       = domain.Forbidden("mfa is required by one of your roles")
+	errMFAEnrollmentRequired   = domain.Forbidden("mfa enrollment is required by one of your roles")
+)
+
+type mfaUseCase struct {
+	mfaRepo       domain.MFARepo
+	loginThrottle domain.LoginThrottleUseCase
+	issuer        string
+	tokens        *utils.TokenService
+}
+
+// NewMFAUseCase creates the TOTP use case. The issuer is the account name
+// shown in authenticator apps; tokens signs the access token issued once the
+// second factor is verified. Failed codes count towards the same login
+// throttle as failed passwords.
+func NewMFAUseCase(mfaRepo domain.MFARepo, loginThrottle domain.LoginThrottleUseCase, issuer string, tokens *utils.TokenService) domain.MFAUseCase {
+	return &mfaUseCase{
+		mfaRepo:       mfaRepo,
+		loginThrottle: loginThrottle,
+		issuer:        issuer,
+		tokens:        tokens,
+	}
+}
+
+// BeginEnrollment generates a new TOTP secret. MFA stays disabled until the
+// user proves the authenticator works with ConfirmEnrollment.
+func (m *mfaUseCase) BeginEnrollment(ctx context.Context, userID uint) (model.MFAEnrollment, error) {
+	user, err := m.mfaRepo.FindUser(ctx, userID)
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+	if user.MFAEnabled {
+		return model.MFAEnrollment{}, errMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return model.MFAEnrollment{}, err
+	}
+	if err := m.mfaRepo.SaveTOTPSecret(ctx, user.ID, secret); err != nil {
+		return model.MFAEnrollment{}, err
+	}
+
+	return model.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(m.issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables MFA once a valid code is presented and returns the
+// recovery codes, which are only shown here.
+func (m *mfaUseCase) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := m.mfaRepo.FindUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, errMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, errMFAEnrollmentIncomplete
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, normalizeMFACode(code), time.Now())
+	if !ok {
+		return nil, errInvalidMFACode
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]model.RecoveryCode, 0, len(codes))
+	for _, recoveryCode := range codes {
+		recoveryCodes = append(recoveryCodes, model.RecoveryCode{UserID: user.ID, CodeHash: utils.HashToken(recoveryCode)})
+	}
+
+	if err := m.mfaRepo.EnableMFA(ctx, user.ID, step, recoveryCodes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns MFA off after verifying a code, unless a role requires it.
+func (m *mfaUseCase) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := m.mfaRepo.FindUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.RequiresMFA() {
+		return errMFARequiredByRole
+	}
+	if !user.MFAEnabled {
+		return nil
+	}
+	if err := m.checkCode(ctx, user, code, ""); err != nil {
+		return err
+	}
+	return m.mfaRepo.DisableMFA(ctx, user.ID)
+}
+
+// VerifyLogin completes the second login step and exchanges an mfa_pending
+// token and a valid code for a regular access token.
+func (m *mfaUseCase) VerifyLogin(ctx context.Context, mfaToken string, code string, clientIP string) (string, error) {
+	claims, err := m.tokens.Parse(mfaToken)
+	if err != nil || claimString(claims, model.TokenPurposeClaim) != model.TokenPurposeMFAPending {
+		return "", errInvalidMFAToken
+	}
+
+	userID, ok := utils.TokenSubjectID(claims)
+	if !ok {
+		return "", errInvalidMFAToken
+	}
+
+	user, err := m.mfaRepo.FindUser(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", errInvalidMFAToken
+	}
+	if err != nil {
+		return "", err
+	}
+	if !user.SessionValid(utils.TokenIssuedAt(claims)) {
+		return "", errInvalidMFAToken
+	}
+	if !user.MFAEnabled {
+		return "", errMFANotEnabled
+	}
+	// A wrong code fails the login rather than the request
+	if err := m.checkCode(ctx, user, code, clientIP); errors.Is(err, errInvalidMFACode) {
+		return "", domain.Unauthorized("%w", err)
+	} else if err != nil {
+		return "", err
+	}
+
+	return m.tokens.Issue(user.ID)
+}
+
+// VerifySecondFactor checks the code of a user who authenticated with a
+// password in a single step, such as the OpenID Connect sign in form. Users
+// without MFA pass unless a role requires them to enroll first.
+func (m *mfaUseCase) VerifySecondFactor(ctx context.Context, user model.User, code string, clientIP string) error {
+	if !user.MFAEnabled {
+		if user.RequiresMFA() {
+			return errMFAEnrollmentRequired
+		}
+		return nil
+	}
+	return m.checkCode(ctx, user, code, clientIP)
+}
+
+// SetRolePolicy implements domain.MFAUseCase.
+func (m *mfaUseCase) SetRolePolicy(ctx context.Context, roleID uint, policy model.RoleMFAPolicy) error {
+	return m.mfaRepo.SetRoleRequireMFA(ctx, roleID, policy.RequireMFA)
+}
+
+// checkCode verifies the code under the login throttle. Success clears the
+// account's failures, including those of the password step.
+func (m *mfaUseCase) checkCode(ctx context.Context, user model.User, code string, clientIP string) error {
+	if err := m.loginThrottle.Check(ctx, user.Username, clientIP); err != nil {
+		return err
+	}
+
+	if err := m.verifyCode(ctx, user, code); err != nil {
+		if errors.Is(err, errInvalidMFACode) {
+			if recordErr := m.loginThrottle.RecordFailure(ctx, user.Username, clientIP); recordErr != nil {
+				return recordErr
+			}
+		}
+		return err
+	}
+	return m.loginThrottle.RecordSuccess(ctx, user.Username)
+}
+
+// verifyCode accepts either a current TOTP code, each time step at most once,
+// or an unused recovery code.
+func (m *mfaUseCase) verifyCode(ctx context.Context, user model.User, code string) error {
+	code = normalizeMFACode(code)
+	if code == "" {
+		return errInvalidMFACode
+	}
+
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		advanced, err := m.mfaRepo.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return domain.Validation("%w: code was already used", errInvalidMFACode)
+		}
+		return nil
+	}
+
+	used, err := m.mfaRepo.UseRecoveryCode(ctx, user.ID, utils.HashToken(code), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+func normalizeMFACode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
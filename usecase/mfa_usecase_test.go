@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock the MFARepo interface
+type MockMFARepo struct {
+	mock.Mock
+}
+
+func (m *MockMFARepo) FindUser(ctx context.Context, userID uint) (model.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockMFARepo) SaveTOTPSecret(ctx context.Context, userID uint, secret string) error {
+	args := m.Called(userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockMFARepo) EnableMFA(ctx context.Context, userID uint, step int64, recoveryCodes []model.RecoveryCode) error {
+	args := m.Called(userID, step, recoveryCodes)
+	return args.Error(0)
+}
+
+func (m *MockMFARepo) DisableMFA(ctx context.Context, userID uint) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockMFARepo) AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	args := m.Called(userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepo) UseRecoveryCode(ctx context.Context, userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	args := m.Called(userID, codeHash, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepo) SetRoleRequireMFA(ctx context.Context, roleID uint, required bool) error {
+	args := m.Called(roleID, required)
+	return args.Error(0)
+}
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func currentTOTPCode() (string, int64) {
+	step := utils.TOTPStep(time.Now())
+	code, _ := utils.TOTPCode(testTOTPSecret, step)
+	return code, step
+}
+
+func TestBeginEnrollment(t *testing.T) {
+	mockRepo := new(MockMFARepo)
+	useCase := NewMFAUseCase(mockRepo, new(MockLoginThrottleUseCase), "RBAC", testTokens)
+
+	mockRepo.On("FindUser", uint(7)).Return(model.User{ID: 7, Username: "john_doe"}, nil)
+	mockRepo.On("SaveTOTPSecret", uint(7), mock.AnythingOfType("string")).Return(nil)
+
+	enrollment, err := useCase.BeginEnrollment(context.Background(), 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, enrollment.Secret, 32)
+	assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/RBAC:john_doe?")
+	mockRepo.AssertCalled(t, "SaveTOTPSecret", uint(7), enrollment.Secret)
+}
+
+func TestBeginEnrollment_AlreadyEnabled(t *testing.T) {
+	mockRepo := new(MockMFARepo)
+	useCase := NewMFAUseCase(mockRepo, new(MockLoginThrottleUseCase), "RBAC", testTokens)
+
+	mockRepo.On("FindUser", uint(7)).Return(model.User{ID: 7, MFAEnabled: true}, nil)
+
+	_, err := useCase.BeginEnrollment(context.Background(), 7)
+
+	assert.EqualError(t, err, "mfa is already enabled")
+	mockRepo.AssertNotCalled(t, "SaveTOTPSecret", mock.Anything, mock.Anything)
+}
+
+func TestConfirmEnrollment(t *testing.T) {
+	mockRepo := new(MockMFARepo)
+	useCase := NewMFAUseCase(mockRepo, new(MockLoginThrottleUseCase), "RBAC", testTokens)
+	code, step := currentTOTPCode()
+
+	mockRepo.On("FindUser", uint(7)).Return(model.User{ID: 7, TOTPSecret: testTOTPSecret}, nil)
+	var stored []model.RecoveryCode
+	mockRepo.On("EnableMFA", uint(7), step, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(2).([]model.RecoveryCode)
+	}).Return(nil)
+
+	t.Run("Rejects a wrong code", func(t *testing.T) {
+		_, err := useCase.ConfirmEnrollment(context.Background(), 7, "000000")
+
+		assert.EqualError(t, err, "invalid verification code")
+		mockRepo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Enables MFA and returns recovery codes", func(t *testing.T) {
+		recoveryCodes, err := useCase.ConfirmEnrollment(context.Background(), 7, code)
+
+		assert.NoError(t, err)
+		assert.Len(t, recoveryCodes, 10)
+		assert.Len(t, stored, 10)
+		// Only hashes of the recovery codes are stored
+		assert.Equal(t, utils.HashToken(recoveryCodes[0]), stored[0].CodeHash)
+	})
+}
+
+func TestVerifyLogin(t *testing.T) {
+	mockRepo := new(MockMFARepo)
+	throttle := new(MockLoginThrottleUseCase)
+	useCase := NewMFAUseCase(mockRepo, throttle, "RBAC", testTokens)
+	code, step := currentTOTPCode()
+
+	mfaToken, _ := utils.GenerateTokenWithClaims(time.Minute, map[string]interface{}{
+		"sub":                   7,
+		model.TokenPurposeClaim: model.TokenPurposeMFAPending,
+	}, testTokenSecret)
+	mockRepo.On("FindUser", uint(7)).Return(model.User{ID: 7, Username: "john_doe", MFAEnabled: true, TOTPSecret: testTOTPSecret}, nil)
+	throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+	throttle.On("RecordSuccess", "john_doe").Return(nil)
+
+	t.Run("Rejects a regular access token", func(t *testing.T) {
+		accessToken, _ := utils.GenerateToken(time.Minute, 7, testTokenSecret)
+
+		_, err := useCase.VerifyLogin(context.Background(), accessToken, code, "203.0.113.9")
+
+		assert.EqualError(t, err, "invalid or expired mfa token")
+	})
+
+	t.Run("Issues an access token for a valid code", func(t *testing.T) {
+		mockRepo.On("AdvanceTOTPStep", uint(7), step).Return(true, nil).Once()
+
+		token, err := useCase.VerifyLogin(context.Background(), mfaToken, code, "203.0.113.9")
+
+		assert.NoError(t, err)
+		claims, err := utils.ParseToken(token, testTokenSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(7), claims["sub"])
+		assert.NotContains(t, claims, model.TokenPurposeClaim)
+	})
+
+	t.Run("Rejects a replayed code", func(t *testing.T) {
+		mockRepo.On("AdvanceTOTPStep", uint(7), step).Return(false, nil).Once()
+		throttle.On("RecordFailure", "john_doe", "203.0.113.9").Return(nil).Once()
+
+		_, err := useCase.VerifyLogin(context.Background(), mfaToken, code, "203.0.113.9")
+
+		assert.EqualError(t, err, "invalid verification code: code was already used")
+		throttle.AssertCalled(t, "RecordFailure", "john_doe", "203.0.113.9")
+	})
+
+	t.Run("Accepts an unused recovery code", func(t *testing.T) {
+		mockRepo.On("UseRecoveryCode", uint(7), utils.HashToken("abcde-12345"), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		_, err := useCase.VerifyLogin(context.Background(), mfaToken, " ABCDE-12345 ", "203.0.113.9")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Refuses codes while throttled", func(t *testing.T) {
+		lockedThrottle := new(MockLoginThrottleUseCase)
+		lockedUseCase := NewMFAUseCase(mockRepo, lockedThrottle, "RBAC", testTokens)
+		lockedThrottle.On("Check", "john_doe", "203.0.113.9").Return(&model.LoginThrottledError{RetryAfter: time.Minute})
+
+		_, err := lockedUseCase.VerifyLogin(context.Background(), mfaToken, code, "203.0.113.9")
+
+		var throttled *model.LoginThrottledError
+		assert.ErrorAs(t, err, &throttled)
+		lockedThrottle.AssertNotCalled(t, "RecordSuccess", mock.Anything)
+	})
+}
+
+func TestDisableMFA_RequiredByRole(t *testing.T) {
+	mockRepo := new(MockMFARepo)
+	useCase := NewMFAUseCase(mockRepo, new(MockLoginThrottleUseCase), "RBAC", testTokens)
+
+	mockRepo.On("FindUser", uint(7)).Return(model.User{
+		ID: 7, MFAEnabled: true, TOTPSecret: testTOTPSecret,
+		Roles: []model.Role{{Name: "admin", RequireMFA: true}},
+	}, nil)
+
+	err := useCase.Disable(context.Background(), 7, "abcde-12345")
+
+	assert.EqualError(t, err, "mfa is required by one of your roles")
+	mockRepo.AssertNotCalled(t, "DisableMFA", mock.Anything)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	throttle := new(MockLoginThrottleUseCase)
+	useCase := NewMFAUseCase(new(MockMFARepo), throttle, "RBAC", testTokens)
+
+	assert.NoError(t, useCase.VerifySecondFactor(context.Background(), model.User{ID: 7}, "", ""), "users without MFA need no code")
+
+	err := useCase.VerifySecondFactor(context.Background(), model.User{ID: 7, Roles: []model.Role{{RequireMFA: true}}}, "", "")
+	assert.EqualError(t, err, "mfa enrollment is required by one of your roles")
+
+	throttle.On("Check", "john_doe", "").Return(nil)
+	throttle.On("RecordFailure", "john_doe", "").Return(nil).Once()
+	err = useCase.VerifySecondFactor(context.Background(), model.User{ID: 7, Username: "john_doe", MFAEnabled: true, TOTPSecret: testTOTPSecret}, "", "")
+	assert.EqualError(t, err, "invalid verification code")
+	throttle.AssertExpectations(t)
+}
@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// GrantClientCredentials is stored in the gty claim of client tokens so they
+// can be told apart from user tokens.
+const GrantClientCredentials = "client_credentials"
+
+type oauthUseCase struct {
+	oauthRepo   domain.OAuthRepo
+	userUseCase domain.UserUseCase
+	tokens      *utils.TokenService
+	tokenTTL    atomic.Int64
+}
+
+// NewOAuthUseCase creates the OAuth use case. Client credentials tokens are
+// signed by tokens and expire after tokenTTL rather than the user token TTL.
+// The sessions of user tokens are checked with userUseCase on introspection.
+func NewOAuthUseCase(oauthRepo domain.OAuthRepo, userUseCase domain.UserUseCase, tokens *utils.TokenService, tokenTTL time.Duration) domain.OAuthUseCase {
+	o := &oauthUseCase{
+		oauthRepo:   oauthRepo,
+		userUseCase: userUseCase,
+		tokens:      tokens,
+	}
+	o.SetTokenTTL(tokenTTL)
+	return o
+}
+
+// SetTokenTTL changes the lifetime of the client credentials tokens issued
+// from now on.
+func (o *oauthUseCase) SetTokenTTL(tokenTTL time.Duration) {
+	o.tokenTTL.Store(int64(tokenTTL))
+}
+
+// CreateClient registers a client and returns its secret, which is only shown once.
+func (o *oauthUseCase) CreateClient(ctx context.Context, client model.OAuthClient) (string, model.OAuthClient, error) {
+	if client.ClientID == "" {
+		return "", model.OAuthClient{}, domain.Validation("client_id is required")
+	}
+
+	// Public clients can't keep a secret and rely on PKCE instead
+	var secret string
+	client.SecretHash = ""
+	if !client.Public {
+		var err error
+		secret, err = utils.GenerateRandomHex(32)
+		if err != nil {
+			return "", model.OAuthClient{}, err
+		}
+		client.SecretHash, err = utils.HashPassword(secret)
+		if err != nil {
+			return "", model.OAuthClient{}, err
+		}
+	}
+	client.Roles = nil
+
+	created, err := o.oauthRepo.CreateClient(ctx, client)
+	if err != nil {
+		return "", model.OAuthClient{}, err
+	}
+	return secret, created, nil
+}
+
+// ListClients implements domain.OAuthUseCase.
+func (o *oauthUseCase) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	return o.oauthRepo.ListClients(ctx)
+}
+
+// AssignRoleToClient implements domain.OAuthUseCase.
+func (o *oauthUseCase) AssignRoleToClient(ctx context.Context, clientID string, roleID uint) error {
+	return o.oauthRepo.AssignRoleToClient(ctx, clientID, roleID)
+}
+
+// IssueClientCredentialsToken implements the client_credentials grant. The
+// granted scopes are the requested ones, or every permission of the client's
+// roles when none are requested.
+func (o *oauthUseCase) IssueClientCredentialsToken(ctx context.Context, clientID string, clientSecret string, scope string) (model.TokenResponse, error) {
+	client, err := o.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	allowed := clientScopes(client)
+	granted := strings.Fields(scope)
+	if len(granted) == 0 {
+		granted = allowed
+	}
+	for _, requested := range granted {
+		if !contains(allowed, requested) {
+			return model.TokenResponse{}, &model.OAuthError{Code: "invalid_scope", Description: "scope " + requested + " is not granted to the client"}
+		}
+	}
+
+	scopeClaim := strings.Join(granted, " ")
+	tokenTTL := time.Duration(o.tokenTTL.Load())
+	token, err := o.tokens.IssueWithClaims(tokenTTL, map[string]interface{}{
+		"sub":       client.ClientID,
+		"client_id": client.ClientID,
+		"scope":     scopeClaim,
+		"gty":       GrantClientCredentials,
+	})
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	return model.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(tokenTTL.Seconds()),
+		Scope:       scopeClaim,
+	}, nil
+}
+
+// Introspect implements RFC 7662. Invalid, expired and revoked tokens are
+// reported as inactive rather than as errors, and so is every other token the
+// API itself rejects: tokens restricted to a purpose, and user tokens whose
+// session ended or whose user isn't active.
+func (o *oauthUseCase) Introspect(ctx context.Context, clientID string, clientSecret string, token string) (model.IntrospectionResponse, error) {
+	if _, err := o.authenticateClient(ctx, clientID, clientSecret); err != nil {
+		return model.IntrospectionResponse{}, err
+	}
+
+	claims, err := o.tokens.Parse(token)
+	if err != nil {
+		return model.IntrospectionResponse{Active: false}, nil
+	}
+
+	jti := claimString(claims, "jti")
+	revoked, err := o.oauthRepo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return model.IntrospectionResponse{}, err
+	}
+	if revoked {
+		return model.IntrospectionResponse{Active: false}, nil
+	}
+	if _, restricted := claims[model.TokenPurposeClaim]; restricted {
+		return model.IntrospectionResponse{Active: false}, nil
+	}
+	if _, isClientToken := claims["gty"]; !isClientToken {
+		active, err := o.sessionActive(ctx, claims)
+		if err != nil || !active {
+			return model.IntrospectionResponse{Active: false}, err
+		}
+	}
+
+	return model.IntrospectionResponse{
+		Active:    true,
+		Scope:     claimString(claims, "scope"),
+		ClientID:  claimString(claims, "client_id"),
+		Sub:       claimString(claims, "sub"),
+		TokenType: "Bearer",
+		Exp:       claimInt(claims, "exp"),
+		Iat:       claimInt(claims, "iat"),
+		Jti:       jti,
+	}, nil
+}
+
+// sessionActive reports whether the user token's session is still valid and
+// its user active. Errors other than an unknown or inactive user are returned.
+func (o *oauthUseCase) sessionActive(ctx context.Context, claims map[string]interface{}) (bool, error) {
+	userID, ok := utils.TokenSubjectID(claims)
+	if !ok {
+		return false, nil
+	}
+	valid, err := o.userUseCase.SessionValid(ctx, userID, utils.TokenIssuedAt(claims))
+	if errors.Is(err, domain.ErrAccountInactive) || errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	}
+	return valid, err
+}
+
+// Revoke implements RFC 7009. A client may only revoke its own tokens; an
+// invalid token is not an error as there is nothing left to revoke.
+func (o *oauthUseCase) Revoke(ctx context.Context, clientID string, clientSecret string, token string) error {
+	client, err := o.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	claims, err := o.tokens.Parse(token)
+	if err != nil {
+		return nil
+	}
+	if claimString(claims, "client_id") != client.ClientID {
+		return &model.OAuthError{Code: "unauthorized_client", Description: "the token was not issued to this client"}
+	}
+
+	return o.oauthRepo.RevokeToken(ctx, claimString(claims, "jti"), time.Unix(claimInt(claims, "exp"), 0))
+}
+
+// IsTokenRevoked implements domain.OAuthUseCase.
+func (o *oauthUseCase) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return o.oauthRepo.IsTokenRevoked(ctx, jti)
+}
+
+func (o *oauthUseCase) authenticateClient(ctx context.Context, clientID string, clientSecret string) (model.OAuthClient, error) {
+	invalidClient := &model.OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	if clientID == "" || clientSecret == "" {
+		return model.OAuthClient{}, invalidClient
+	}
+
+	client, err := o.oauthRepo.FindClientByClientID(ctx, clientID)
+	if err != nil {
+		return model.OAuthClient{}, invalidClient
+	}
+	if !utils.VerifyPassword(client.SecretHash, clientSecret) {
+		return model.OAuthClient{}, invalidClient
+	}
+	return client, nil
+}
+
+// clientScopes returns the sorted, de-duplicated permission names of the client's roles.
+func clientScopes(client model.OAuthClient) []string {
+	seen := map[string]bool{}
+	scopes := []string{}
+	for _, role := range client.Roles {
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				scopes = append(scopes, permission.Name)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	value, ok := claims[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if number, ok := value.(float64); ok {
+		return fmt.Sprintf("%.0f", number)
+	}
+	return fmt.Sprint(value)
+}
+
+func claimInt(claims map[string]interface{}, key string) int64 {
+	number, _ := claims[key].(float64)
+	return int64(number)
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock the OAuthRepo interface
+type MockOAuthRepo struct {
+	mock.Mock
+}
+
+func (m *MockOAuthRepo) CreateClient(ctx context.Context, client model.OAuthClient) (model.OAuthClient, error) {
+	args := m.Called(client)
+	return args.Get(0).(model.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthRepo) FindClientByClientID(ctx context.Context, clientID string) (model.OAuthClient, error) {
+	args := m.Called(clientID)
+	return args.Get(0).(model.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthRepo) ListClients(ctx context.Context) ([]model.OAuthClient, error) {
+	args := m.Called()
+	return args.Get(0).([]model.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthRepo) AssignRoleToClient(ctx context.Context, clientID string, roleID uint) error {
+	args := m.Called(clientID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepo) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	args := m.Called(jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(jti)
+	return args.Bool(0), args.Error(1)
+}
+
+const testTokenSecret = "mock_secret"
+
+var testTokens = utils.NewTokenService(testTokenSecret, time.Hour)
+
+func testOAuthClient(t *testing.T) model.OAuthClient {
+	secretHash, err := utils.HashPassword("client-secret")
+	assert.NoError(t, err)
+	return model.OAuthClient{
+		ID:         1,
+		ClientID:   "reporting",
+		SecretHash: secretHash,
+		Roles: []model.Role{
+			{ID: 1, Name: "reader", Permissions: []model.Permission{{ID: 1, Name: "read"}, {ID: 2, Name: "export"}}},
+			{ID: 2, Name: "auditor", Permissions: []model.Permission{{ID: 1, Name: "read"}}},
+		},
+	}
+}
+
+func TestIssueClientCredentialsToken(t *testing.T) {
+	mockRepo := new(MockOAuthRepo)
+	mockRepo.On("FindClientByClientID", "reporting").Return(testOAuthClient(t), nil)
+	mockRepo.On("FindClientByClientID", "unknown").Return(model.OAuthClient{}, errors.New("record not found"))
+	useCase := NewOAuthUseCase(mockRepo, new(MockUserUseCase), testTokens, time.Hour)
+
+	t.Run("Grants every role permission when no scope is requested", func(t *testing.T) {
+		response, err := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "client-secret", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, int64(3600), response.ExpiresIn)
+		assert.Equal(t, "export read", response.Scope)
+
+		claims, err := utils.ParseToken(response.AccessToken, testTokenSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, "reporting", claims["client_id"])
+		assert.Equal(t, GrantClientCredentials, claims["gty"])
+	})
+
+	t.Run("Narrows the token to the requested scope", func(t *testing.T) {
+		response, err := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "client-secret", "read")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "read", response.Scope)
+	})
+
+	t.Run("Rejects scopes outside the client's roles", func(t *testing.T) {
+		_, err := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "client-secret", "read delete")
+
+		var oauthErr *model.OAuthError
+		assert.True(t, errors.As(err, &oauthErr))
+		assert.Equal(t, "invalid_scope", oauthErr.Code)
+	})
+
+	t.Run("Rejects a wrong secret and an unknown client alike", func(t *testing.T) {
+		_, wrongSecret := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "guess", "")
+		_, unknown := useCase.IssueClientCredentialsToken(context.Background(), "unknown", "guess", "")
+
+		assert.EqualError(t, wrongSecret, "invalid_client: client authentication failed")
+		assert.EqualError(t, unknown, "invalid_client: client authentication failed")
+	})
+
+	t.Run("Uses the token TTL set while running", func(t *testing.T) {
+		useCase := NewOAuthUseCase(mockRepo, new(MockUserUseCase), testTokens, time.Hour)
+		useCase.SetTokenTTL(5 * time.Minute)
+
+		response, err := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "client-secret", "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(300), response.ExpiresIn)
+	})
+}
+
+func TestIntrospect(t *testing.T) {
+	mockRepo := new(MockOAuthRepo)
+	mockRepo.On("FindClientByClientID", "reporting").Return(testOAuthClient(t), nil)
+	userUseCase := new(MockUserUseCase)
+	useCase := NewOAuthUseCase(mockRepo, userUseCase, testTokens, time.Hour)
+
+	issued, err := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "client-secret", "read")
+	assert.NoError(t, err)
+	claims, _ := utils.ParseToken(issued.AccessToken, testTokenSecret)
+	jti := claims["jti"].(string)
+
+	t.Run("Reports an active token", func(t *testing.T) {
+		mockRepo.On("IsTokenRevoked", jti).Return(false, nil).Once()
+
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", issued.AccessToken)
+
+		assert.NoError(t, err)
+		assert.True(t, response.Active)
+		assert.Equal(t, "read", response.Scope)
+		assert.Equal(t, "reporting", response.ClientID)
+		assert.Equal(t, jti, response.Jti)
+	})
+
+	t.Run("Reports a revoked token as inactive", func(t *testing.T) {
+		mockRepo.On("IsTokenRevoked", jti).Return(true, nil).Once()
+
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", issued.AccessToken)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+		assert.Empty(t, response.Scope)
+	})
+
+	t.Run("Reports a malformed token as inactive", func(t *testing.T) {
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", "not-a-token")
+
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+	})
+
+	t.Run("Reports an active user token", func(t *testing.T) {
+		userToken, _ := testTokens.Issue(7)
+		mockRepo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+		userUseCase.On("SessionValid", uint(7), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", userToken)
+
+		assert.NoError(t, err)
+		assert.True(t, response.Active)
+		assert.Equal(t, "7", response.Sub)
+	})
+
+	for _, purpose := range []string{model.TokenPurposeMFAPending, model.TokenPurposeMFAEnrollment, model.TokenPurposeOIDCAccess} {
+		t.Run("Reports a "+purpose+" token as inactive", func(t *testing.T) {
+			restricted, _ := testTokens.IssueWithClaims(time.Minute, map[string]interface{}{"sub": 7, model.TokenPurposeClaim: purpose})
+			mockRepo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+
+			response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", restricted)
+
+			assert.NoError(t, err)
+			assert.False(t, response.Active)
+		})
+	}
+
+	t.Run("Reports a token issued before a password change as inactive", func(t *testing.T) {
+		userToken, _ := testTokens.Issue(7)
+		mockRepo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+		userUseCase.On("SessionValid", uint(7), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", userToken)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+	})
+
+	t.Run("Reports a token of an inactive user as inactive", func(t *testing.T) {
+		userToken, _ := testTokens.Issue(7)
+		mockRepo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+		userUseCase.On("SessionValid", uint(7), mock.AnythingOfType("time.Time")).Return(false, domain.ErrAccountInactive).Once()
+
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", userToken)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+	})
+
+	t.Run("Reports a token of a deleted user as inactive", func(t *testing.T) {
+		userToken, _ := testTokens.Issue(7)
+		mockRepo.On("IsTokenRevoked", mock.Anything).Return(false, nil).Once()
+		userUseCase.On("SessionValid", uint(7), mock.AnythingOfType("time.Time")).Return(false, domain.NotFound("user not found")).Once()
+
+		response, err := useCase.Introspect(context.Background(), "reporting", "client-secret", userToken)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Active)
+	})
+}
+
+func TestRevoke(t *testing.T) {
+	mockRepo := new(MockOAuthRepo)
+	mockRepo.On("FindClientByClientID", "reporting").Return(testOAuthClient(t), nil)
+	useCase := NewOAuthUseCase(mockRepo, new(MockUserUseCase), testTokens, time.Hour)
+
+	t.Run("Revokes the client's own token", func(t *testing.T) {
+		issued, _ := useCase.IssueClientCredentialsToken(context.Background(), "reporting", "client-secret", "")
+		claims, _ := utils.ParseToken(issued.AccessToken, testTokenSecret)
+		mockRepo.On("RevokeToken", claims["jti"].(string), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		err := useCase.Revoke(context.Background(), "reporting", "client-secret", issued.AccessToken)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Refuses to revoke another party's token", func(t *testing.T) {
+		userToken, _ := utils.GenerateToken(time.Hour, 1, testTokenSecret)
+
+		err := useCase.Revoke(context.Background(), "reporting", "client-secret", userToken)
+
+		assert.EqualError(t, err, "unauthorized_client: the token was not issued to this client")
+	})
+
+	t.Run("Ignores invalid tokens", func(t *testing.T) {
+		err := useCase.Revoke(context.Background(), "reporting", "client-secret", "not-a-token")
+
+		assert.NoError(t, err)
+	})
+}
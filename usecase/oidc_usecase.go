@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const authorizationCodeTTL = 5 * time.Minute
+
+// supportedScopes are the scopes an OpenID Connect client may request.
+var supportedScopes = []string{"openid", "profile", "roles"}
+
+type oidcUseCase struct {
+	oidcRepo    domain.OIDCRepo
+	oauthRepo   domain.OAuthRepo
+	userUseCase domain.UserUseCase
+	mfaUseCase  domain.MFAUseCase
+	issuer      string
+	tokens      *utils.TokenService
+	signingKey  *rsa.PrivateKey
+}
+
+// NewOIDCUseCase creates the OpenID Connect provider. Access tokens are signed
+// by tokens like every other token; ID tokens are signed with signingKey
+// so relying parties can verify them using the published JWKS.
+func NewOIDCUseCase(oidcRepo domain.OIDCRepo, oauthRepo domain.OAuthRepo, userUseCase domain.UserUseCase, mfaUseCase domain.MFAUseCase, issuer string, tokens *utils.TokenService, signingKey *rsa.PrivateKey) domain.OIDCUseCase {
+	return &oidcUseCase{
+		oidcRepo:    oidcRepo,
+		oauthRepo:   oauthRepo,
+		userUseCase: userUseCase,
+		mfaUseCase:  mfaUseCase,
+		issuer:      strings.TrimSuffix(issuer, "/"),
+		tokens:      tokens,
+		signingKey:  signingKey,
+	}
+}
+
+// Discovery implements domain.OIDCUseCase.
+func (o *oidcUseCase) Discovery() model.OpenIDConfiguration {
+	return model.OpenIDConfiguration{
+		Issuer:                            o.issuer,
+		AuthorizationEndpoint:             o.issuer + "/oauth/authorize",
+		TokenEndpoint:                     o.issuer + "/oauth/token",
+		UserinfoEndpoint:                  o.issuer + "/userinfo",
+		JwksURI:                           o.issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             o.issuer + "/oauth/introspect",
+		RevocationEndpoint:                o.issuer + "/oauth/revoke",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", GrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   supportedScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "roles"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+// JWKS implements domain.OIDCUseCase.
+func (o *oidcUseCase) JWKS() map[string]interface{} {
+	return map[string]interface{}{
+		"keys": []map[string]string{utils.RSAPublicJWK(&o.signingKey.PublicKey)},
+	}
+}
+
+// ValidateAuthorizeRequest checks everything about the request that must hold
+// before the user is asked for credentials.
+func (o *oidcUseCase) ValidateAuthorizeRequest(ctx context.Context, request model.AuthorizeRequest) (model.OAuthClient, error) {
+	client, err := o.oauthRepo.FindClientByClientID(ctx, request.ClientID)
+	if err != nil {
+		return model.OAuthClient{}, &model.OAuthError{Code: "invalid_client", Description: "unknown client"}
+	}
+	if !client.AllowsRedirectURI(request.RedirectURI) {
+		return model.OAuthClient{}, &model.OAuthError{Code: "invalid_request", Description: "redirect_uri is not registered for the client"}
+	}
+	if request.ResponseType != "code" {
+		return model.OAuthClient{}, &model.OAuthError{Code: "unsupported_response_type"}
+	}
+	if !contains(strings.Fields(request.Scope), "openid") {
+		return model.OAuthClient{}, &model.OAuthError{Code: "invalid_scope", Description: "the openid scope is required"}
+	}
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != "S256" {
+		return model.OAuthClient{}, &model.OAuthError{Code: "invalid_request", Description: "PKCE with code_challenge_method S256 is required"}
+	}
+	return client, nil
+}
+
+// Authorize checks the user's credentials and returns the redirect URL carrying
+// a fresh authorization code.
+func (o *oidcUseCase) Authorize(ctx context.Context, request model.AuthorizeRequest, credentials model.User, otp string, clientIP string) (string, error) {
+	client, err := o.ValidateAuthorizeRequest(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	// Throttling errors are returned as is so the caller can report when to retry
+	user, err := o.userUseCase.AuthenticateUser(ctx, credentials, clientIP)
+	if errors.Is(err, domain.ErrInvalidCredentials) || errors.Is(err, domain.ErrAccountInactive) {
+		return "", &model.OAuthError{Code: "access_denied", Description: err.Error()}
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := o.mfaUseCase.VerifySecondFactor(ctx, user, otp, clientIP); err != nil {
+		var throttled *model.LoginThrottledError
+		if errors.As(err, &throttled) {
+			return "", err
+		}
+		return "", &model.OAuthError{Code: "access_denied", Description: err.Error()}
+	}
+
+	code, err := utils.GenerateRandomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = o.oidcRepo.CreateAuthorizationCode(ctx, model.AuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      client.ClientID,
+		UserID:        user.ID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         strings.Join(filterSupportedScopes(request.Scope), " "),
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+		AuthTime:      now,
+		ExpiresAt:     now.Add(authorizationCodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	redirect, err := url.Parse(request.RedirectURI)
+	if err != nil {
+		return "", err
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	if request.State != "" {
+		query.Set("state", request.State)
+	}
+	redirect.RawQuery = query.Encode()
+	return redirect.String(), nil
+}
+
+// ExchangeAuthorizationCode implements the authorization_code grant.
+func (o *oidcUseCase) ExchangeAuthorizationCode(ctx context.Context, clientID string, clientSecret string, code string, redirectURI string, codeVerifier string) (model.TokenResponse, error) {
+	invalidGrant := &model.OAuthError{Code: "invalid_grant", Description: "the authorization code is invalid or expired"}
+
+	client, err := o.oauthRepo.FindClientByClientID(ctx, clientID)
+	if err != nil {
+		return model.TokenResponse{}, &model.OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+	if !client.Public && !utils.VerifyPassword(client.SecretHash, clientSecret) {
+		return model.TokenResponse{}, &model.OAuthError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	authorizationCode, err := o.oidcRepo.FindAuthorizationCode(ctx, utils.HashToken(code))
+	if err != nil {
+		return model.TokenResponse{}, invalidGrant
+	}
+	now := time.Now()
+	if authorizationCode.ClientID != client.ClientID || authorizationCode.RedirectURI != redirectURI ||
+		authorizationCode.UsedAt != nil || now.After(authorizationCode.ExpiresAt) {
+		return model.TokenResponse{}, invalidGrant
+	}
+	if !utils.VerifyPKCE(codeVerifier, authorizationCode.CodeChallenge) {
+		return model.TokenResponse{}, &model.OAuthError{Code: "invalid_grant", Description: "code_verifier does not match the code_challenge"}
+	}
+
+	consumed, err := o.oidcRepo.MarkAuthorizationCodeUsed(ctx, authorizationCode.ID, now)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+	if !consumed {
+		return model.TokenResponse{}, invalidGrant
+	}
+
+	user, err := o.oidcRepo.FindUserWithRoles(ctx, authorizationCode.UserID)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+	if !user.Active() {
+		return model.TokenResponse{}, invalidGrant
+	}
+
+	accessToken, err := o.tokens.IssueWithClaims(o.tokens.TTL(), map[string]interface{}{
+		"sub":                   user.ID,
+		"client_id":             client.ClientID,
+		"scope":                 authorizationCode.Scope,
+		model.TokenPurposeClaim: model.TokenPurposeOIDCAccess,
+	})
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	idClaims := map[string]interface{}{
+		"iss":                o.issuer,
+		"sub":                strconv.FormatUint(uint64(user.ID), 10),
+		"aud":                client.ClientID,
+		"auth_time":          authorizationCode.AuthTime.Unix(),
+		"preferred_username": user.Username,
+		"roles":              roleNames(user),
+	}
+	if authorizationCode.Nonce != "" {
+		idClaims["nonce"] = authorizationCode.Nonce
+	}
+	idToken, err := utils.GenerateRS256Token(o.tokens.TTL(), idClaims, o.signingKey)
+	if err != nil {
+		return model.TokenResponse{}, err
+	}
+
+	return model.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(o.tokens.TTL().Seconds()),
+		Scope:       authorizationCode.Scope,
+		IDToken:     idToken,
+	}, nil
+}
+
+// UserInfo implements domain.OIDCUseCase.
+func (o *oidcUseCase) UserInfo(ctx context.Context, accessToken string) (model.UserInfo, error) {
+	invalidToken := &model.OAuthError{Code: "invalid_token"}
+
+	claims, err := o.tokens.Parse(accessToken)
+	if err != nil {
+		return model.UserInfo{}, invalidToken
+	}
+	if _, isClientToken := claims["gty"]; isClientToken {
+		return model.UserInfo{}, invalidToken
+	}
+	if !contains(strings.Fields(claimString(claims, "scope")), "openid") {
+		return model.UserInfo{}, &model.OAuthError{Code: "insufficient_scope", Description: "the openid scope is required"}
+	}
+
+	revoked, err := o.oauthRepo.IsTokenRevoked(ctx, claimString(claims, "jti"))
+	if err != nil {
+		return model.UserInfo{}, err
+	}
+	if revoked {
+		return model.UserInfo{}, invalidToken
+	}
+
+	userID, ok := utils.TokenSubjectID(claims)
+	if !ok {
+		return model.UserInfo{}, invalidToken
+	}
+
+	user, err := o.oidcRepo.FindUserWithRoles(ctx, userID)
+	if err != nil || !user.SessionValid(utils.TokenIssuedAt(claims)) {
+		return model.UserInfo{}, invalidToken
+	}
+
+	return model.UserInfo{
+		Sub:               strconv.FormatUint(uint64(user.ID), 10),
+		PreferredUsername: user.Username,
+		Roles:             roleNames(user),
+	}, nil
+}
+
+func filterSupportedScopes(scope string) []string {
+	scopes := []string{}
+	for _, requested := range strings.Fields(scope) {
+		if contains(supportedScopes, requested) && !contains(scopes, requested) {
+			scopes = append(scopes, requested)
+		}
+	}
+	return scopes
+}
+
+func roleNames(user model.User) []string {
+	names := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock the OIDCRepo interface
+type MockOIDCRepo struct {
+	mock.Mock
+}
+
+func (m *MockOIDCRepo) CreateAuthorizationCode(ctx context.Context, code model.AuthorizationCode) (model.AuthorizationCode, error) {
+	args := m.Called(code)
+	return args.Get(0).(model.AuthorizationCode), args.Error(1)
+}
+
+func (m *MockOIDCRepo) FindAuthorizationCode(ctx context.Context, codeHash string) (model.AuthorizationCode, error) {
+	args := m.Called(codeHash)
+	return args.Get(0).(model.AuthorizationCode), args.Error(1)
+}
+
+func (m *MockOIDCRepo) MarkAuthorizationCodeUsed(ctx context.Context, codeID uint, usedAt time.Time) (bool, error) {
+	args := m.Called(codeID, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOIDCRepo) FindUserWithRoles(ctx context.Context, userID uint) (model.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+// Mock the UserUseCase interface
+type MockUserUseCase struct {
+	mock.Mock
+}
+
+func (m *MockUserUseCase) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) LoginUser(ctx context.Context, user model.User, clientIP string) (model.LoginResult, error) {
+	args := m.Called(user, clientIP)
+	return args.Get(0).(model.LoginResult), args.Error(1)
+}
+
+func (m *MockUserUseCase) AuthenticateUser(ctx context.Context, user model.User, clientIP string) (model.User, error) {
+	args := m.Called(user, clientIP)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ValidatePassword(ctx context.Context, user model.User, password string) error {
+	args := m.Called(user, password)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	args := m.Called(identifier)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
+	args := m.Called(userID, permissionName)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Permission), args.Error(1)
+}
+
+func (m *MockUserUseCase) ChangePassword(ctx context.Context, userID uint, currentPassword string, newPassword string, clientIP string) (string, error) {
+	args := m.Called(userID, currentPassword, newPassword, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) RequestPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	args := m.Called(identifier, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserUseCase) SessionValid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error) {
+	args := m.Called(userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockUserUseCase) ChangeStatus(ctx context.Context, userID uint, request model.UserStatusRequest, actorID uint) (model.User, error) {
+	args := m.Called(userID, request, actorID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserUseCase) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.UserStatusChange), args.Error(1)
+}
+
+// Mock the MFAUseCase interface
+type MockMFAUseCase struct {
+	mock.Mock
+}
+
+func (m *MockMFAUseCase) BeginEnrollment(ctx context.Context, userID uint) (model.MFAEnrollment, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.MFAEnrollment), args.Error(1)
+}
+
+func (m *MockMFAUseCase) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAUseCase) Disable(ctx context.Context, userID uint, code string) error {
+	args := m.Called(userID, code)
+	return args.Error(0)
+}
+
+func (m *MockMFAUseCase) VerifyLogin(ctx context.Context, mfaToken string, code string, clientIP string) (string, error) {
+	args := m.Called(mfaToken, code, clientIP)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMFAUseCase) VerifySecondFactor(ctx context.Context, user model.User, code string, clientIP string) error {
+	args := m.Called(user, code, clientIP)
+	return args.Error(0)
+}
+
+func (m *MockMFAUseCase) SetRolePolicy(ctx context.Context, roleID uint, policy model.RoleMFAPolicy) error {
+	args := m.Called(roleID, policy)
+	return args.Error(0)
+}
+
+var testCodeVerifier = strings.Repeat("v", 64)
+
+func testCodeChallenge() string {
+	sum := sha256.Sum256([]byte(testCodeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func testAuthorizeRequest() model.AuthorizeRequest {
+	return model.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "portal",
+		RedirectURI:         "https://portal.example.com/callback",
+		Scope:               "openid profile roles",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       testCodeChallenge(),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func newTestOIDCUseCase(t *testing.T, oidcRepo *MockOIDCRepo, oauthRepo *MockOAuthRepo, userUseCase *MockUserUseCase, mfaUseCase *MockMFAUseCase) *oidcUseCase {
+	key, err := utils.GenerateRSAPrivateKey()
+	assert.NoError(t, err)
+	oauthRepo.On("FindClientByClientID", "portal").Return(model.OAuthClient{
+		ID:           2,
+		ClientID:     "portal",
+		Public:       true,
+		RedirectURIs: []string{"https://portal.example.com/callback"},
+	}, nil)
+	return NewOIDCUseCase(oidcRepo, oauthRepo, userUseCase, mfaUseCase, "https://auth.example.com/", testTokens, key).(*oidcUseCase)
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	useCase := newTestOIDCUseCase(t, new(MockOIDCRepo), new(MockOAuthRepo), new(MockUserUseCase), new(MockMFAUseCase))
+
+	configuration := useCase.Discovery()
+
+	assert.Equal(t, "https://auth.example.com", configuration.Issuer)
+	assert.Equal(t, "https://auth.example.com/oauth/authorize", configuration.AuthorizationEndpoint)
+	assert.Equal(t, []string{"S256"}, configuration.CodeChallengeMethodsSupported)
+	assert.Len(t, useCase.JWKS()["keys"], 1)
+}
+
+func TestValidateAuthorizeRequest(t *testing.T) {
+	useCase := newTestOIDCUseCase(t, new(MockOIDCRepo), new(MockOAuthRepo), new(MockUserUseCase), new(MockMFAUseCase))
+
+	t.Run("Rejects an unregistered redirect uri", func(t *testing.T) {
+		request := testAuthorizeRequest()
+		request.RedirectURI = "https://evil.example.com/callback"
+
+		_, err := useCase.ValidateAuthorizeRequest(context.Background(), request)
+
+		assert.EqualError(t, err, "invalid_request: redirect_uri is not registered for the client")
+	})
+
+	t.Run("Requires the openid scope", func(t *testing.T) {
+		request := testAuthorizeRequest()
+		request.Scope = "profile"
+
+		_, err := useCase.ValidateAuthorizeRequest(context.Background(), request)
+
+		assert.EqualError(t, err, "invalid_scope: the openid scope is required")
+	})
+
+	t.Run("Requires PKCE", func(t *testing.T) {
+		request := testAuthorizeRequest()
+		request.CodeChallengeMethod = "plain"
+
+		_, err := useCase.ValidateAuthorizeRequest(context.Background(), request)
+
+		assert.EqualError(t, err, "invalid_request: PKCE with code_challenge_method S256 is required")
+	})
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	oidcRepo := new(MockOIDCRepo)
+	oauthRepo := new(MockOAuthRepo)
+	userUseCase := new(MockUserUseCase)
+	mfaUseCase := new(MockMFAUseCase)
+	useCase := newTestOIDCUseCase(t, oidcRepo, oauthRepo, userUseCase, mfaUseCase)
+
+	credentials := model.User{Username: "john_doe", Password: "password123"}
+	userUseCase.On("AuthenticateUser", credentials, "203.0.113.9").Return(model.User{ID: 7, Username: "john_doe"}, nil)
+	mfaUseCase.On("VerifySecondFactor", model.User{ID: 7, Username: "john_doe"}, "", "203.0.113.9").Return(nil)
+
+	var stored model.AuthorizationCode
+	oidcRepo.On("CreateAuthorizationCode", mock.AnythingOfType("model.AuthorizationCode")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(model.AuthorizationCode)
+		stored.ID = 11
+	}).Return(model.AuthorizationCode{ID: 11}, nil)
+
+	// Step 1: the user authenticates and is redirected back with a code
+	redirect, err := useCase.Authorize(context.Background(), testAuthorizeRequest(), credentials, "", "203.0.113.9")
+	assert.NoError(t, err)
+	location, _ := url.Parse(redirect)
+	code := location.Query().Get("code")
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+	assert.Equal(t, utils.HashToken(code), stored.CodeHash)
+	assert.Equal(t, "openid profile roles", stored.Scope)
+
+	oidcRepo.On("FindAuthorizationCode", utils.HashToken(code)).Return(stored, nil)
+	oidcRepo.On("FindUserWithRoles", uint(7)).Return(model.User{ID: 7, Username: "john_doe", Roles: []model.Role{{ID: 1, Name: "admin"}}}, nil)
+
+	t.Run("Rejects a wrong code verifier", func(t *testing.T) {
+		_, err := useCase.ExchangeAuthorizationCode(context.Background(), "portal", "", code, "https://portal.example.com/callback", strings.Repeat("x", 64))
+
+		assert.EqualError(t, err, "invalid_grant: code_verifier does not match the code_challenge")
+	})
+
+	t.Run("Exchanges the code for an access and id token", func(t *testing.T) {
+		oidcRepo.On("MarkAuthorizationCodeUsed", uint(11), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+
+		response, err := useCase.ExchangeAuthorizationCode(context.Background(), "portal", "", code, "https://portal.example.com/callback", testCodeVerifier)
+		assert.NoError(t, err)
+
+		idToken, err := jwt.Parse(response.IDToken, func(jwtToken *jwt.Token) (interface{}, error) {
+			if _, ok := jwtToken.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("unexpected method: %s", jwtToken.Header["alg"])
+			}
+			return &useCase.signingKey.PublicKey, nil
+		})
+		assert.NoError(t, err)
+		claims := idToken.Claims.(jwt.MapClaims)
+		assert.Equal(t, "https://auth.example.com", claims["iss"])
+		assert.Equal(t, "portal", claims["aud"])
+		assert.Equal(t, "7", claims["sub"])
+		assert.Equal(t, "n-0S6", claims["nonce"])
+		assert.Equal(t, "john_doe", claims["preferred_username"])
+		assert.Equal(t, []interface{}{"admin"}, claims["roles"])
+
+		// The access token is marked so the API doesn't take it for a session
+		accessClaims, err := utils.ParseToken(response.AccessToken, testTokenSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TokenPurposeOIDCAccess, accessClaims[model.TokenPurposeClaim])
+
+		// The access token works against the userinfo endpoint
+		oauthRepo.On("IsTokenRevoked", mock.AnythingOfType("string")).Return(false, nil).Once()
+		userInfo, err := useCase.UserInfo(context.Background(), response.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserInfo{Sub: "7", PreferredUsername: "john_doe", Roles: []string{"admin"}}, userInfo)
+	})
+
+	t.Run("Rejects a code that was already redeemed", func(t *testing.T) {
+		oidcRepo.On("MarkAuthorizationCodeUsed", uint(11), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+		_, err := useCase.ExchangeAuthorizationCode(context.Background(), "portal", "", code, "https://portal.example.com/callback", testCodeVerifier)
+
+		assert.EqualError(t, err, "invalid_grant: the authorization code is invalid or expired")
+	})
+}
+
+func TestAuthorizeWithWrongPassword(t *testing.T) {
+	userUseCase := new(MockUserUseCase)
+	useCase := newTestOIDCUseCase(t, new(MockOIDCRepo), new(MockOAuthRepo), userUseCase, new(MockMFAUseCase))
+
+	credentials := model.User{Username: "john_doe", Password: "wrong"}
+	userUseCase.On("AuthenticateUser", credentials, "203.0.113.9").Return(model.User{}, domain.ErrInvalidCredentials)
+
+	_, err := useCase.Authorize(context.Background(), testAuthorizeRequest(), credentials, "", "203.0.113.9")
+
+	assert.EqualError(t, err, "access_denied: invalid username or password")
+}
+
+func TestAuthorizeRequiresSecondFactor(t *testing.T) {
+	userUseCase := new(MockUserUseCase)
+	mfaUseCase := new(MockMFAUseCase)
+	oidcRepo := new(MockOIDCRepo)
+	useCase := newTestOIDCUseCase(t, oidcRepo, new(MockOAuthRepo), userUseCase, mfaUseCase)
+
+	credentials := model.User{Username: "john_doe", Password: "password123"}
+	user := model.User{ID: 7, Username: "john_doe", MFAEnabled: true}
+	userUseCase.On("AuthenticateUser", credentials, "203.0.113.9").Return(user, nil)
+	mfaUseCase.On("VerifySecondFactor", user, "000000", "203.0.113.9").Return(errInvalidMFACode)
+
+	_, err := useCase.Authorize(context.Background(), testAuthorizeRequest(), credentials, "000000", "203.0.113.9")
+
+	assert.EqualError(t, err, "access_denied: invalid verification code")
+	oidcRepo.AssertNotCalled(t, "CreateAuthorizationCode", mock.Anything)
+}
+
+func TestUserInfoRequiresOpenIDScope(t *testing.T) {
+	useCase := newTestOIDCUseCase(t, new(MockOIDCRepo), new(MockOAuthRepo), new(MockUserUseCase), new(MockMFAUseCase))
+	loginToken, _ := utils.GenerateToken(time.Hour, 7, testTokenSecret)
+
+	_, err := useCase.UserInfo(context.Background(), loginToken)
+
+	assert.EqualError(t, err, "insufficient_scope: the openid scope is required")
+}
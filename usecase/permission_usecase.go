@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"go-multirole/domain"
 	"go-multirole/model"
 )
@@ -16,6 +17,16 @@ func NewPermissionUseCase(permissionRepo domain.PermissionRepo) domain.Permissio
 }
 
 // CreateRole implements domain.RoleUseCase.
-func (r *permissionUseCase) CreatePermission(role model.Permission) (model.Permission, error) {
-	return r.permissionRepo.CreatePermission(role)
+func (r *permissionUseCase) CreatePermission(ctx context.Context, role model.Permission) (model.Permission, error) {
+	return r.permissionRepo.CreatePermission(ctx, role)
+}
+
+// ListPermissions implements domain.PermissionUseCase.
+func (r *permissionUseCase) ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error) {
+	return r.permissionRepo.ListPermissions(ctx, filter)
+}
+
+// FindPermissionByName implements domain.PermissionUseCase.
+func (r *permissionUseCase) FindPermissionByName(ctx context.Context, name string) (model.Permission, error) {
+	return r.permissionRepo.FindPermissionByName(ctx, name)
 }
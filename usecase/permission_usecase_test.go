@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"go-multirole/model"
 	"testing"
@@ -14,11 +15,21 @@ type MockPermissionRepo struct {
 	mock.Mock
 }
 
-func (m *MockPermissionRepo) CreatePermission(permission model.Permission) (model.Permission, error) {
+func (m *MockPermissionRepo) CreatePermission(ctx context.Context, permission model.Permission) (model.Permission, error) {
 	args := m.Called(permission)
 	return args.Get(0).(model.Permission), args.Error(1)
 }
 
+func (m *MockPermissionRepo) ListPermissions(ctx context.Context, filter model.PermissionFilter) ([]model.Permission, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Permission), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockPermissionRepo) FindPermissionByName(ctx context.Context, name string) (model.Permission, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Permission), args.Error(1)
+}
+
 func TestCreatePermission(t *testing.T) {
 	// Create a mock repository
 	mockRepo := new(MockPermissionRepo)
@@ -36,7 +47,7 @@ func TestCreatePermission(t *testing.T) {
 	useCase := NewPermissionUseCase(mockRepo)
 
 	// Call the method under test
-	result, err := useCase.CreatePermission(testPermission)
+	result, err := useCase.CreatePermission(context.Background(), testPermission)
 
 	// Assert the expectations
 	assert.NoError(t, err)
@@ -63,7 +74,7 @@ func TestCreatePermission_Error(t *testing.T) {
 	useCase := NewPermissionUseCase(mockRepo)
 
 	// Call the method under test
-	result, err := useCase.CreatePermission(testPermission)
+	result, err := useCase.CreatePermission(context.Background(), testPermission)
 
 	// Assert that an error occurred
 	assert.Error(t, err)
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"sort"
+)
+
+type policyUseCase struct {
+	policyRepo     domain.PolicyRepo
+	roleRepo       domain.RoleRepo
+	permissionRepo domain.PermissionRepo
+}
+
+// NewPolicyUseCase creates the use case syncing policy files, which reads the
+// current roles and permissions through their repositories.
+func NewPolicyUseCase(policyRepo domain.PolicyRepo, roleRepo domain.RoleRepo, permissionRepo domain.PermissionRepo) domain.PolicyUseCase {
+	return &policyUseCase{
+		policyRepo:     policyRepo,
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// Plan implements domain.PolicyUseCase. It compares the policy with every role
+// and permission in the database.
+func (p *policyUseCase) Plan(ctx context.Context, policy model.Policy) (model.PolicyPlan, error) {
+	if fields := policy.Validate(); len(fields) > 0 {
+		return model.PolicyPlan{}, domain.InvalidFields(fields...)
+	}
+	roles, err := p.allRoles(ctx)
+	if err != nil {
+		return model.PolicyPlan{}, err
+	}
+	permissions, err := p.allPermissions(ctx)
+	if err != nil {
+		return model.PolicyPlan{}, err
+	}
+	return diffPolicy(currentPolicy(roles, permissions), policy), nil
+}
+
+// Apply implements domain.PolicyUseCase. It returns the plan it applied.
+func (p *policyUseCase) Apply(ctx context.Context, policy model.Policy) (model.PolicyPlan, error) {
+	plan, err := p.Plan(ctx, policy)
+	if err != nil || plan.Empty() {
+		return plan, err
+	}
+	if err := p.policyRepo.ApplyPolicyPlan(ctx, plan); err != nil {
+		return model.PolicyPlan{}, err
+	}
+	return plan, nil
+}
+
+func (p *policyUseCase) allRoles(ctx context.Context) ([]model.Role, error) {
+	var all []model.Role
+	filter := model.RoleFilter{PageRequest: model.PageRequest{Limit: model.MaxPageLimit}}
+	for {
+		roles, page, err := p.roleRepo.ListRoles(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, roles...)
+		if !page.HasMore {
+			return all, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+func (p *policyUseCase) allPermissions(ctx context.Context) ([]model.Permission, error) {
+	var all []model.Permission
+	filter := model.PermissionFilter{PageRequest: model.PageRequest{Limit: model.MaxPageLimit}}
+	for {
+		permissions, page, err := p.permissionRepo.ListPermissions(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, permissions...)
+		if !page.HasMore {
+			return all, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// currentPolicy describes the roles and permissions in the database as a policy.
+func currentPolicy(roles []model.Role, permissions []model.Permission) model.Policy {
+	var policy model.Policy
+	for _, permission := range permissions {
+		policy.Permissions = append(policy.Permissions, permission.Name)
+	}
+	for _, role := range roles {
+		declared := model.PolicyRole{Name: role.Name, RequireMFA: role.RequireMFA}
+		for _, permission := range role.Permissions {
+			declared.Permissions = append(declared.Permissions, permission.Name)
+		}
+		policy.Roles = append(policy.Roles, declared)
+	}
+	return policy
+}
+
+// diffPolicy returns the changes from the current policy to the wanted one.
+func diffPolicy(current model.Policy, wanted model.Policy) model.PolicyPlan {
+	var plan model.PolicyPlan
+	declaredPermissions := map[string]bool{}
+	existingPermissions := map[string]bool{}
+	for _, name := range current.Permissions {
+		existingPermissions[name] = true
+	}
+	for _, name := range wanted.Permissions {
+		declaredPermissions[name] = true
+		if !existingPermissions[name] {
+			plan.CreatePermissions = append(plan.CreatePermissions, name)
+		}
+	}
+
+	existingRoles := map[string]model.PolicyRole{}
+	for _, role := range current.Roles {
+		existingRoles[role.Name] = role
+	}
+	declaredRoles := map[string]bool{}
+	for _, declared := range wanted.Roles {
+		declaredRoles[declared.Name] = true
+		existing, ok := existingRoles[declared.Name]
+		switch {
+		case !ok:
+			plan.CreateRoles = append(plan.CreateRoles, model.Role{Name: declared.Name, RequireMFA: declared.RequireMFA})
+		case existing.RequireMFA != declared.RequireMFA:
+			plan.UpdateRoles = append(plan.UpdateRoles, model.Role{Name: declared.Name, RequireMFA: declared.RequireMFA})
+		}
+
+		granted := map[string]bool{}
+		for _, name := range existing.Permissions {
+			granted[name] = true
+		}
+		grants := map[string]bool{}
+		for _, name := range declared.Permissions {
+			grants[name] = true
+			if !granted[name] {
+				plan.Grant = append(plan.Grant, model.PolicyBinding{Role: declared.Name, Permission: name})
+			}
+		}
+		// Grants of deleted permissions go with them
+		for _, name := range existing.Permissions {
+			if !grants[name] && declaredPermissions[name] {
+				plan.Revoke = append(plan.Revoke, model.PolicyBinding{Role: declared.Name, Permission: name})
+			}
+		}
+	}
+
+	for _, role := range current.Roles {
+		if !declaredRoles[role.Name] {
+			plan.DeleteRoles = append(plan.DeleteRoles, role.Name)
+		}
+	}
+	for _, name := range current.Permissions {
+		if !declaredPermissions[name] {
+			plan.DeletePermissions = append(plan.DeletePermissions, name)
+		}
+	}
+	sort.Strings(plan.DeleteRoles)
+	sort.Strings(plan.DeletePermissions)
+	return plan
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPolicyRepo struct {
+	mock.Mock
+}
+
+func (m *MockPolicyRepo) ApplyPolicyPlan(ctx context.Context, plan model.PolicyPlan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+// newPolicyMocks returns repositories holding the admin role, granting
+// manage_users and old_permission, and the legacy role.
+func newPolicyMocks() (*MockPolicyRepo, *MockRoleRepo, *MockPermissionRepo) {
+	manageUsers := model.Permission{ID: 1, Name: "manage_users"}
+	oldPermission := model.Permission{ID: 2, Name: "old_permission"}
+
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("ListRoles", mock.Anything).Return([]model.Role{
+		{ID: 1, Name: "admin", Permissions: []model.Permission{manageUsers, oldPermission}},
+		{ID: 2, Name: "legacy"},
+	}, model.PageInfo{}, nil)
+	permissionRepo := new(MockPermissionRepo)
+	permissionRepo.On("ListPermissions", mock.Anything).Return([]model.Permission{manageUsers, oldPermission}, model.PageInfo{}, nil)
+	return new(MockPolicyRepo), roleRepo, permissionRepo
+}
+
+func TestPolicyPlan(t *testing.T) {
+	t.Run("Changes towards the policy", func(t *testing.T) {
+		policyRepo, roleRepo, permissionRepo := newPolicyMocks()
+		useCase := NewPolicyUseCase(policyRepo, roleRepo, permissionRepo)
+
+		plan, err := useCase.Plan(context.Background(), model.Policy{
+			Permissions: []string{"manage_users", "manage_roles"},
+			Roles: []model.PolicyRole{
+				{Name: "admin", RequireMFA: true, Permissions: []string{"manage_roles"}},
+				{Name: "auditor", Permissions: []string{"manage_users"}},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.PolicyPlan{
+			CreatePermissions: []string{"manage_roles"},
+			CreateRoles:       []model.Role{{Name: "auditor"}},
+			UpdateRoles:       []model.Role{{Name: "admin", RequireMFA: true}},
+			// The grant of old_permission goes with the permission
+			Revoke:            []model.PolicyBinding{{Role: "admin", Permission: "manage_users"}},
+			Grant:             []model.PolicyBinding{{Role: "admin", Permission: "manage_roles"}, {Role: "auditor", Permission: "manage_users"}},
+			DeleteRoles:       []string{"legacy"},
+			DeletePermissions: []string{"old_permission"},
+		}, plan)
+		policyRepo.AssertNotCalled(t, "ApplyPolicyPlan", mock.Anything)
+	})
+
+	t.Run("Matching policy", func(t *testing.T) {
+		policyRepo, roleRepo, permissionRepo := newPolicyMocks()
+		useCase := NewPolicyUseCase(policyRepo, roleRepo, permissionRepo)
+
+		plan, err := useCase.Plan(context.Background(), model.Policy{
+			Permissions: []string{"manage_users", "old_permission"},
+			Roles: []model.PolicyRole{
+				{Name: "admin", Permissions: []string{"old_permission", "manage_users"}},
+				{Name: "legacy"},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, plan.Empty())
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		useCase := NewPolicyUseCase(new(MockPolicyRepo), new(MockRoleRepo), new(MockPermissionRepo))
+
+		_, err := useCase.Plan(context.Background(), model.Policy{Roles: []model.PolicyRole{{Name: "admin", Permissions: []string{"manage_users"}}}})
+
+		assert.ErrorIs(t, err, domain.ErrValidation)
+	})
+}
+
+func TestPolicyApply(t *testing.T) {
+	t.Run("Applies the plan", func(t *testing.T) {
+		policyRepo, roleRepo, permissionRepo := newPolicyMocks()
+		useCase := NewPolicyUseCase(policyRepo, roleRepo, permissionRepo)
+		expected := model.PolicyPlan{
+			DeleteRoles:       []string{"admin", "legacy"},
+			DeletePermissions: []string{"manage_users", "old_permission"},
+		}
+		policyRepo.On("ApplyPolicyPlan", expected).Return(nil)
+
+		plan, err := useCase.Apply(context.Background(), model.Policy{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, plan)
+		policyRepo.AssertExpectations(t)
+	})
+
+	t.Run("Nothing to apply", func(t *testing.T) {
+		policyRepo, roleRepo, permissionRepo := newPolicyMocks()
+		useCase := NewPolicyUseCase(policyRepo, roleRepo, permissionRepo)
+
+		_, err := useCase.Apply(context.Background(), model.Policy{
+			Permissions: []string{"manage_users", "old_permission"},
+			Roles:       []model.PolicyRole{{Name: "admin", Permissions: []string{"manage_users", "old_permission"}}, {Name: "legacy"}},
+		})
+
+		assert.NoError(t, err)
+		policyRepo.AssertNotCalled(t, "ApplyPolicyPlan", mock.Anything)
+	})
+
+	t.Run("Failed transaction", func(t *testing.T) {
+		policyRepo, roleRepo, permissionRepo := newPolicyMocks()
+		useCase := NewPolicyUseCase(policyRepo, roleRepo, permissionRepo)
+		policyRepo.On("ApplyPolicyPlan", mock.Anything).Return(errors.New("database error"))
+
+		_, err := useCase.Apply(context.Background(), model.Policy{})
+
+		assert.EqualError(t, err, "database error")
+	})
+}
@@ -1,26 +1,71 @@
 package usecase
 
 import (
+	"context"
 	"go-multirole/domain"
 	"go-multirole/model"
 )
 
 type roleUseCase struct {
-	roleRepo domain.RoleRepo
+	roleRepo   domain.RoleRepo
+	unitOfWork domain.UnitOfWork
 }
 
-func NewRoleUseCase(roleRepo domain.RoleRepo) domain.RoleUseCase {
+func NewRoleUseCase(roleRepo domain.RoleRepo, unitOfWork domain.UnitOfWork) domain.RoleUseCase {
 	return &roleUseCase{
-		roleRepo: roleRepo,
+		roleRepo:   roleRepo,
+		unitOfWork: unitOfWork,
 	}
 }
 
 // CreateRole implements domain.RoleUseCase.
-func (r *roleUseCase) CreateRole(role model.Role) (model.Role, error) {
-	return r.roleRepo.CreateRole(role)
+func (r *roleUseCase) CreateRole(ctx context.Context, role model.Role) (model.Role, error) {
+	return r.roleRepo.CreateRole(ctx, role)
+}
+
+// CreateRoleWithAccess implements domain.RoleUseCase. The role is returned
+// with its permissions.
+func (r *roleUseCase) CreateRoleWithAccess(ctx context.Context, role model.Role, permissionIDs []uint, userIDs []uint) (model.Role, error) {
+	err := r.unitOfWork.Do(ctx, func(repos domain.Repos) error {
+		created, err := repos.Roles.CreateRole(ctx, role)
+		if err != nil {
+			return err
+		}
+		for _, permissionID := range permissionIDs {
+			if err := repos.Roles.AssignPermissionToRole(ctx, created.ID, permissionID); err != nil {
+				return err
+			}
+		}
+		for _, userID := range userIDs {
+			if err := repos.Users.AssignRoleToUser(ctx, userID, created.ID); err != nil {
+				return err
+			}
+		}
+		role, err = repos.Roles.FindRoleByName(ctx, created.Name)
+		return err
+	})
+	if err != nil {
+		return model.Role{}, err
+	}
+	return role, nil
+}
+
+// ListRoles implements domain.RoleUseCase.
+func (r *roleUseCase) ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error) {
+	return r.roleRepo.ListRoles(ctx, filter)
+}
+
+// FindRoleByName implements domain.RoleUseCase.
+func (r *roleUseCase) FindRoleByName(ctx context.Context, name string) (model.Role, error) {
+	return r.roleRepo.FindRoleByName(ctx, name)
 }
 
 // AssignPermissionToRole implements domain.RoleUseCase.
-func (r *roleUseCase) AssignPermissionToRole(roleID string, permissionID string) error {
-	return r.roleRepo.AssignPermissionToRole(roleID, permissionID)
+func (r *roleUseCase) AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error {
+	return r.roleRepo.AssignPermissionToRole(ctx, roleID, permissionID)
+}
+
+// RevokePermissionFromRole implements domain.RoleUseCase.
+func (r *roleUseCase) RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error {
+	return r.roleRepo.RevokePermissionFromRole(ctx, roleID, permissionID)
 }
@@ -1,7 +1,9 @@
 package usecase
 
 import (
+	"context"
 	"errors"
+	"go-multirole/domain"
 	"go-multirole/model"
 	"testing"
 
@@ -14,12 +16,27 @@ type MockRoleRepo struct {
 	mock.Mock
 }
 
-func (m *MockRoleRepo) CreateRole(role model.Role) (model.Role, error) {
+func (m *MockRoleRepo) CreateRole(ctx context.Context, role model.Role) (model.Role, error) {
 	args := m.Called(role)
 	return args.Get(0).(model.Role), args.Error(1)
 }
 
-func (m *MockRoleRepo) AssignPermissionToRole(roleID string, permissionID string) error {
+func (m *MockRoleRepo) ListRoles(ctx context.Context, filter model.RoleFilter) ([]model.Role, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.Role), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockRoleRepo) FindRoleByName(ctx context.Context, name string) (model.Role, error) {
+	args := m.Called(name)
+	return args.Get(0).(model.Role), args.Error(1)
+}
+
+func (m *MockRoleRepo) AssignPermissionToRole(ctx context.Context, roleID uint, permissionID uint) error {
+	args := m.Called(roleID, permissionID)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) RevokePermissionFromRole(ctx context.Context, roleID uint, permissionID uint) error {
 	args := m.Called(roleID, permissionID)
 	return args.Error(0)
 }
@@ -38,10 +55,10 @@ func TestCreateRole(t *testing.T) {
 	mockRepo.On("CreateRole", testRole).Return(testRole, nil)
 
 	// Create the UseCase with the mocked repository
-	useCase := NewRoleUseCase(mockRepo)
+	useCase := NewRoleUseCase(mockRepo, nil)
 
 	// Call the method under test
-	result, err := useCase.CreateRole(testRole)
+	result, err := useCase.CreateRole(context.Background(), testRole)
 
 	// Assert the expectations
 	assert.NoError(t, err)
@@ -65,10 +82,10 @@ func TestCreateRole_Error(t *testing.T) {
 	mockRepo.On("CreateRole", testRole).Return(model.Role{}, errors.New("failed to create role"))
 
 	// Create the UseCase with the mocked repository
-	useCase := NewRoleUseCase(mockRepo)
+	useCase := NewRoleUseCase(mockRepo, nil)
 
 	// Call the method under test
-	result, err := useCase.CreateRole(testRole)
+	result, err := useCase.CreateRole(context.Background(), testRole)
 
 	// Assert that an error occurred
 	assert.Error(t, err)
@@ -83,17 +100,17 @@ func TestAssignPermissionToRole(t *testing.T) {
 	mockRepo := new(MockRoleRepo)
 
 	// Define the test input
-	roleID := "1"
-	permissionID := "101"
+	roleID := uint(1)
+	permissionID := uint(101)
 
 	// Set up expectations: mock the AssignPermissionToRole method
 	mockRepo.On("AssignPermissionToRole", roleID, permissionID).Return(nil)
 
 	// Create the UseCase with the mocked repository
-	useCase := NewRoleUseCase(mockRepo)
+	useCase := NewRoleUseCase(mockRepo, nil)
 
 	// Call the method under test
-	err := useCase.AssignPermissionToRole(roleID, permissionID)
+	err := useCase.AssignPermissionToRole(context.Background(), roleID, permissionID)
 
 	// Assert that there is no error
 	assert.NoError(t, err)
@@ -107,17 +124,17 @@ func TestAssignPermissionToRole_Error(t *testing.T) {
 	mockRepo := new(MockRoleRepo)
 
 	// Define the test input
-	roleID := "1"
-	permissionID := "101"
+	roleID := uint(1)
+	permissionID := uint(101)
 
 	// Set up expectations: simulate an error returned by AssignPermissionToRole
 	mockRepo.On("AssignPermissionToRole", roleID, permissionID).Return(errors.New("failed to assign permission"))
 
 	// Create the UseCase with the mocked repository
-	useCase := NewRoleUseCase(mockRepo)
+	useCase := NewRoleUseCase(mockRepo, nil)
 
 	// Call the method under test
-	err := useCase.AssignPermissionToRole(roleID, permissionID)
+	err := useCase.AssignPermissionToRole(context.Background(), roleID, permissionID)
 
 	// Assert that an error occurred
 	assert.Error(t, err)
@@ -125,3 +142,54 @@ func TestAssignPermissionToRole_Error(t *testing.T) {
 	// Assert that the AssignPermissionToRole method was called with the correct arguments
 	mockRepo.AssertExpectations(t)
 }
+
+// fakeUnitOfWork hands its function the mocked repositories and records
+// whether the work would have been committed.
+type fakeUnitOfWork struct {
+	repos     domain.Repos
+	committed bool
+}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(repos domain.Repos) error) error {
+	err := fn(f.repos)
+	f.committed = err == nil
+	return err
+}
+
+func TestCreateRoleWithAccess(t *testing.T) {
+	role := model.Role{Name: "auditor"}
+	created := model.Role{ID: 5, Name: "auditor"}
+
+	t.Run("Success", func(t *testing.T) {
+		roleRepo, userRepo := new(MockRoleRepo), new(MockUserRepo)
+		unitOfWork := &fakeUnitOfWork{repos: domain.Repos{Roles: roleRepo, Users: userRepo}}
+		roleRepo.On("CreateRole", role).Return(created, nil)
+		roleRepo.On("AssignPermissionToRole", uint(5), uint(1)).Return(nil)
+		roleRepo.On("AssignPermissionToRole", uint(5), uint(2)).Return(nil)
+		userRepo.On("AssignRoleToUser", uint(9), uint(5)).Return(nil)
+		loaded := model.Role{ID: 5, Name: "auditor", Permissions: []model.Permission{{ID: 1}, {ID: 2}}}
+		roleRepo.On("FindRoleByName", "auditor").Return(loaded, nil)
+
+		result, err := NewRoleUseCase(new(MockRoleRepo), unitOfWork).CreateRoleWithAccess(context.Background(), role, []uint{1, 2}, []uint{9})
+
+		assert.NoError(t, err)
+		assert.Equal(t, loaded, result)
+		assert.True(t, unitOfWork.committed)
+		roleRepo.AssertExpectations(t)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		roleRepo, userRepo := new(MockRoleRepo), new(MockUserRepo)
+		unitOfWork := &fakeUnitOfWork{repos: domain.Repos{Roles: roleRepo, Users: userRepo}}
+		roleRepo.On("CreateRole", role).Return(created, nil)
+		userRepo.On("AssignRoleToUser", uint(9), uint(5)).Return(domain.NotFound("user not found"))
+
+		result, err := NewRoleUseCase(new(MockRoleRepo), unitOfWork).CreateRoleWithAccess(context.Background(), role, nil, []uint{9})
+
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.Equal(t, model.Role{}, result)
+		assert.False(t, unitOfWork.committed, "The role is rolled back with the assignment")
+		roleRepo.AssertNotCalled(t, "FindRoleByName", mock.Anything)
+	})
+}
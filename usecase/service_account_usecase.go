@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"time"
+)
+
+// lastUsedResolution limits how often last-used tracking writes to the database.
+const lastUsedResolution = time.Minute
+
+var errInvalidAPIKey = domain.Unauthorized("invalid api key")
+
+type serviceAccountUseCase struct {
+	serviceAccountRepo domain.ServiceAccountRepo
+}
+
+func NewServiceAccountUseCase(serviceAccountRepo domain.ServiceAccountRepo) domain.ServiceAccountUseCase {
+	return &serviceAccountUseCase{
+		serviceAccountRepo: serviceAccountRepo,
+	}
+}
+
+// CreateServiceAccount implements domain.ServiceAccountUseCase.
+func (s *serviceAccountUseCase) CreateServiceAccount(ctx context.Context, user model.User) (model.User, error) {
+	if user.Username == "" {
+		return model.User{}, domain.Validation("username is required")
+	}
+	return s.serviceAccountRepo.CreateServiceAccount(ctx, user)
+}
+
+// CreateAPIKey issues a key for a service account. The plain key is returned
+// only here; afterwards it can't be recovered.
+func (s *serviceAccountUseCase) CreateAPIKey(ctx context.Context, userID uint, apiKey model.APIKey) (string, model.APIKey, error) {
+	account, err := s.serviceAccountRepo.FindServiceAccount(ctx, userID)
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return "", model.APIKey{}, domain.Validation("expires_at must be in the future")
+	}
+
+	key, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+
+	apiKey.ID = 0
+	apiKey.UserID = account.ID
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = utils.HashAPIKey(key)
+	apiKey.LastUsedAt = nil
+	apiKey.RevokedAt = nil
+
+	created, err := s.serviceAccountRepo.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	return key, created, nil
+}
+
+// ListAPIKeys implements domain.ServiceAccountUseCase.
+func (s *serviceAccountUseCase) ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	if _, err := s.serviceAccountRepo.FindServiceAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.serviceAccountRepo.ListAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey implements domain.ServiceAccountUseCase.
+func (s *serviceAccountUseCase) RevokeAPIKey(ctx context.Context, userID uint, keyID uint) error {
+	return s.serviceAccountRepo.RevokeAPIKey(ctx, userID, keyID, time.Now())
+}
+
+// AuthenticateAPIKey resolves a presented key to its stored record.
+func (s *serviceAccountUseCase) AuthenticateAPIKey(ctx context.Context, key string) (model.APIKey, error) {
+	prefix, ok := utils.APIKeyPrefix(key)
+	if !ok {
+		return model.APIKey{}, errInvalidAPIKey
+	}
+
+	apiKey, err := s.serviceAccountRepo.FindAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return model.APIKey{}, errInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(utils.HashAPIKey(key))) != 1 {
+		return model.APIKey{}, errInvalidAPIKey
+	}
+
+	now := time.Now()
+	if !apiKey.Usable(now) {
+		return model.APIKey{}, domain.Unauthorized("api key is expired or revoked")
+	}
+
+	// Disabling the account cuts off its keys without revoking them
+	account, err := s.serviceAccountRepo.FindServiceAccount(ctx, apiKey.UserID)
+	if err != nil {
+		return model.APIKey{}, errInvalidAPIKey
+	}
+	if !account.Active() {
+		return model.APIKey{}, domain.ErrAccountInactive
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution {
+		if err := s.serviceAccountRepo.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+			return model.APIKey{}, err
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	return apiKey, nil
+}
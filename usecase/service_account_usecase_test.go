@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock the ServiceAccountRepo interface
+type MockServiceAccountRepo struct {
+	mock.Mock
+}
+
+func (m *MockServiceAccountRepo) CreateServiceAccount(ctx context.Context, user model.User) (model.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockServiceAccountRepo) FindServiceAccount(ctx context.Context, userID uint) (model.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockServiceAccountRepo) CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error) {
+	args := m.Called(apiKey)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockServiceAccountRepo) FindAPIKeyByPrefix(ctx context.Context, prefix string) (model.APIKey, error) {
+	args := m.Called(prefix)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockServiceAccountRepo) ListAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockServiceAccountRepo) RevokeAPIKey(ctx context.Context, userID uint, keyID uint, revokedAt time.Time) error {
+	args := m.Called(userID, keyID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockServiceAccountRepo) TouchAPIKey(ctx context.Context, keyID uint, usedAt time.Time) error {
+	args := m.Called(keyID, usedAt)
+	return args.Error(0)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	mockRepo := new(MockServiceAccountRepo)
+	useCase := NewServiceAccountUseCase(mockRepo)
+
+	t.Run("Issues a hashed key for a service account", func(t *testing.T) {
+		mockRepo.On("FindServiceAccount", uint(5)).Return(model.User{ID: 5, ServiceAccount: true}, nil).Once()
+		var stored model.APIKey
+		mockRepo.On("CreateAPIKey", mock.AnythingOfType("model.APIKey")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(model.APIKey)
+		}).Return(model.APIKey{ID: 1, UserID: 5, Name: "nightly-batch"}, nil).Once()
+
+		key, apiKey, err := useCase.CreateAPIKey(context.Background(), 5, model.APIKey{Name: "nightly-batch", Scopes: []string{"read"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), apiKey.ID)
+		assert.Equal(t, uint(5), stored.UserID)
+		assert.Equal(t, utils.HashAPIKey(key), stored.KeyHash)
+		assert.Contains(t, key, stored.Prefix)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects accounts that are not service accounts", func(t *testing.T) {
+		mockRepo.On("FindServiceAccount", uint(1)).Return(model.User{}, errors.New("record not found")).Once()
+
+		_, _, err := useCase.CreateAPIKey(context.Background(), 1, model.APIKey{Name: "human"})
+
+		assert.EqualError(t, err, "record not found")
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	key, prefix, _ := utils.GenerateAPIKey()
+	stored := model.APIKey{ID: 1, UserID: 5, Prefix: prefix, KeyHash: utils.HashAPIKey(key)}
+
+	t.Run("Accepts a valid key and tracks its use", func(t *testing.T) {
+		mockRepo := new(MockServiceAccountRepo)
+		mockRepo.On("FindAPIKeyByPrefix", prefix).Return(stored, nil)
+		mockRepo.On("FindServiceAccount", uint(5)).Return(model.User{ID: 5, ServiceAccount: true, Status: model.UserStatusActive}, nil)
+		mockRepo.On("TouchAPIKey", uint(1), mock.AnythingOfType("time.Time")).Return(nil)
+		useCase := NewServiceAccountUseCase(mockRepo)
+
+		apiKey, err := useCase.AuthenticateAPIKey(context.Background(), key)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(5), apiKey.UserID)
+		assert.NotNil(t, apiKey.LastUsedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects a key with the wrong secret", func(t *testing.T) {
+		mockRepo := new(MockServiceAccountRepo)
+		mockRepo.On("FindAPIKeyByPrefix", prefix).Return(stored, nil)
+		useCase := NewServiceAccountUseCase(mockRepo)
+
+		_, err := useCase.AuthenticateAPIKey(context.Background(), prefix+"_guessed")
+
+		assert.EqualError(t, err, "invalid api key")
+		mockRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects a revoked key", func(t *testing.T) {
+		revokedAt := time.Now().Add(-time.Minute)
+		revoked := stored
+		revoked.RevokedAt = &revokedAt
+
+		mockRepo := new(MockServiceAccountRepo)
+		mockRepo.On("FindAPIKeyByPrefix", prefix).Return(revoked, nil)
+		useCase := NewServiceAccountUseCase(mockRepo)
+
+		_, err := useCase.AuthenticateAPIKey(context.Background(), key)
+
+		assert.EqualError(t, err, "api key is expired or revoked")
+	})
+
+	t.Run("Rejects keys of a disabled account", func(t *testing.T) {
+		mockRepo := new(MockServiceAccountRepo)
+		mockRepo.On("FindAPIKeyByPrefix", prefix).Return(stored, nil)
+		mockRepo.On("FindServiceAccount", uint(5)).Return(model.User{ID: 5, ServiceAccount: true, Status: model.UserStatusDisabled}, nil)
+		useCase := NewServiceAccountUseCase(mockRepo)
+
+		_, err := useCase.AuthenticateAPIKey(context.Background(), key)
+
+		assert.ErrorIs(t, err, domain.ErrAccountInactive)
+		mockRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+	})
+}
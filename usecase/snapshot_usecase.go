@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type snapshotUseCase struct {
+	snapshotRepo domain.SnapshotRepo
+}
+
+func NewSnapshotUseCase(snapshotRepo domain.SnapshotRepo) domain.SnapshotUseCase {
+	return &snapshotUseCase{
+		snapshotRepo: snapshotRepo,
+	}
+}
+
+// Export implements domain.SnapshotUseCase.
+func (s *snapshotUseCase) Export(ctx context.Context, options model.ExportOptions) (model.Snapshot, error) {
+	snapshot, err := s.snapshotRepo.ExportSnapshot(ctx, options.IncludePasswordHashes)
+	if err != nil {
+		return model.Snapshot{}, err
+	}
+	snapshot.ExportedAt = time.Now().UTC()
+	return snapshot, nil
+}
+
+// Import implements domain.SnapshotUseCase. It compares the snapshot with the
+// whole database, so a dry run reports exactly what the import would do.
+func (s *snapshotUseCase) Import(ctx context.Context, snapshot model.Snapshot, options model.ImportOptions) (model.ImportPlan, error) {
+	fields := snapshot.Validate()
+	for i, user := range snapshot.Users {
+		if user.PasswordHash != "" && !utils.SupportedHash(user.PasswordHash) {
+			fields = append(fields, model.FieldError{Name: fmt.Sprintf("users[%d].password_hash", i), Reason: "must be an argon2id or bcrypt hash with supported parameters"})
+		}
+	}
+	if len(fields) > 0 {
+		return model.ImportPlan{}, domain.InvalidFields(fields...)
+	}
+
+	current, err := s.snapshotRepo.ExportSnapshot(ctx, false)
+	if err != nil {
+		return model.ImportPlan{}, err
+	}
+	plan := planImport(current, snapshot, options.Mode == model.ImportModeReplace)
+	if options.DryRun || plan.Empty() {
+		return plan, nil
+	}
+	if err := s.snapshotRepo.ApplyImportPlan(ctx, plan); err != nil {
+		return model.ImportPlan{}, err
+	}
+	return plan, nil
+}
+
+// planImport returns the changes importing the snapshot into the current
+// state. Account details of existing users are never changed, only reported
+// as conflicts, and so are role settings when merging.
+func planImport(current model.Snapshot, imported model.Snapshot, replace bool) model.ImportPlan {
+	plan := model.ImportPlan{PolicyPlan: diffPolicy(current.Policy(), imported.Policy())}
+	if !replace {
+		for _, role := range plan.UpdateRoles {
+			plan.Conflicts = append(plan.Conflicts, model.ImportConflict{
+				Entity:   "role",
+				Name:     role.Name,
+				Field:    "require_mfa",
+				Current:  strconv.FormatBool(!role.RequireMFA),
+				Imported: strconv.FormatBool(role.RequireMFA),
+			})
+		}
+		plan.UpdateRoles, plan.Revoke, plan.DeleteRoles, plan.DeletePermissions = nil, nil, nil, nil
+	}
+
+	// Assignments of deleted roles go with them
+	deletedRoles := map[string]bool{}
+	for _, name := range plan.DeleteRoles {
+		deletedRoles[name] = true
+	}
+	unassign := func(user model.SnapshotUser, keep map[string]bool) {
+		for _, name := range user.Roles {
+			if !keep[name] && !deletedRoles[name] {
+				plan.Unassign = append(plan.Unassign, model.UserBinding{Username: user.Username, Role: name})
+			}
+		}
+	}
+
+	// Usernames are unique regardless of case
+	existingUsers := map[string]model.SnapshotUser{}
+	for _, user := range current.Users {
+		existingUsers[strings.ToLower(user.Username)] = user
+	}
+	importedUsers := map[string]bool{}
+	for _, user := range imported.Users {
+		importedUsers[strings.ToLower(user.Username)] = true
+		existing, ok := existingUsers[strings.ToLower(user.Username)]
+		if ok {
+			plan.Conflicts = append(plan.Conflicts, userConflicts(existing, user)...)
+		} else {
+			plan.CreateUsers = append(plan.CreateUsers, user)
+		}
+
+		assigned := map[string]bool{}
+		for _, name := range existing.Roles {
+			assigned[name] = true
+		}
+		wanted := map[string]bool{}
+		for _, name := range user.Roles {
+			wanted[name] = true
+			if !assigned[name] {
+				plan.Assign = append(plan.Assign, model.UserBinding{Username: user.Username, Role: name})
+			}
+		}
+		if replace {
+			unassign(existing, wanted)
+		}
+	}
+	if replace {
+		for _, user := range current.Users {
+			if !importedUsers[strings.ToLower(user.Username)] {
+				unassign(user, nil)
+			}
+		}
+	}
+	return plan
+}
+
+// userConflicts returns the account details that differ between the existing
+// user and the imported one.
+func userConflicts(existing model.SnapshotUser, imported model.SnapshotUser) []model.ImportConflict {
+	// Users stored before statuses existed have none and count as active
+	status := func(status string) string {
+		if status == "" {
+			return model.UserStatusActive
+		}
+		return status
+	}
+	var conflicts []model.ImportConflict
+	for _, field := range []struct{ name, current, imported string }{
+		{"username", existing.Username, imported.Username},
+		{"email", existing.Email, imported.Email},
+		{"status", status(existing.Status), status(imported.Status)},
+		{"service_account", strconv.FormatBool(existing.ServiceAccount), strconv.FormatBool(imported.ServiceAccount)},
+	} {
+		if field.current != field.imported {
+			conflicts = append(conflicts, model.ImportConflict{
+				Entity:   "user",
+				Name:     existing.Username,
+				Field:    field.name,
+				Current:  field.current,
+				Imported: field.imported,
+			})
+		}
+	}
+	return conflicts
+}
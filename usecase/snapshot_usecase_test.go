@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSnapshotRepo struct {
+	mock.Mock
+}
+
+func (m *MockSnapshotRepo) ExportSnapshot(ctx context.Context, includePasswordHashes bool) (model.Snapshot, error) {
+	args := m.Called(includePasswordHashes)
+	return args.Get(0).(model.Snapshot), args.Error(1)
+}
+
+func (m *MockSnapshotRepo) ApplyImportPlan(ctx context.Context, plan model.ImportPlan) error {
+	args := m.Called(plan)
+	return args.Error(0)
+}
+
+// currentSnapshot holds alice, an admin, and the legacy role assigned to bob.
+var currentSnapshot = model.Snapshot{
+	Version:     model.SnapshotVersion,
+	Permissions: []string{"manage_users"},
+	Roles: []model.PolicyRole{
+		{Name: "admin", Permissions: []string{"manage_users"}},
+		{Name: "legacy"},
+	},
+	Users: []model.SnapshotUser{
+		{Username: "alice", Email: "alice@example.com", Status: model.UserStatusActive, Roles: []string{"admin"}},
+		{Username: "bob", Status: model.UserStatusActive, Roles: []string{"admin", "legacy"}},
+	},
+}
+
+// importedSnapshot requires MFA for admins, moves alice to the auditor role and
+// adds carol.
+var importedSnapshot = model.Snapshot{
+	Version:     model.SnapshotVersion,
+	Permissions: []string{"manage_users"},
+	Roles: []model.PolicyRole{
+		{Name: "admin", RequireMFA: true, Permissions: []string{"manage_users"}},
+		{Name: "auditor"},
+	},
+	Users: []model.SnapshotUser{
+		{Username: "alice", Email: "alice@new.example.com", Roles: []string{"auditor"}},
+		{Username: "carol", PasswordHash: "$2a$04$2S/G5zFbd9ChL9TYGaGqUOVAqD/ZpOmKgN9hnWw/fnk6tVbb0ypZq", Roles: []string{"admin"}},
+	},
+}
+
+func TestSnapshotExport(t *testing.T) {
+	mockRepo := new(MockSnapshotRepo)
+	useCase := NewSnapshotUseCase(mockRepo)
+	mockRepo.On("ExportSnapshot", true).Return(currentSnapshot, nil)
+
+	snapshot, err := useCase.Export(context.Background(), model.ExportOptions{IncludePasswordHashes: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, currentSnapshot.Users, snapshot.Users)
+	assert.False(t, snapshot.ExportedAt.IsZero())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSnapshotImport(t *testing.T) {
+	emailConflict := model.ImportConflict{Entity: "user", Name: "alice", Field: "email", Current: "alice@example.com", Imported: "alice@new.example.com"}
+
+	t.Run("Merge", func(t *testing.T) {
+		mockRepo := new(MockSnapshotRepo)
+		useCase := NewSnapshotUseCase(mockRepo)
+		mockRepo.On("ExportSnapshot", false).Return(currentSnapshot, nil)
+		expected := model.ImportPlan{
+			PolicyPlan:  model.PolicyPlan{CreateRoles: []model.Role{{Name: "auditor"}}},
+			CreateUsers: []model.SnapshotUser{importedSnapshot.Users[1]},
+			Assign:      []model.UserBinding{{Username: "alice", Role: "auditor"}, {Username: "carol", Role: "admin"}},
+			Conflicts: []model.ImportConflict{
+				{Entity: "role", Name: "admin", Field: "require_mfa", Current: "false", Imported: "true"},
+				emailConflict,
+			},
+		}
+		mockRepo.On("ApplyImportPlan", expected).Return(nil)
+
+		plan, err := useCase.Import(context.Background(), importedSnapshot, model.ImportOptions{Mode: model.ImportModeMerge})
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, plan)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Replace dry run", func(t *testing.T) {
+		mockRepo := new(MockSnapshotRepo)
+		useCase := NewSnapshotUseCase(mockRepo)
+		mockRepo.On("ExportSnapshot", false).Return(currentSnapshot, nil)
+
+		plan, err := useCase.Import(context.Background(), importedSnapshot, model.ImportOptions{Mode: model.ImportModeReplace, DryRun: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.ImportPlan{
+			PolicyPlan: model.PolicyPlan{
+				CreateRoles: []model.Role{{Name: "auditor"}},
+				UpdateRoles: []model.Role{{Name: "admin", RequireMFA: true}},
+				DeleteRoles: []string{"legacy"},
+			},
+			CreateUsers: []model.SnapshotUser{importedSnapshot.Users[1]},
+			// bob isn't in the snapshot and their legacy role goes with the role
+			Unassign:  []model.UserBinding{{Username: "alice", Role: "admin"}, {Username: "bob", Role: "admin"}},
+			Assign:    []model.UserBinding{{Username: "alice", Role: "auditor"}, {Username: "carol", Role: "admin"}},
+			Conflicts: []model.ImportConflict{emailConflict},
+		}, plan)
+		mockRepo.AssertNotCalled(t, "ApplyImportPlan", mock.Anything)
+	})
+
+	t.Run("Usernames differing in case", func(t *testing.T) {
+		mockRepo := new(MockSnapshotRepo)
+		useCase := NewSnapshotUseCase(mockRepo)
+		mockRepo.On("ExportSnapshot", false).Return(currentSnapshot, nil)
+		snapshot := currentSnapshot
+		snapshot.Users = []model.SnapshotUser{{Username: "Bob", Status: model.UserStatusActive, Roles: []string{"admin", "legacy"}}}
+
+		plan, err := useCase.Import(context.Background(), snapshot, model.ImportOptions{Mode: model.ImportModeMerge, DryRun: true})
+
+		assert.NoError(t, err)
+		assert.Empty(t, plan.CreateUsers, "Bob is the existing bob")
+		assert.Empty(t, plan.Assign)
+		assert.Equal(t, []model.ImportConflict{{Entity: "user", Name: "bob", Field: "username", Current: "bob", Imported: "Bob"}}, plan.Conflicts)
+	})
+
+	t.Run("Nothing to import", func(t *testing.T) {
+		mockRepo := new(MockSnapshotRepo)
+		useCase := NewSnapshotUseCase(mockRepo)
+		mockRepo.On("ExportSnapshot", false).Return(currentSnapshot, nil)
+
+		plan, err := useCase.Import(context.Background(), currentSnapshot, model.ImportOptions{Mode: model.ImportModeReplace})
+
+		assert.NoError(t, err)
+		assert.True(t, plan.Empty())
+		mockRepo.AssertNotCalled(t, "ApplyImportPlan", mock.Anything)
+	})
+
+	t.Run("Unsupported password hash", func(t *testing.T) {
+		useCase := NewSnapshotUseCase(new(MockSnapshotRepo))
+		snapshot := model.Snapshot{Version: model.SnapshotVersion, Users: []model.SnapshotUser{
+			{Username: "alice", PasswordHash: "5f4dcc3b5aa765d61d8327deb882cf99"},
+			{Username: "bob", PasswordHash: "$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		}}
+
+		_, err := useCase.Import(context.Background(), snapshot, model.ImportOptions{})
+
+		var domainErr *domain.Error
+		if assert.ErrorAs(t, err, &domainErr) {
+			assert.Equal(t, []model.FieldError{
+				{Name: "users[0].password_hash", Reason: "must be an argon2id or bcrypt hash with supported parameters"},
+				{Name: "users[1].password_hash", Reason: "must be an argon2id or bcrypt hash with supported parameters"},
+			}, domainErr.Fields)
+		}
+	})
+
+	t.Run("Failed transaction", func(t *testing.T) {
+		mockRepo := new(MockSnapshotRepo)
+		useCase := NewSnapshotUseCase(mockRepo)
+		mockRepo.On("ExportSnapshot", false).Return(currentSnapshot, nil)
+		mockRepo.On("ApplyImportPlan", mock.Anything).Return(errors.New("database error"))
+
+		_, err := useCase.Import(context.Background(), importedSnapshot, model.ImportOptions{})
+
+		assert.EqualError(t, err, "database error")
+	})
+}
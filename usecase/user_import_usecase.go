@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"strings"
+	"time"
+)
+
+// generatedPasswordLength is the length of passwords generated for imported users.
+const generatedPasswordLength = 24
+
+type userImportUseCase struct {
+	userImportRepo domain.UserImportRepo
+	roleRepo       domain.RoleRepo
+	userUseCase    domain.UserUseCase
+	notifier       domain.Notifier
+	invitationTTL  time.Duration
+	invitationURL  string
+}
+
+// NewUserImportUseCase creates the bulk user import. Passwords are checked
+// against the user use case's policy, and invitations are sent like those of
+// the invitation use case, expiring after invitationTTL.
+func NewUserImportUseCase(userImportRepo domain.UserImportRepo, roleRepo domain.RoleRepo, userUseCase domain.UserUseCase, notifier domain.Notifier, invitationTTL time.Duration, invitationURL string) domain.UserImportUseCase {
+	return &userImportUseCase{
+		userImportRepo: userImportRepo,
+		roleRepo:       roleRepo,
+		userUseCase:    userUseCase,
+		notifier:       notifier,
+		invitationTTL:  invitationTTL,
+		invitationURL:  invitationURL,
+	}
+}
+
+// Import implements domain.UserImportUseCase. Every row is validated before
+// any user is created, so an import in a transaction with an invalid row
+// doesn't touch the database. Invitations are sent once their user is stored.
+func (u *userImportUseCase) Import(ctx context.Context, rows []model.UserImportRow, options model.UserImportOptions, actorID uint) (model.UserImportReport, error) {
+	results := make([]model.UserImportResult, len(rows))
+	var (
+		imports []model.UserImport
+		indexes []int              // Row of each import
+		tokens  = map[int]string{} // Invitation token of each import
+	)
+	roleIDs := map[string]uint{}
+	lines := map[string]int{}
+	for i, row := range rows {
+		results[i] = model.UserImportResult{Line: row.Line, Username: row.Username}
+		fields := row.Validate(options.Password)
+		// Usernames are unique regardless of case
+		if line, ok := lines[strings.ToLower(row.Username)]; ok && row.Username != "" {
+			fields = append(fields, model.FieldError{Name: "username", Reason: fmt.Sprintf("%s also appears on line %d", row.Username, line)})
+		} else {
+			lines[strings.ToLower(row.Username)] = row.Line
+		}
+
+		imported := model.UserImport{User: model.User{Username: row.Username, Email: row.Email}}
+		for j, name := range row.Roles {
+			id, err := u.roleID(ctx, name, roleIDs)
+			if errors.Is(err, domain.ErrNotFound) {
+				fields = append(fields, model.FieldError{Name: fmt.Sprintf("roles[%d]", j), Reason: fmt.Sprintf("%s isn't a role", name)})
+				continue
+			}
+			if err != nil {
+				return model.UserImportReport{}, err
+			}
+			imported.RoleIDs = append(imported.RoleIDs, id)
+		}
+
+		var token string
+		if len(fields) == 0 {
+			var err error
+			token, fields, err = u.preparePassword(ctx, &imported, row, options.Password, actorID)
+			if err != nil {
+				return model.UserImportReport{}, err
+			}
+		}
+		if len(fields) > 0 {
+			results[i].Status = model.UserImportFailed
+			results[i].Error = "row has invalid fields"
+			results[i].Fields = fields
+			continue
+		}
+		if token != "" {
+			tokens[len(imports)] = token
+		}
+		imports = append(imports, imported)
+		indexes = append(indexes, i)
+	}
+
+	perRow := options.Mode == model.UserImportModePerRow
+	var (
+		users []model.User
+		errs  []error
+	)
+	if perRow || len(imports) == len(rows) {
+		users, errs = u.userImportRepo.ImportUsers(ctx, imports, perRow)
+	} else {
+		users, errs = make([]model.User, len(imports)), make([]error, len(imports))
+	}
+
+	for k, i := range indexes {
+		result := &results[i]
+		switch {
+		case errs[k] != nil:
+			result.Status = model.UserImportFailed
+			result.Error = errs[k].Error()
+		case users[k].ID == 0:
+			result.Status = model.UserImportSkipped
+			result.Error = "not created because another row failed"
+		case imports[k].Invitation != nil:
+			result.Status = model.UserImportInvited
+			result.UserID = users[k].ID
+			invitation := *imports[k].Invitation
+			if err := u.notifier.Notify(ctx, invitationNotification(invitation, u.invitationURL, tokens[k])); err != nil {
+				result.Error = "the invitation could not be sent, resend it: " + err.Error()
+			}
+		default:
+			result.Status = model.UserImportCreated
+			result.UserID = users[k].ID
+			if options.Password == model.UserImportPasswordGenerate {
+				result.Password = imports[k].User.Password
+			}
+		}
+	}
+
+	var report model.UserImportReport
+	for _, result := range results {
+		report.Add(result)
+	}
+	return report, nil
+}
+
+// preparePassword sets the password or invitation of the user to import. It
+// returns the invitation token, and the password policy violations.
+func (u *userImportUseCase) preparePassword(ctx context.Context, imported *model.UserImport, row model.UserImportRow, mode string, actorID uint) (string, []model.FieldError, error) {
+	switch mode {
+	case model.UserImportPasswordInvite:
+		token, err := utils.GenerateRandomHex(32)
+		if err != nil {
+			return "", nil, err
+		}
+		imported.Invitation = &model.Invitation{
+			Username:  row.Username,
+			Email:     row.Email,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: time.Now().Add(u.invitationTTL),
+		}
+		if actorID != 0 {
+			imported.Invitation.InvitedBy = &actorID
+		}
+		return token, nil, nil
+	case model.UserImportPasswordGenerate:
+		password, err := utils.GeneratePassword(generatedPasswordLength)
+		if err != nil {
+			return "", nil, err
+		}
+		imported.User.Password = password
+	default:
+		imported.User.Password = row.Password
+	}
+
+	err := u.userUseCase.ValidatePassword(ctx, imported.User, imported.User.Password)
+	var policyErr *model.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return "", nil, err
+	}
+	fields := make([]model.FieldError, 0, len(policyErr.Violations))
+	for _, violation := range policyErr.Violations {
+		fields = append(fields, model.FieldError{Name: "password", Reason: violation.Message})
+	}
+	return "", fields, nil
+}
+
+// roleID looks the role up by name, caching the ids of the roles found.
+func (u *userImportUseCase) roleID(ctx context.Context, name string, roleIDs map[string]uint) (uint, error) {
+	if id, ok := roleIDs[name]; ok {
+		return id, nil
+	}
+	role, err := u.roleRepo.FindRoleByName(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	roleIDs[name] = role.ID
+	return role.ID, nil
+}
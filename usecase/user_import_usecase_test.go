@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserImportRepo struct {
+	mock.Mock
+}
+
+func (m *MockUserImportRepo) ImportUsers(ctx context.Context, imports []model.UserImport, perRow bool) ([]model.User, []error) {
+	args := m.Called(imports, perRow)
+	return args.Get(0).([]model.User), args.Get(1).([]error)
+}
+
+func newUserImportMocks() (*MockUserImportRepo, *MockRoleRepo, *MockUserUseCase, *MockNotifier) {
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindRoleByName", "admin").Return(model.Role{ID: 3, Name: "admin"}, nil)
+	roleRepo.On("FindRoleByName", "ghost").Return(model.Role{}, domain.NotFound("role ghost not found"))
+	userUseCase := new(MockUserUseCase)
+	userUseCase.On("ValidatePassword", mock.Anything, "weak").Return(&model.PasswordPolicyError{Violations: []model.PolicyViolation{{Rule: "min_length", Message: "password must be at least 12 characters"}}})
+	userUseCase.On("ValidatePassword", mock.Anything, mock.Anything).Return(nil)
+	return new(MockUserImportRepo), roleRepo, userUseCase, new(MockNotifier)
+}
+
+func TestUserImport(t *testing.T) {
+	rows := []model.UserImportRow{
+		{Line: 2, Username: "alice", Password: "Secret-123456", Roles: []string{"admin"}},
+		{Line: 3, Username: "bob", Password: "weak", Roles: []string{"ghost"}},
+		{Line: 4, Username: "carol", Password: "Secret-123456"},
+	}
+	alice := model.UserImport{User: model.User{Username: "alice", Password: "Secret-123456"}, RoleIDs: []uint{3}}
+	carol := model.UserImport{User: model.User{Username: "carol", Password: "Secret-123456"}}
+
+	t.Run("Per row", func(t *testing.T) {
+		importRepo, roleRepo, userUseCase, notifier := newUserImportMocks()
+		useCase := NewUserImportUseCase(importRepo, roleRepo, userUseCase, notifier, time.Hour, "")
+		importRepo.On("ImportUsers", []model.UserImport{alice, carol}, true).
+			Return([]model.User{{ID: 10}, {}}, []error{nil, domain.Conflict("username carol is already taken")})
+
+		report, err := useCase.Import(context.Background(), rows, model.UserImportOptions{Mode: model.UserImportModePerRow}, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserImportReport{
+			Created: 1,
+			Failed:  2,
+			Rows: []model.UserImportResult{
+				{Line: 2, Username: "alice", Status: model.UserImportCreated, UserID: 10},
+				{Line: 3, Username: "bob", Status: model.UserImportFailed, Error: "row has invalid fields", Fields: []model.FieldError{{Name: "roles[0]", Reason: "ghost isn't a role"}}},
+				{Line: 4, Username: "carol", Status: model.UserImportFailed, Error: "username carol is already taken"},
+			},
+		}, report)
+		importRepo.AssertExpectations(t)
+	})
+
+	t.Run("Transaction with an invalid row", func(t *testing.T) {
+		importRepo, roleRepo, userUseCase, notifier := newUserImportMocks()
+		useCase := NewUserImportUseCase(importRepo, roleRepo, userUseCase, notifier, time.Hour, "")
+
+		report, err := useCase.Import(context.Background(), rows, model.UserImportOptions{}, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Skipped)
+		assert.Equal(t, model.UserImportSkipped, report.Rows[0].Status)
+		assert.Equal(t, model.UserImportFailed, report.Rows[1].Status)
+		importRepo.AssertNotCalled(t, "ImportUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Transaction rolled back", func(t *testing.T) {
+		importRepo, roleRepo, userUseCase, notifier := newUserImportMocks()
+		useCase := NewUserImportUseCase(importRepo, roleRepo, userUseCase, notifier, time.Hour, "")
+		importRepo.On("ImportUsers", []model.UserImport{alice, carol}, false).
+			Return([]model.User{{}, {}}, []error{nil, domain.Conflict("username carol is already taken")})
+
+		report, err := useCase.Import(context.Background(), []model.UserImportRow{rows[0], rows[2]}, model.UserImportOptions{Mode: model.UserImportModeTransaction}, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.UserImportResult{
+			{Line: 2, Username: "alice", Status: model.UserImportSkipped, Error: "not created because another row failed"},
+			{Line: 4, Username: "carol", Status: model.UserImportFailed, Error: "username carol is already taken"},
+		}, report.Rows)
+	})
+
+	t.Run("Password policy and duplicate rows", func(t *testing.T) {
+		importRepo, roleRepo, userUseCase, notifier := newUserImportMocks()
+		useCase := NewUserImportUseCase(importRepo, roleRepo, userUseCase, notifier, time.Hour, "")
+
+		report, err := useCase.Import(context.Background(), []model.UserImportRow{
+			{Line: 1, Username: "bob", Password: "weak"},
+			{Line: 2, Username: "bob", Password: "Secret-123456"},
+			{Line: 3, Username: "Bob", Password: "Secret-123456"},
+		}, model.UserImportOptions{}, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []model.FieldError{{Name: "password", Reason: "password must be at least 12 characters"}}, report.Rows[0].Fields)
+		assert.Equal(t, []model.FieldError{{Name: "username", Reason: "bob also appears on line 1"}}, report.Rows[1].Fields)
+		assert.Equal(t, []model.FieldError{{Name: "username", Reason: "Bob also appears on line 1"}}, report.Rows[2].Fields, "Usernames differing in case are duplicates")
+	})
+
+	t.Run("Generated passwords", func(t *testing.T) {
+		importRepo, roleRepo, userUseCase, notifier := newUserImportMocks()
+		useCase := NewUserImportUseCase(importRepo, roleRepo, userUseCase, notifier, time.Hour, "")
+		var imported []model.UserImport
+		importRepo.On("ImportUsers", mock.Anything, false).
+			Run(func(args mock.Arguments) { imported = args.Get(0).([]model.UserImport) }).
+			Return([]model.User{{ID: 10}}, []error{nil})
+
+		report, err := useCase.Import(context.Background(), []model.UserImportRow{{Line: 2, Username: "alice"}}, model.UserImportOptions{Password: model.UserImportPasswordGenerate}, 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, report.Rows[0].Password, generatedPasswordLength)
+		assert.Equal(t, imported[0].User.Password, report.Rows[0].Password, "The stored password is reported")
+	})
+
+	t.Run("Invitations", func(t *testing.T) {
+		importRepo, roleRepo, userUseCase, notifier := newUserImportMocks()
+		useCase := NewUserImportUseCase(importRepo, roleRepo, userUseCase, notifier, time.Hour, "https://app.example.com/accept")
+		var imported []model.UserImport
+		importRepo.On("ImportUsers", mock.Anything, false).
+			Run(func(args mock.Arguments) { imported = args.Get(0).([]model.UserImport) }).
+			Return([]model.User{{ID: 10}, {ID: 11}}, []error{nil, nil})
+		notifier.On("Notify", mock.MatchedBy(func(notification model.Notification) bool {
+			return notification.To == "alice@example.com" && strings.Contains(notification.Body, "https://app.example.com/accept?token=")
+		})).Return(nil)
+		notifier.On("Notify", mock.Anything).Return(errors.New("smtp unavailable"))
+
+		report, err := useCase.Import(context.Background(), []model.UserImportRow{
+			{Line: 2, Username: "alice", Email: "alice@example.com", Roles: []string{"admin"}},
+			{Line: 3, Username: "bob", Email: "bob@example.com"},
+		}, model.UserImportOptions{Password: model.UserImportPasswordInvite}, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Invited)
+		assert.Empty(t, report.Rows[0].Error)
+		assert.Equal(t, "the invitation could not be sent, resend it: smtp unavailable", report.Rows[1].Error)
+		if assert.NotNil(t, imported[0].Invitation) {
+			assert.Equal(t, []uint{3}, imported[0].RoleIDs)
+			assert.Equal(t, uint(1), *imported[0].Invitation.InvitedBy)
+			assert.Len(t, imported[0].Invitation.TokenHash, 64)
+		}
+		userUseCase.AssertNotCalled(t, "ValidatePassword", mock.Anything, mock.Anything)
+	})
+}
@@ -1,57 +1,405 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"go-multirole/config"
+	"fmt"
 	"go-multirole/domain"
 	"go-multirole/model"
 	"go-multirole/utils"
+	"log/slog"
+	"net/url"
+	"time"
 )
 
 type userUseCase struct {
-	userRepo domain.UserRepo
+	userRepo       domain.UserRepo
+	unitOfWork     domain.UnitOfWork
+	loginThrottle  domain.LoginThrottleUseCase
+	passwordHasher utils.PasswordHasher
+	passwordPolicy model.PasswordPolicy
+	tokens         *utils.TokenService
+	// dummyPasswordHash is compared against when the username doesn't exist
+	// so unknown and known usernames take the same time to reject.
+	dummyPasswordHash string
+	notifier          domain.Notifier
+	resetTTL          time.Duration
+	resetURL          string
 }
 
-func NewUserUseCase(userRepo domain.UserRepo) domain.UserUseCase {
+// NewUserUseCase creates the user use case. Passwords are verified with the
+// hasher, which must be the one the repository hashes with, and tokens signs
+// the tokens issued on login. Password reset tokens are sent through the
+// notifier and expire after resetTTL; resetURL is the page the token is
+// appended to, the bare token is sent when it is empty.
+func NewUserUseCase(userRepo domain.UserRepo, unitOfWork domain.UnitOfWork, loginThrottle domain.LoginThrottleUseCase, passwordHasher utils.PasswordHasher, passwordPolicy model.PasswordPolicy, tokens *utils.TokenService, notifier domain.Notifier, resetTTL time.Duration, resetURL string) domain.UserUseCase {
+	dummyPasswordHash, _ := passwordHasher.Hash("dummy password for timing")
 	return &userUseCase{
-		userRepo: userRepo,
+		userRepo:          userRepo,
+		unitOfWork:        unitOfWork,
+		loginThrottle:     loginThrottle,
+		passwordHasher:    passwordHasher,
+		passwordPolicy:    passwordPolicy,
+		tokens:            tokens,
+		dummyPasswordHash: dummyPasswordHash,
+		notifier:          notifier,
+		resetTTL:          resetTTL,
+		resetURL:          resetURL,
 	}
 }
 
-func (u *userUseCase) CreateUser(user model.User) (model.User, error) {
-	return u.userRepo.CreateUser(user)
+// CreateUser implements domain.UserUseCase.
+func (u *userUseCase) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	if err := u.ValidatePassword(ctx, user, user.Password); err != nil {
+		return model.User{}, err
+	}
+	return u.userRepo.CreateUser(ctx, user)
+}
+
+// ValidatePassword checks a new password of the user against the policy and
+// returns a *model.PasswordPolicyError listing every failed rule. The history
+// is only checked for existing users.
+func (u *userUseCase) ValidatePassword(ctx context.Context, user model.User, password string) error {
+	violations := u.passwordPolicy.Check(user.Username, password)
+	if password == "" {
+		return &model.PasswordPolicyError{Violations: violations}
+	}
+
+	if user.ID != 0 && u.passwordPolicy.HistorySize > 0 {
+		history, err := u.userRepo.ListPasswordHistory(ctx, user.ID, u.passwordPolicy.HistorySize)
+		if err != nil {
+			return err
+		}
+		for _, previous := range history {
+			if u.passwordHasher.Verify(previous.PasswordHash, password) {
+				violations = append(violations, model.PolicyViolation{
+					Rule:    "history",
+					Message: fmt.Sprintf("password must not match any of the last %d passwords", u.passwordPolicy.HistorySize),
+				})
+				break
+			}
+		}
+	}
+
+	if u.passwordPolicy.BreachedRangesDir != "" {
+		breached, err := utils.IsPasswordBreached(u.passwordPolicy.BreachedRangesDir, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, model.PolicyViolation{Rule: "breached", Message: "password has appeared in a data breach"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &model.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// ChangePassword replaces the password of a logged in user after checking the
+// current one, which counts towards the login throttle. All existing sessions
+// end, so a fresh access token is returned for the caller.
+func (u *userUseCase) ChangePassword(ctx context.Context, userID uint, currentPassword string, newPassword string, clientIP string) (string, error) {
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := u.loginThrottle.Check(ctx, user.Username, clientIP); err != nil {
+		return "", err
+	}
+	if user.ServiceAccount || !u.passwordHasher.Verify(user.Password, currentPassword) {
+		return "", u.rejectLogin(ctx, user.Username, clientIP)
+	}
+
+	if err := u.ValidatePassword(ctx, user, newPassword); err != nil {
+		return "", err
+	}
+	if err := u.userRepo.UpdatePassword(ctx, user.ID, newPassword, time.Now()); err != nil {
+		return "", err
+	}
+
+	return u.tokens.Issue(user.ID)
+}
+
+// RequestPasswordReset sends a single-use reset token to the account's email.
+// It succeeds without sending anything for unknown identifiers, accounts
+// without an email, inactive users and service accounts, so it can't be used
+// to find users. Requests are limited per identifier and client IP by the
+// login throttle.
+func (u *userUseCase) RequestPasswordReset(ctx context.Context, identifier string, clientIP string) error {
+	if err := u.loginThrottle.LimitPasswordReset(ctx, identifier, clientIP); err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.FindUserByIdentifier(ctx, identifier)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.ServiceAccount || user.Email == "" || !user.Active() {
+		return nil
+	}
+
+	token, err := utils.GenerateRandomHex(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(u.resetTTL)
+	err = u.userRepo.CreatePasswordResetToken(ctx, model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return u.notifier.Notify(ctx, model.Notification{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("A password reset was requested for %s.\n\nUse %s before %s to choose a new password. If you didn't request it, ignore this message.",
+			user.Username, u.resetLink(token), expiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+func (u *userUseCase) resetLink(token string) string {
+	if u.resetURL == "" {
+		return "the reset token " + token
+	}
+	return tokenLink(u.resetURL, token)
+}
+
+// tokenLink appends the token to baseURL as the token query parameter.
+func tokenLink(baseURL string, token string) string {
+	link, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset.
+// The token is consumed even if another request raced to use it, and only
+// together with the password change. The account's login failures and
+// sessions are cleared.
+func (u *userUseCase) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	resetToken, err := u.userRepo.FindPasswordResetToken(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !resetToken.Usable(now) {
+		return domain.ErrInvalidResetToken
+	}
+
+	user, err := u.userRepo.FindUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.Active() {
+		return domain.ErrInvalidResetToken
+	}
+	if err := u.ValidatePassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// The token stays usable when the password can't be stored
+	err = u.unitOfWork.Do(ctx, func(repos domain.Repos) error {
+		consumed, err := repos.Users.MarkPasswordResetTokenUsed(ctx, resetToken.ID, now)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return domain.ErrInvalidResetToken
+		}
+		return repos.Users.UpdatePassword(ctx, user.ID, newPassword, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	return u.loginThrottle.RecordSuccess(ctx, user.Username)
+}
+
+// SessionValid reports whether a token issued to the user at issuedAt is still
+// accepted, i.e. it doesn't predate the last password change. Tokens of
+// inactive users are rejected with domain.ErrAccountInactive.
+func (u *userUseCase) SessionValid(ctx context.Context, userID uint, issuedAt time.Time) (bool, error) {
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !user.Active() {
+		return false, domain.ErrAccountInactive
+	}
+	return user.SessionValid(issuedAt), nil
+}
+
+// ListUsers implements domain.UserUseCase.
+func (u *userUseCase) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	return u.userRepo.ListUsers(ctx, filter)
+}
+
+// ChangeStatus moves a user through the lifecycle on behalf of actorID, who
+// can't change their own status. The user and their history are kept in every
+// state; only active users can authenticate.
+func (u *userUseCase) ChangeStatus(ctx context.Context, userID uint, request model.UserStatusRequest, actorID uint) (model.User, error) {
+	if userID == actorID {
+		return model.User{}, domain.Conflict("%w: you cannot change your own status", model.ErrInvalidStatusTransition)
+	}
+
+	user, err := u.userRepo.FindUserByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+	from := user.Status
+	if from == "" {
+		from = model.UserStatusActive
+	}
+	if err := model.ValidateStatusTransition(from, request.Status); err != nil {
+		return model.User{}, domain.Conflict("%w", err)
+	}
+
+	change := model.UserStatusChange{UserID: user.ID, FromStatus: from, ToStatus: request.Status, Reason: request.Reason}
+	if actorID != 0 {
+		change.ChangedBy = &actorID
+	}
+	now := time.Now()
+	if err := u.userRepo.UpdateStatus(ctx, change, now); err != nil {
+		return model.User{}, err
+	}
+
+	user.Status = request.Status
+	user.StatusReason = request.Reason
+	user.StatusChangedAt = &now
+	return user, nil
+}
+
+// ListStatusChanges implements domain.UserUseCase.
+func (u *userUseCase) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	return u.userRepo.ListStatusChanges(ctx, userID)
+}
+
+// FindUserByIdentifier implements domain.UserUseCase.
+func (u *userUseCase) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	return u.userRepo.FindUserByIdentifier(ctx, identifier)
 }
 
 // AssignRoleToUser implements domain.UserUseCase.
-func (u *userUseCase) AssignRoleToUser(userId string, roleID string) error {
-	return u.userRepo.AssignRoleToUser(userId, roleID)
+func (u *userUseCase) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	return u.userRepo.AssignRoleToUser(ctx, userID, roleID)
+}
+
+// RevokeRoleFromUser implements domain.UserUseCase.
+func (u *userUseCase) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	return u.userRepo.RevokeRoleFromUser(ctx, userID, roleID)
 }
 
 // CheckUserPermission implements domain.UserUseCase.
-func (u *userUseCase) CheckUserPermission(userID string, permissionName string) (bool, error) {
-	return u.userRepo.CheckUserPermission(userID, permissionName)
+func (u *userUseCase) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
+	return u.userRepo.CheckUserPermission(ctx, userID, permissionName)
 }
 
-// LoginUser implements domain.UserUseCase.
-func (u *userUseCase) LoginUser(user model.User) (string, error) {
-	dbUser, err := u.userRepo.LoginUser(user)
+// ListUserPermissions implements domain.UserUseCase.
+func (u *userUseCase) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	return u.userRepo.ListUserPermissions(ctx, userID)
+}
+
+// AuthenticateUser verifies the username and password and returns the user.
+// Attempts are throttled per account and client IP, and every failure returns
+// domain.ErrInvalidCredentials. Failures of users with MFA are only cleared
+// once the second factor succeeds, so a known password can't be used to reset
+// the count while guessing codes.
+func (u *userUseCase) AuthenticateUser(ctx context.Context, user model.User, clientIP string) (model.User, error) {
+	if err := u.loginThrottle.Check(ctx, user.Username, clientIP); err != nil {
+		return model.User{}, err
+	}
+
+	dbUser, err := u.userRepo.LoginUser(ctx, user)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		u.passwordHasher.Verify(u.dummyPasswordHash, user.Password)
+		return model.User{}, u.rejectLogin(ctx, user.Username, clientIP)
+	}
 	if err != nil {
-		return "", err
+		return model.User{}, err
+	}
+
+	// Service accounts have no password and must use api keys
+	if dbUser.ServiceAccount || !u.passwordHasher.Verify(dbUser.Password, user.Password) {
+		return model.User{}, u.rejectLogin(ctx, user.Username, clientIP)
+	}
+	// Checked after the password so the status of an account isn't revealed
+	// to someone who doesn't know it
+	if !dbUser.Active() {
+		return model.User{}, domain.ErrAccountInactive
 	}
 
-	if !utils.VerifyPassword(dbUser.Password, user.Password) {
-		return "", errors.New("incorrect password")
+	// Upgrade hashes made with an older algorithm or parameters while the
+	// plain password is at hand. A failure here must not block the login.
+	if u.passwordHasher.NeedsRehash(dbUser.Password) {
+		if err := u.userRepo.RehashPassword(ctx, dbUser.ID, dbUser.Password, user.Password); err != nil {
+			slog.Warn("password rehash failed", "user_id", dbUser.ID, "error", err)
+		}
 	}
 
-	config, err := config.LoadConfig(".")
+	if !dbUser.MFAEnabled {
+		if err := u.loginThrottle.RecordSuccess(ctx, user.Username); err != nil {
+			return model.User{}, err
+		}
+	}
+	return dbUser, nil
+}
+
+func (u *userUseCase) rejectLogin(ctx context.Context, username string, clientIP string) error {
+	if err := u.loginThrottle.RecordFailure(ctx, username, clientIP); err != nil {
+		return err
+	}
+	return domain.ErrInvalidCredentials
+}
+
+// LoginUser verifies the password and issues an access token. Users with MFA
+// enabled get an mfa_pending token to be exchanged at the second step; users
+// whose role requires MFA but who haven't enrolled get a token that only
+// allows enrollment.
+func (u *userUseCase) LoginUser(ctx context.Context, user model.User, clientIP string) (model.LoginResult, error) {
+	dbUser, err := u.AuthenticateUser(ctx, user, clientIP)
 	if err != nil {
-		return "", nil
+		return model.LoginResult{}, err
+	}
+
+	switch {
+	case dbUser.MFAEnabled:
+		token, err := u.mfaToken(dbUser.ID, model.TokenPurposeMFAPending)
+		if err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{Token: token, MFARequired: true}, nil
+	case dbUser.RequiresMFA():
+		token, err := u.mfaToken(dbUser.ID, model.TokenPurposeMFAEnrollment)
+		if err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{Token: token, MFAEnrollmentRequired: true}, nil
 	}
 
-	token, err := utils.GenerateToken(config.TokenExpiresIn, dbUser.ID, config.TokenSecret)
+	token, err := u.tokens.Issue(dbUser.ID)
 	if err != nil {
-		return "", err
+		return model.LoginResult{}, err
 	}
 
-	return token, nil
+	return model.LoginResult{Token: token}, nil
+}
+
+// mfaToken signs a short lived token that only allows the purpose.
+func (u *userUseCase) mfaToken(userID uint, purpose string) (string, error) {
+	return u.tokens.IssueWithClaims(mfaTokenTTL, map[string]interface{}{
+		"sub":                   userID,
+		model.TokenPurposeClaim: purpose,
+	})
 }
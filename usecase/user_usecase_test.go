@@ -1,13 +1,20 @@
 package usecase
 
 import (
+	"context"
 	"errors"
-	"go-multirole/config"
+	"go-multirole/domain"
 	"go-multirole/model"
+	"go-multirole/utils"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Mock the UserRepo interface
@@ -15,26 +22,103 @@ type MockUserRepo struct {
 	mock.Mock
 }
 
-func (m *MockUserRepo) CreateUser(user model.User) (model.User, error) {
+func (m *MockUserRepo) CreateUser(ctx context.Context, user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
-func (m *MockUserRepo) AssignRoleToUser(userId string, roleID string) error {
-	args := m.Called(userId, roleID)
+func (m *MockUserRepo) AssignRoleToUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
 	return args.Error(0)
 }
 
-func (m *MockUserRepo) CheckUserPermission(userID string, permissionName string) (bool, error) {
+func (m *MockUserRepo) RevokeRoleFromUser(ctx context.Context, userID uint, roleID uint) error {
+	args := m.Called(userID, roleID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) CheckUserPermission(ctx context.Context, userID uint, permissionName string) (bool, error) {
 	args := m.Called(userID, permissionName)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockUserRepo) LoginUser(user model.User) (model.User, error) {
+func (m *MockUserRepo) ListUserPermissions(ctx context.Context, userID uint) ([]model.Permission, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.Permission), args.Error(1)
+}
+
+func (m *MockUserRepo) LoginUser(ctx context.Context, user model.User) (model.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(model.User), args.Error(1)
 }
 
+func (m *MockUserRepo) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]model.PasswordHistory, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]model.PasswordHistory), args.Error(1)
+}
+
+func (m *MockUserRepo) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, model.PageInfo, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]model.User), args.Get(1).(model.PageInfo), args.Error(2)
+}
+
+func (m *MockUserRepo) FindUserByID(ctx context.Context, userID uint) (model.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserRepo) FindUserByIdentifier(ctx context.Context, identifier string) (model.User, error) {
+	args := m.Called(identifier)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockUserRepo) UpdatePassword(ctx context.Context, userID uint, newPassword string, changedAt time.Time) error {
+	args := m.Called(userID, newPassword, changedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) RehashPassword(ctx context.Context, userID uint, currentHash string, password string) error {
+	args := m.Called(userID, currentHash, password)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) UpdateStatus(ctx context.Context, change model.UserStatusChange, changedAt time.Time) error {
+	args := m.Called(change, changedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) ListStatusChanges(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.UserStatusChange), args.Error(1)
+}
+
+func (m *MockUserRepo) CreatePasswordResetToken(ctx context.Context, token model.PasswordResetToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) FindPasswordResetToken(ctx context.Context, tokenHash string) (model.PasswordResetToken, error) {
+	args := m.Called(tokenHash)
+	return args.Get(0).(model.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockUserRepo) MarkPasswordResetTokenUsed(ctx context.Context, tokenID uint, usedAt time.Time) (bool, error) {
+	args := m.Called(tokenID, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, notification model.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+// A cheap bcrypt cost keeps the tests fast and leaves default cost hashes as they are
+var testPasswordHasher = utils.NewBcryptHasher(bcrypt.MinCost)
+
 // Mocking utils functions
 func MockVerifyPassword(expectedPassword, actualPassword string) bool {
 	return expectedPassword == actualPassword
@@ -47,14 +131,6 @@ func MockGenerateToken(expiresIn int, userID uint, secret string) (string, error
 	return "mock_token", nil
 }
 
-// Mocking config.LoadConfig function
-func MockLoadConfig(path string) (*config.Config, error) {
-	return &config.Config{
-		TokenExpiresIn: 3600,
-		TokenSecret:    "mock_secret",
-	}, nil
-}
-
 func TestCreateUser(t *testing.T) {
 	// Create a mock repository
 	mockRepo := new(MockUserRepo)
@@ -70,10 +146,10 @@ func TestCreateUser(t *testing.T) {
 	mockRepo.On("CreateUser", testUser).Return(testUser, nil)
 
 	// Create the UseCase with the mocked repository
-	useCase := NewUserUseCase(mockRepo)
+	useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
 
 	// Call the method under test
-	result, err := useCase.CreateUser(testUser)
+	result, err := useCase.CreateUser(context.Background(), testUser)
 
 	// Assert the expectations
 	assert.NoError(t, err)
@@ -88,17 +164,17 @@ func TestAssignRoleToUser(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 
 	// Define the test input
-	userID := "1"
-	roleID := "101"
+	userID := uint(1)
+	roleID := uint(101)
 
 	// Set up expectations: mock the AssignRoleToUser method
 	mockRepo.On("AssignRoleToUser", userID, roleID).Return(nil)
 
 	// Create the UseCase with the mocked repository
-	useCase := NewUserUseCase(mockRepo)
+	useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
 
 	// Call the method under test
-	err := useCase.AssignRoleToUser(userID, roleID)
+	err := useCase.AssignRoleToUser(context.Background(), userID, roleID)
 
 	// Assert that there is no error
 	assert.NoError(t, err)
@@ -112,17 +188,17 @@ func TestCheckUserPermission(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 
 	// Define the test input
-	userID := "1"
+	userID := uint(1)
 	permissionName := "admin"
 
 	// Set up expectations: mock the CheckUserPermission method
 	mockRepo.On("CheckUserPermission", userID, permissionName).Return(true, nil)
 
 	// Create the UseCase with the mocked repository
-	useCase := NewUserUseCase(mockRepo)
+	useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
 
 	// Call the method under test
-	result, err := useCase.CheckUserPermission(userID, permissionName)
+	result, err := useCase.CheckUserPermission(context.Background(), userID, permissionName)
 
 	// Assert the expectations
 	assert.NoError(t, err)
@@ -131,3 +207,451 @@ func TestCheckUserPermission(t *testing.T) {
 	// Assert that the CheckUserPermission method was called with the correct arguments
 	mockRepo.AssertExpectations(t)
 }
+
+func TestLoginUser(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	throttle := new(MockLoginThrottleUseCase)
+	hashedPassword, _ := utils.HashPassword("password123")
+	loginUser := model.User{Username: "john_doe", Password: "password123"}
+	mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 7, Username: "john_doe", Password: hashedPassword}, nil)
+	throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+	throttle.On("RecordSuccess", "john_doe").Return(nil)
+
+	useCase := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
+
+	result, err := useCase.LoginUser(context.Background(), loginUser, "203.0.113.9")
+
+	// The token is signed with the injected token service
+	assert.NoError(t, err)
+	claims, err := utils.ParseToken(result.Token, testTokenSecret)
+	assert.NoError(t, err)
+	id, _ := utils.TokenSubjectID(claims)
+	assert.Equal(t, uint(7), id)
+}
+
+func TestLoginUser_ServiceAccount(t *testing.T) {
+	// Create mocks for the repository and login throttle
+	mockRepo := new(MockUserRepo)
+	throttle := new(MockLoginThrottleUseCase)
+
+	// Service accounts have no password and must use api keys
+	loginUser := model.User{Username: "billing-job", Password: ""}
+	mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 3, Username: "billing-job", ServiceAccount: true}, nil)
+	throttle.On("Check", "billing-job", "203.0.113.9").Return(nil)
+	throttle.On("RecordFailure", "billing-job", "203.0.113.9").Return(nil)
+
+	// Create the UseCase with the mocked repository
+	useCase := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
+
+	// Call the method under test
+	result, err := useCase.LoginUser(context.Background(), loginUser, "203.0.113.9")
+
+	// Assert that the login is refused like any other bad credentials
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Empty(t, result.Token)
+	mockRepo.AssertExpectations(t)
+	throttle.AssertExpectations(t)
+}
+
+func TestAuthenticateUser_UniformErrors(t *testing.T) {
+	hashedPassword, _ := utils.HashPassword("password123")
+
+	t.Run("Unknown username", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		loginUser := model.User{Username: "nobody", Password: "password123"}
+		mockRepo.On("LoginUser", loginUser).Return(model.User{}, domain.ErrUserNotFound)
+		throttle.On("Check", "nobody", "203.0.113.9").Return(nil)
+		throttle.On("RecordFailure", "nobody", "203.0.113.9").Return(nil)
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.EqualError(t, err, "invalid username or password")
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		loginUser := model.User{Username: "john_doe", Password: "wrong"}
+		mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 1, Username: "john_doe", Password: hashedPassword}, nil)
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+		throttle.On("RecordFailure", "john_doe", "203.0.113.9").Return(nil)
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.EqualError(t, err, "invalid username or password")
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("Correct password clears failures", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		loginUser := model.User{Username: "john_doe", Password: "password123"}
+		mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 1, Username: "john_doe", Password: hashedPassword}, nil)
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+		throttle.On("RecordSuccess", "john_doe").Return(nil)
+
+		user, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("Correct password of an MFA user keeps failures until the second factor", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		loginUser := model.User{Username: "john_doe", Password: "password123"}
+		mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 1, Username: "john_doe", Password: hashedPassword, MFAEnabled: true}, nil)
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.NoError(t, err)
+		throttle.AssertNotCalled(t, "RecordSuccess", mock.Anything)
+	})
+}
+
+func TestAuthenticateUser_Rehash(t *testing.T) {
+	argon2idHasher, _ := utils.NewPasswordHasher(utils.HashAlgorithmArgon2id, utils.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}, bcrypt.MinCost)
+	legacyHash, _ := utils.HashPassword("password123")
+	loginUser := model.User{Username: "john_doe", Password: "password123"}
+
+	t.Run("Upgrade a bcrypt hash on login", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 1, Username: "john_doe", Password: legacyHash}, nil)
+		mockRepo.On("RehashPassword", uint(1), legacyHash, "password123").Return(nil).Once()
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+		throttle.On("RecordSuccess", "john_doe").Return(nil)
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, argon2idHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("A failed rehash doesn't block the login", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 1, Username: "john_doe", Password: legacyHash}, nil)
+		mockRepo.On("RehashPassword", uint(1), legacyHash, "password123").Return(errors.New("database error")).Once()
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+		throttle.On("RecordSuccess", "john_doe").Return(nil)
+
+		user, err := NewUserUseCase(mockRepo, nil, throttle, argon2idHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+	})
+
+	t.Run("Keep current hashes", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		currentHash, _ := argon2idHasher.Hash("password123")
+		mockRepo.On("LoginUser", loginUser).Return(model.User{ID: 1, Username: "john_doe", Password: currentHash}, nil)
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+		throttle.On("RecordSuccess", "john_doe").Return(nil)
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, argon2idHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "RehashPassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthenticateUser_Throttled(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	throttle := new(MockLoginThrottleUseCase)
+	loginUser := model.User{Username: "john_doe", Password: "password123"}
+	throttle.On("Check", "john_doe", "203.0.113.9").Return(&model.LoginThrottledError{RetryAfter: time.Minute})
+
+	_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+	// The password isn't even checked while throttled
+	var throttled *model.LoginThrottledError
+	assert.ErrorAs(t, err, &throttled)
+	mockRepo.AssertNotCalled(t, "LoginUser", mock.Anything)
+}
+
+func TestCreateUser_PasswordPolicy(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{
+		MinLength:        12,
+		RequireDigit:     true,
+		DisallowUsername: true,
+	}, testTokens, new(MockNotifier), time.Hour, "")
+
+	// Every failed rule is reported and nothing is stored
+	_, err := useCase.CreateUser(context.Background(), model.User{Username: "john_doe", Password: "john_doe"})
+
+	var policyErr *model.PasswordPolicyError
+	assert.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []model.PolicyViolation{
+		{Rule: "min_length", Message: "password must be at least 12 characters"},
+		{Rule: "digit", Message: "password must contain a digit"},
+		{Rule: "username", Message: "password must not contain the username"},
+	}, policyErr.Violations)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestCreateUser_EmptyPassword(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
+
+	_, err := useCase.CreateUser(context.Background(), model.User{Username: "john_doe"})
+
+	assert.EqualError(t, err, "password does not meet the policy: password is required")
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestValidatePassword_HistoryAndBreaches(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	dir := t.TempDir()
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte("1E4C9B93F3F0682250B6CF8331B7EE68FD8:42\n"), 0o600))
+	useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{
+		HistorySize:       3,
+		BreachedRangesDir: dir,
+	}, testTokens, new(MockNotifier), time.Hour, "")
+
+	previousHash, _ := utils.HashPassword("Old-Password-1")
+	mockRepo.On("ListPasswordHistory", uint(7), 3).Return([]model.PasswordHistory{{UserID: 7, PasswordHash: previousHash}}, nil)
+	user := model.User{ID: 7, Username: "john_doe"}
+
+	err := useCase.ValidatePassword(context.Background(), user, "Old-Password-1")
+	assert.EqualError(t, err, "password does not meet the policy: password must not match any of the last 3 passwords")
+
+	err = useCase.ValidatePassword(context.Background(), user, "password")
+	assert.EqualError(t, err, "password does not meet the policy: password has appeared in a data breach")
+
+	assert.NoError(t, useCase.ValidatePassword(context.Background(), user, "New-Password-2"))
+}
+
+func TestChangePassword(t *testing.T) {
+	currentHash, _ := utils.HashPassword("Current-Password-1")
+	user := model.User{ID: 7, Username: "john_doe", Password: currentHash}
+
+	t.Run("Wrong current password counts as a failed login", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		mockRepo.On("FindUserByID", uint(7)).Return(user, nil).Once()
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil).Once()
+		throttle.On("RecordFailure", "john_doe", "203.0.113.9").Return(nil).Once()
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").
+			ChangePassword(context.Background(), 7, "wrong", "New-Password-2", "203.0.113.9")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("New password must meet the policy", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		mockRepo.On("FindUserByID", uint(7)).Return(user, nil).Once()
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil).Once()
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{MinLength: 12}, testTokens, new(MockNotifier), time.Hour, "").
+			ChangePassword(context.Background(), 7, "Current-Password-1", "short", "203.0.113.9")
+
+		var policyErr *model.PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	t.Run("Send a hashed, expiring token", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		notifier := new(MockNotifier)
+		user := model.User{ID: 7, Username: "john_doe", Email: "john@example.com"}
+		mockRepo.On("FindUserByIdentifier", "john@example.com").Return(user, nil).Once()
+
+		var stored model.PasswordResetToken
+		mockRepo.On("CreatePasswordResetToken", mock.AnythingOfType("model.PasswordResetToken")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(model.PasswordResetToken) }).
+			Return(nil).Once()
+		var sent model.Notification
+		notifier.On("Notify", mock.AnythingOfType("model.Notification")).
+			Run(func(args mock.Arguments) { sent = args.Get(0).(model.Notification) }).
+			Return(nil).Once()
+
+		throttle := new(MockLoginThrottleUseCase)
+		throttle.On("LimitPasswordReset", "john@example.com", "203.0.113.9").Return(nil).Once()
+
+		useCase := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, notifier, 30*time.Minute, "https://app.example.com/reset")
+		assert.NoError(t, useCase.RequestPasswordReset(context.Background(), "john@example.com", "203.0.113.9"))
+
+		assert.Equal(t, uint(7), stored.UserID)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), stored.ExpiresAt, time.Minute)
+		assert.Equal(t, "john@example.com", sent.To)
+
+		// The link carries the token whose hash was stored
+		start := strings.Index(sent.Body, "token=") + len("token=")
+		token := sent.Body[start : start+64]
+		assert.Equal(t, utils.HashToken(token), stored.TokenHash)
+		assert.NotContains(t, sent.Body, stored.TokenHash)
+	})
+
+	t.Run("Stay silent for unknown users and accounts without email", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		notifier := new(MockNotifier)
+		mockRepo.On("FindUserByIdentifier", "nobody").Return(model.User{}, domain.ErrUserNotFound).Once()
+		mockRepo.On("FindUserByIdentifier", "john_doe").Return(model.User{ID: 7, Username: "john_doe"}, nil).Once()
+
+		throttle := new(MockLoginThrottleUseCase)
+		throttle.On("LimitPasswordReset", mock.Anything, "203.0.113.9").Return(nil)
+
+		useCase := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, notifier, 30*time.Minute, "")
+		assert.NoError(t, useCase.RequestPasswordReset(context.Background(), "nobody", "203.0.113.9"))
+		assert.NoError(t, useCase.RequestPasswordReset(context.Background(), "john_doe", "203.0.113.9"))
+
+		mockRepo.AssertNotCalled(t, "CreatePasswordResetToken", mock.Anything)
+		notifier.AssertNotCalled(t, "Notify", mock.Anything)
+	})
+
+	t.Run("Refuse throttled requests", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		notifier := new(MockNotifier)
+		throttle := new(MockLoginThrottleUseCase)
+		throttled := &model.LoginThrottledError{RetryAfter: time.Minute}
+		throttle.On("LimitPasswordReset", "john@example.com", "203.0.113.9").Return(throttled).Once()
+
+		useCase := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, notifier, 30*time.Minute, "")
+		err := useCase.RequestPasswordReset(context.Background(), "john@example.com", "203.0.113.9")
+
+		assert.ErrorIs(t, err, throttled)
+		mockRepo.AssertNotCalled(t, "FindUserByIdentifier", mock.Anything)
+		notifier.AssertNotCalled(t, "Notify", mock.Anything)
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	tokenHash := utils.HashToken("reset-token")
+	user := model.User{ID: 7, Username: "john_doe"}
+
+	t.Run("Reset with a valid token", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		mockRepo.On("FindPasswordResetToken", tokenHash).Return(model.PasswordResetToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(time.Minute)}, nil).Once()
+		mockRepo.On("FindUserByID", uint(7)).Return(user, nil).Once()
+		mockRepo.On("MarkPasswordResetTokenUsed", uint(3), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		mockRepo.On("UpdatePassword", uint(7), "New-Password-2", mock.AnythingOfType("time.Time")).Return(nil).Once()
+		throttle.On("RecordSuccess", "john_doe").Return(nil).Once()
+		unitOfWork := &fakeUnitOfWork{repos: domain.Repos{Users: mockRepo}}
+
+		err := NewUserUseCase(mockRepo, unitOfWork, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").ResetPassword(context.Background(), "reset-token", "New-Password-2")
+
+		assert.NoError(t, err)
+		assert.True(t, unitOfWork.committed)
+		mockRepo.AssertExpectations(t)
+		throttle.AssertExpectations(t)
+	})
+
+	t.Run("Reject expired tokens", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		mockRepo.On("FindPasswordResetToken", tokenHash).Return(model.PasswordResetToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(-time.Minute)}, nil).Once()
+
+		err := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").ResetPassword(context.Background(), "reset-token", "New-Password-2")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Reject a token consumed by a concurrent reset", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		mockRepo.On("FindPasswordResetToken", tokenHash).Return(model.PasswordResetToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(time.Minute)}, nil).Once()
+		mockRepo.On("FindUserByID", uint(7)).Return(user, nil).Once()
+		mockRepo.On("MarkPasswordResetTokenUsed", uint(3), mock.AnythingOfType("time.Time")).Return(false, nil).Once()
+
+		err := NewUserUseCase(mockRepo, &fakeUnitOfWork{repos: domain.Repos{Users: mockRepo}}, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").ResetPassword(context.Background(), "reset-token", "New-Password-2")
+
+		assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Keep the token when the password isn't stored", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		mockRepo.On("FindPasswordResetToken", tokenHash).Return(model.PasswordResetToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(time.Minute)}, nil).Once()
+		mockRepo.On("FindUserByID", uint(7)).Return(user, nil).Once()
+		mockRepo.On("MarkPasswordResetTokenUsed", uint(3), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		mockRepo.On("UpdatePassword", uint(7), "New-Password-2", mock.AnythingOfType("time.Time")).Return(errors.New("connection reset")).Once()
+		unitOfWork := &fakeUnitOfWork{repos: domain.Repos{Users: mockRepo}}
+
+		err := NewUserUseCase(mockRepo, unitOfWork, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").ResetPassword(context.Background(), "reset-token", "New-Password-2")
+
+		assert.Error(t, err)
+		assert.False(t, unitOfWork.committed, "The token is rolled back with the password")
+	})
+}
+
+func TestChangeStatus(t *testing.T) {
+	t.Run("Disable a user and record who did it", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		mockRepo.On("FindUserByID", uint(7)).Return(model.User{ID: 7, Username: "john_doe", Status: model.UserStatusActive}, nil).Once()
+		adminID := uint(1)
+		mockRepo.On("UpdateStatus", model.UserStatusChange{
+			UserID: 7, FromStatus: model.UserStatusActive, ToStatus: model.UserStatusDisabled, Reason: "left the company", ChangedBy: &adminID,
+		}, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
+		user, err := useCase.ChangeStatus(context.Background(), 7, model.UserStatusRequest{Status: model.UserStatusDisabled, Reason: "left the company"}, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, model.UserStatusDisabled, user.Status)
+		assert.NotNil(t, user.StatusChangedAt)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reject invalid transitions", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		mockRepo.On("FindUserByID", uint(7)).Return(model.User{ID: 7, Status: model.UserStatusDisabled}, nil).Once()
+
+		useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
+		_, err := useCase.ChangeStatus(context.Background(), 7, model.UserStatusRequest{Status: model.UserStatusLocked}, 1)
+
+		assert.ErrorIs(t, err, model.ErrInvalidStatusTransition)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Users can't change their own status", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+
+		useCase := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "")
+		_, err := useCase.ChangeStatus(context.Background(), 1, model.UserStatusRequest{Status: model.UserStatusDisabled}, 1)
+
+		assert.ErrorIs(t, err, model.ErrInvalidStatusTransition)
+		mockRepo.AssertNotCalled(t, "FindUserByID", mock.Anything)
+	})
+}
+
+func TestInactiveUsers(t *testing.T) {
+	hashedPassword, _ := utils.HashPassword("password123")
+	disabled := model.User{ID: 7, Username: "john_doe", Password: hashedPassword, Status: model.UserStatusDisabled}
+
+	t.Run("Login is refused after the password check", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		throttle := new(MockLoginThrottleUseCase)
+		loginUser := model.User{Username: "john_doe", Password: "password123"}
+		mockRepo.On("LoginUser", loginUser).Return(disabled, nil)
+		throttle.On("Check", "john_doe", "203.0.113.9").Return(nil)
+
+		_, err := NewUserUseCase(mockRepo, nil, throttle, testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").AuthenticateUser(context.Background(), loginUser, "203.0.113.9")
+
+		assert.ErrorIs(t, err, domain.ErrAccountInactive)
+		throttle.AssertNotCalled(t, "RecordSuccess", mock.Anything)
+	})
+
+	t.Run("Existing tokens are rejected", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		mockRepo.On("FindUserByID", uint(7)).Return(disabled, nil)
+
+		valid, err := NewUserUseCase(mockRepo, nil, new(MockLoginThrottleUseCase), testPasswordHasher, model.PasswordPolicy{}, testTokens, new(MockNotifier), time.Hour, "").SessionValid(context.Background(), 7, time.Now())
+
+		assert.ErrorIs(t, err, domain.ErrAccountInactive)
+		assert.False(t, valid)
+	})
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-multirole/domain"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	webhookBatchSize = 100
+	webhookTimeout   = 10 * time.Second
+	// webhookLease covers sending a whole batch of deliveries one after another
+	webhookLease = webhookBatchSize * webhookTimeout
+)
+
+type webhookUseCase struct {
+	webhookRepo domain.WebhookRepo
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewWebhookUseCase creates a use case that retries failed deliveries up to
+// maxAttempts times, doubling the wait after each failure starting at backoff.
+func NewWebhookUseCase(webhookRepo domain.WebhookRepo, maxAttempts int, backoff time.Duration) domain.WebhookUseCase {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &webhookUseCase{
+		webhookRepo: webhookRepo,
+		client:      &http.Client{Timeout: webhookTimeout},
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// CreateWebhook implements domain.WebhookUseCase.
+func (w *webhookUseCase) CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return model.Webhook{}, domain.Validation("webhook url must be an absolute http(s) url")
+	}
+
+	if webhook.Secret == "" {
+		webhook.Secret, err = utils.GenerateRandomHex(32)
+		if err != nil {
+			return model.Webhook{}, err
+		}
+	}
+	webhook.Active = true
+
+	return w.webhookRepo.CreateWebhook(ctx, webhook)
+}
+
+// ListWebhooks implements domain.WebhookUseCase.
+func (w *webhookUseCase) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	webhooks, err := w.webhookRepo.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The secret is only shown once, when the webhook is created
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook implements domain.WebhookUseCase.
+func (w *webhookUseCase) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	return w.webhookRepo.DeleteWebhook(ctx, webhookID)
+}
+
+// ListDeliveries implements domain.WebhookUseCase.
+func (w *webhookUseCase) ListDeliveries(ctx context.Context, webhookID uint) ([]model.WebhookDelivery, error) {
+	return w.webhookRepo.ListDeliveries(ctx, webhookID)
+}
+
+// DispatchPending fans out new outbox events and attempts every due delivery once.
+func (w *webhookUseCase) DispatchPending(ctx context.Context) error {
+	if _, err := w.webhookRepo.FanOutPendingEvents(ctx, webhookBatchSize); err != nil {
+		return fmt.Errorf("fan out outbox events: %w", err)
+	}
+
+	// Claimed deliveries are skipped by other instances until the lease ends,
+	// and retried then if this one stops before recording the outcome
+	now := time.Now()
+	deliveries, err := w.webhookRepo.ClaimDueDeliveries(ctx, now, now.Add(webhookLease), webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("claim due deliveries: %w", err)
+	}
+
+	for _, delivery := range deliveries {
+		w.attempt(ctx, &delivery)
+		if err := w.webhookRepo.UpdateDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("update delivery %d: %w", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+// attempt sends the delivery once and records the outcome on it.
+func (w *webhookUseCase) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	now := time.Now()
+	delivery.Attempts++
+
+	statusCode, err := w.send(ctx, *delivery)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		delivery.Status = model.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= w.maxAttempts {
+		delivery.Status = model.DeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(w.backoff << (delivery.Attempts - 1))
+}
+
+func (w *webhookUseCase) send(ctx context.Context, delivery model.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         delivery.EventID,
+		"type":       delivery.Event.Type,
+		"created_at": delivery.Event.CreatedAt,
+		"data":       json.RawMessage(delivery.Event.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Event", delivery.Event.Type)
+	request.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	request.Header.Set("X-Webhook-Timestamp", timestamp)
+	request.Header.Set("X-Webhook-Signature", "sha256="+utils.SignPayload(delivery.Webhook.Secret, timestamp, body))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"go-multirole/model"
+	"go-multirole/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock the WebhookRepo interface
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) CreateWebhook(ctx context.Context, webhook model.Webhook) (model.Webhook, error) {
+	args := m.Called(webhook)
+	return args.Get(0).(model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	args := m.Called(webhookID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) FanOutPendingEvents(ctx context.Context, limit int) (int, error) {
+	args := m.Called(limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	args := m.Called(now, leaseUntil, limit)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepo) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) ListDeliveries(ctx context.Context, webhookID uint) ([]model.WebhookDelivery, error) {
+	args := m.Called(webhookID)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func testDelivery(url string) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		ID:        7,
+		WebhookID: 1,
+		Webhook:   model.Webhook{ID: 1, URL: url, Secret: "secret", Active: true},
+		EventID:   3,
+		Event:     model.OutboxEvent{ID: 3, Type: model.EventUserRoleAssigned, Payload: `{"user_id":1,"role_id":2}`},
+		EventType: model.EventUserRoleAssigned,
+		Status:    model.DeliveryPending,
+	}
+}
+
+func TestCreateWebhook(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	useCase := NewWebhookUseCase(mockRepo, 3, time.Second)
+
+	t.Run("Generates a secret when none is given", func(t *testing.T) {
+		mockRepo.On("CreateWebhook", mock.MatchedBy(func(webhook model.Webhook) bool {
+			return webhook.Secret != "" && webhook.Active
+		})).Return(model.Webhook{ID: 1, URL: "https://hr.example.com/hook"}, nil).Once()
+
+		result, err := useCase.CreateWebhook(context.Background(), model.Webhook{URL: "https://hr.example.com/hook"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), result.ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejects relative urls", func(t *testing.T) {
+		_, err := useCase.CreateWebhook(context.Background(), model.Webhook{URL: "/hook"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestListWebhooksHidesSecret(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	mockRepo.On("ListWebhooks").Return([]model.Webhook{{ID: 1, URL: "https://hr.example.com/hook", Secret: "secret"}}, nil)
+	useCase := NewWebhookUseCase(mockRepo, 3, time.Second)
+
+	result, err := useCase.ListWebhooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Empty(t, result[0].Secret)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDispatchPending_Success(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	mockRepo.On("FanOutPendingEvents", webhookBatchSize).Return(1, nil)
+	mockRepo.On("ClaimDueDeliveries", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), webhookBatchSize).Return([]model.WebhookDelivery{testDelivery(server.URL)}, nil)
+	mockRepo.On("UpdateDelivery", mock.MatchedBy(func(delivery model.WebhookDelivery) bool {
+		return delivery.Status == model.DeliverySucceeded && delivery.Attempts == 1 && delivery.DeliveredAt != nil
+	})).Return(nil)
+	useCase := NewWebhookUseCase(mockRepo, 3, time.Second)
+
+	err := useCase.DispatchPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.EventUserRoleAssigned, received.Header.Get("X-Webhook-Event"))
+	assert.Contains(t, string(body), `"user_id":1`)
+
+	// The signature must verify against the delivered body and timestamp
+	signature := strings.TrimPrefix(received.Header.Get("X-Webhook-Signature"), "sha256=")
+	assert.True(t, utils.VerifySignature("secret", received.Header.Get("X-Webhook-Timestamp"), body, signature))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDispatchPending_RetryWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	t.Run("Schedules a retry while attempts remain", func(t *testing.T) {
+		delivery := testDelivery(server.URL)
+		delivery.Attempts = 1
+
+		mockRepo := new(MockWebhookRepo)
+		mockRepo.On("FanOutPendingEvents", webhookBatchSize).Return(0, nil)
+		mockRepo.On("ClaimDueDeliveries", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), webhookBatchSize).Return([]model.WebhookDelivery{delivery}, nil)
+		mockRepo.On("UpdateDelivery", mock.MatchedBy(func(delivery model.WebhookDelivery) bool {
+			// Second failure waits twice the base backoff
+			wait := time.Until(delivery.NextAttemptAt)
+			return delivery.Status == model.DeliveryPending && delivery.Attempts == 2 &&
+				delivery.LastStatusCode == http.StatusInternalServerError &&
+				wait > time.Minute && wait <= 2*time.Minute
+		})).Return(nil)
+		useCase := NewWebhookUseCase(mockRepo, 3, time.Minute)
+
+		assert.NoError(t, useCase.DispatchPending(context.Background()))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Marks the delivery failed after the last attempt", func(t *testing.T) {
+		delivery := testDelivery(server.URL)
+		delivery.Attempts = 2
+
+		mockRepo := new(MockWebhookRepo)
+		mockRepo.On("FanOutPendingEvents", webhookBatchSize).Return(0, nil)
+		mockRepo.On("ClaimDueDeliveries", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), webhookBatchSize).Return([]model.WebhookDelivery{delivery}, nil)
+		mockRepo.On("UpdateDelivery", mock.MatchedBy(func(delivery model.WebhookDelivery) bool {
+			return delivery.Status == model.DeliveryFailed && delivery.Attempts == 3 && delivery.LastError != ""
+		})).Return(nil)
+		useCase := NewWebhookUseCase(mockRepo, 3, time.Minute)
+
+		assert.NoError(t, useCase.DispatchPending(context.Background()))
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,37 @@
+package utils
+
+import "strings"
+
+const apiKeyPrefix = "rbac"
+
+// GenerateAPIKey returns a new key of the form rbac_<id>_<secret> together with
+// its public prefix rbac_<id>, which is used to look the key up. Prefixes are
+// unique, so the id is long enough for collisions to be practically
+// impossible.
+func GenerateAPIKey() (key string, prefix string, err error) {
+	id, err := GenerateRandomHex(8)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := GenerateRandomHex(32)
+	if err != nil {
+		return "", "", err
+	}
+	prefix = apiKeyPrefix + "_" + id
+	return prefix + "_" + secret, prefix, nil
+}
+
+// APIKeyPrefix extracts the public prefix from a key.
+func APIKeyPrefix(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[0] + "_" + parts[1], true
+}
+
+// HashAPIKey hashes a key for storage. Keys carry 256 bits of entropy, so a
+// fast hash is sufficient and keeps per-request verification cheap.
+func HashAPIKey(key string) string {
+	return HashToken(key)
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := GenerateAPIKey()
+
+	// Ensure the key starts with its prefix and can be parsed back
+	assert.NoError(t, err, "expected no error while generating api key")
+	assert.Contains(t, key, prefix+"_", "expected key to start with its prefix")
+	assert.Len(t, prefix, len("rbac_")+16, "expected a 64-bit id in the prefix")
+
+	parsedPrefix, ok := APIKeyPrefix(key)
+	assert.True(t, ok, "expected generated key to be parseable")
+	assert.Equal(t, prefix, parsedPrefix, "expected parsed prefix to match")
+}
+
+func TestAPIKeyPrefix(t *testing.T) {
+	_, ok := APIKeyPrefix("not-a-key")
+	assert.False(t, ok, "expected malformed key to be rejected")
+
+	_, ok = APIKeyPrefix("other_abcd_secret")
+	assert.False(t, ok, "expected foreign prefix to be rejected")
+}
+
+func TestHashAPIKey(t *testing.T) {
+	// Hashing is deterministic so the stored hash can be compared
+	assert.Equal(t, HashAPIKey("rbac_abcd_secret"), HashAPIKey("rbac_abcd_secret"), "expected deterministic hash")
+	assert.NotEqual(t, HashAPIKey("rbac_abcd_secret"), HashAPIKey("rbac_abcd_other"), "expected different keys to hash differently")
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsPasswordBreached looks the password up in a local copy of a k-anonymity
+// breached password list, such as the Pwned Passwords ranges. The directory
+// holds one file per 5 character SHA-1 prefix, named PREFIX or PREFIX.txt,
+// with lines of SUFFIX:COUNT. Only the file of the password's prefix is read.
+func IsPasswordBreached(rangesDir string, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	for _, name := range []string{prefix + ".txt", prefix} {
+		file, err := os.Open(filepath.Join(rangesDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			candidate, count, _ := strings.Cut(line, ":")
+			// Padding entries with a count of 0 aren't real breaches
+			if strings.EqualFold(candidate, suffix) && count != "0" {
+				return true, nil
+			}
+		}
+		return false, scanner.Err()
+	}
+	return false, nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPasswordBreached(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	dir := t.TempDir()
+	ranges := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(ranges), 0o600))
+
+	breached, err := IsPasswordBreached(dir, "password")
+	assert.NoError(t, err)
+	assert.True(t, breached, "expected password to be found in the range file")
+
+	breached, err = IsPasswordBreached(dir, "Correct-Horse-42")
+	assert.NoError(t, err)
+	assert.False(t, breached, "expected a password without range file to pass")
+}
+
+func TestIsPasswordBreached_IgnoresPadding(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6"), []byte("1E4C9B93F3F0682250B6CF8331B7EE68FD8:0\n"), 0o600))
+
+	breached, err := IsPasswordBreached(dir, "password")
+	assert.NoError(t, err)
+	assert.False(t, breached, "expected padding entries to be ignored")
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// VerifyPKCE checks an RFC 7636 S256 code verifier against its challenge.
+func VerifyPKCE(codeVerifier string, codeChallenge string) bool {
+	if len(codeVerifier) < 43 || len(codeVerifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(codeVerifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(codeChallenge)) == 1
+}
+
+// LoadRSAPrivateKey reads a PEM encoded PKCS#1 or PKCS#8 RSA private key.
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+	return key, nil
+}
+
+// GenerateRSAPrivateKey creates a fresh 2048 bit signing key.
+func GenerateRSAPrivateKey() (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+// RSAKeyID derives a stable key id from the public key.
+func RSAKeyID(key *rsa.PublicKey) string {
+	sum := sha256.Sum256(key.N.Bytes())
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+// RSAPublicJWK returns the public key as an RFC 7517 JSON Web Key.
+func RSAPublicJWK(key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": RSAKeyID(key),
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// GenerateRS256Token signs the claims with the RSA key so that relying parties
+// can verify the token using the published JWK.
+func GenerateRS256Token(ttl time.Duration, extraClaims map[string]interface{}, key *rsa.PrivateKey) (string, error) {
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = RSAKeyID(&key.PublicKey)
+	now := time.Now().UTC()
+	claims := token.Claims.(jwt.MapClaims)
+
+	for name, value := range extraClaims {
+		claims[name] = value
+	}
+	claims["exp"] = now.Add(ttl).Unix()
+	claims["iat"] = now.Unix()
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("generating JWT Token failed: %w", err)
+	}
+
+	return tokenString, nil
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := strings.Repeat("a", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	// The matching verifier passes, anything else fails
+	assert.True(t, VerifyPKCE(verifier, challenge), "expected matching verifier to pass")
+	assert.False(t, VerifyPKCE(strings.Repeat("b", 43), challenge), "expected wrong verifier to fail")
+	assert.False(t, VerifyPKCE("short", challenge), "expected too short verifier to fail")
+}
+
+func TestGenerateRS256Token(t *testing.T) {
+	key, err := GenerateRSAPrivateKey()
+	assert.NoError(t, err, "expected no error while generating key")
+
+	token, err := GenerateRS256Token(time.Minute, map[string]interface{}{"sub": "1", "nonce": "abc"}, key)
+	assert.NoError(t, err, "expected no error while signing token")
+
+	// Verify the token using only the public key, as a relying party would
+	parsed, err := jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
+		if _, ok := jwtToken.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected method: %s", jwtToken.Header["alg"])
+		}
+		return &key.PublicKey, nil
+	})
+	assert.NoError(t, err, "expected token to verify with the public key")
+	assert.Equal(t, RSAKeyID(&key.PublicKey), parsed.Header["kid"], "expected key id header")
+	assert.Equal(t, "abc", parsed.Claims.(jwt.MapClaims)["nonce"], "expected nonce claim")
+
+	jwk := RSAPublicJWK(&key.PublicKey)
+	assert.Equal(t, "RS256", jwk["alg"], "expected RS256 jwk")
+	assert.Equal(t, "AQAB", jwk["e"], "expected standard public exponent")
+}
@@ -0,0 +1,262 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
+)
+
+// PasswordHasher hashes passwords into self-describing encoded strings, so
+// hashes made with older algorithms or parameters can still be verified and
+// upgraded later.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encodedHash string, password string) bool
+	// NeedsRehash reports whether the hash was made with a different
+	// algorithm or weaker parameters than the hasher currently uses.
+	NeedsRehash(encodedHash string) bool
+}
+
+// Argon2idParams configures argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follows the OWASP recommendation for argon2id.
+var DefaultArgon2idParams = Argon2idParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+
+// Bounds on the parameters of the hashes that are made and verified. Hashes
+// can be imported from other systems, and verifying one with extreme
+// parameters on every login would exhaust or crash the server.
+const (
+	maxArgon2idMemory      = 1024 * 1024 // KiB
+	maxArgon2idIterations  = 64
+	maxArgon2idParallelism = 64
+	minArgon2idSaltLength  = 8
+	maxArgon2idSaltLength  = 64
+	minArgon2idKeyLength   = 16
+	maxArgon2idKeyLength   = 128
+	maxBcryptCost          = 18
+	bcryptHashLength       = 60
+)
+
+var errArgon2idParams = fmt.Errorf("argon2id needs 1 to %d iterations, 1 to %d lanes, 8 KiB of memory per lane up to %d KiB, "+
+	"a salt of %d to %d bytes and a key of %d to %d bytes", maxArgon2idIterations, maxArgon2idParallelism, maxArgon2idMemory,
+	minArgon2idSaltLength, maxArgon2idSaltLength, minArgon2idKeyLength, maxArgon2idKeyLength)
+
+// validate checks the parameters against the bounds above.
+func (p Argon2idParams) validate() error {
+	if p.Iterations < 1 || p.Iterations > maxArgon2idIterations ||
+		p.Parallelism < 1 || p.Parallelism > maxArgon2idParallelism ||
+		p.Memory < 8*uint32(p.Parallelism) || p.Memory > maxArgon2idMemory ||
+		p.SaltLength < minArgon2idSaltLength || p.SaltLength > maxArgon2idSaltLength ||
+		p.KeyLength < minArgon2idKeyLength || p.KeyLength > maxArgon2idKeyLength {
+		return errArgon2idParams
+	}
+	return nil
+}
+
+// NewPasswordHasher hashes new passwords with the named algorithm and verifies
+// hashes of every supported algorithm.
+func NewPasswordHasher(algorithm string, argon2idParams Argon2idParams, bcryptCost int) (PasswordHasher, error) {
+	argon2id := NewArgon2idHasher(argon2idParams)
+	if err := argon2id.(*argon2idHasher).params.validate(); err != nil {
+		return nil, err
+	}
+	hashers := map[string]PasswordHasher{
+		HashAlgorithmArgon2id: argon2id,
+		HashAlgorithmBcrypt:   NewBcryptHasher(bcryptCost),
+	}
+	if _, ok := hashers[algorithm]; !ok {
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", algorithm)
+	}
+	return &passwordHasher{preferred: algorithm, hashers: hashers}, nil
+}
+
+type passwordHasher struct {
+	preferred string
+	hashers   map[string]PasswordHasher
+}
+
+func (p *passwordHasher) Hash(password string) (string, error) {
+	return p.hashers[p.preferred].Hash(password)
+}
+
+func (p *passwordHasher) Verify(encodedHash string, password string) bool {
+	hasher, ok := p.hashers[hashAlgorithm(encodedHash)]
+	return ok && hasher.Verify(encodedHash, password)
+}
+
+func (p *passwordHasher) NeedsRehash(encodedHash string) bool {
+	return hashAlgorithm(encodedHash) != p.preferred || p.hashers[p.preferred].NeedsRehash(encodedHash)
+}
+
+// SupportedHash reports whether the encoded hash is well formed and made with
+// an algorithm and parameters NewPasswordHasher verifies, such as hashes
+// imported from other systems.
+func SupportedHash(encodedHash string) bool {
+	switch hashAlgorithm(encodedHash) {
+	case HashAlgorithmArgon2id:
+		_, _, _, err := decodeArgon2idHash(encodedHash)
+		return err == nil
+	case HashAlgorithmBcrypt:
+		return validBcryptHash(encodedHash)
+	}
+	return false
+}
+
+// hashAlgorithm identifies the algorithm from the PHC or modular crypt prefix.
+func hashAlgorithm(encodedHash string) string {
+	switch {
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		return HashAlgorithmArgon2id
+	case strings.HasPrefix(encodedHash, "$2a$"), strings.HasPrefix(encodedHash, "$2b$"), strings.HasPrefix(encodedHash, "$2y$"):
+		return HashAlgorithmBcrypt
+	}
+	return ""
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher creates a hasher producing PHC strings such as
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>. Zero parameters fall back to
+// DefaultArgon2idParams.
+func NewArgon2idHasher(params Argon2idParams) PasswordHasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2idParams.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2idParams.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2idParams.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2idParams.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2idParams.KeyLength
+	}
+	return &argon2idHasher{params: params}
+}
+
+func (a *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("could not hash password %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, a.params.Iterations, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		a.params.Memory, a.params.Iterations, a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *argon2idHasher) Verify(encodedHash string, password string) bool {
+	params, salt, key, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, candidate) == 1
+}
+
+func (a *argon2idHasher) NeedsRehash(encodedHash string) bool {
+	params, _, _, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return true
+	}
+	return params.Memory < a.params.Memory || params.Iterations < a.params.Iterations ||
+		params.Parallelism != a.params.Parallelism || params.SaltLength < a.params.SaltLength ||
+		params.KeyLength < a.params.KeyLength
+}
+
+var errInvalidArgon2idHash = errors.New("invalid argon2id hash")
+
+func decodeArgon2idHash(encodedHash string) (Argon2idParams, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return Argon2idParams{}, nil, nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2idParams{}, nil, nil, errInvalidArgon2idHash
+	}
+
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2idParams{}, nil, nil, errInvalidArgon2idHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, errInvalidArgon2idHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2idParams{}, nil, nil, errInvalidArgon2idHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	if params.validate() != nil {
+		return Argon2idParams{}, nil, nil, errInvalidArgon2idHash
+	}
+
+	return params, salt, key, nil
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a bcrypt hasher. Costs below bcrypt's minimum or
+// above the highest cost verified fall back to bcrypt.DefaultCost.
+func NewBcryptHasher(cost int) PasswordHasher {
+	if cost < bcrypt.MinCost || cost > maxBcryptCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &bcryptHasher{cost: cost}
+}
+
+func (b *bcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash password %w", err)
+	}
+	return string(hashedPassword), nil
+}
+
+func (b *bcryptHasher) Verify(encodedHash string, password string) bool {
+	return validBcryptHash(encodedHash) && bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password)) == nil
+}
+
+func (b *bcryptHasher) NeedsRehash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err != nil || cost < b.cost
+}
+
+// validBcryptHash reports whether the hash is well formed with a cost of at
+// most maxBcryptCost.
+func validBcryptHash(encodedHash string) bool {
+	cost, err := bcrypt.Cost([]byte(encodedHash))
+	return err == nil && cost <= maxBcryptCost && len(encodedHash) == bcryptHashLength
+}
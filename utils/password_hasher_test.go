@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Small parameters keep the tests fast
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	encoded, err := hasher.Hash("mysecretpassword")
+	assert.NoError(t, err, "expected no error while hashing password")
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"), "expected a PHC encoded hash, got %s", encoded)
+
+	assert.True(t, hasher.Verify(encoded, "mysecretpassword"), "expected the correct password to verify")
+	assert.False(t, hasher.Verify(encoded, "wrongpassword"), "expected a wrong password to fail")
+	assert.False(t, hasher.Verify("$argon2id$v=19$garbage", "mysecretpassword"), "expected a malformed hash to fail")
+	assert.False(t, hasher.NeedsRehash(encoded), "expected a hash with current parameters to be kept")
+
+	stronger := NewArgon2idHasher(Argon2idParams{Memory: 2048, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	assert.True(t, stronger.NeedsRehash(encoded), "expected a hash with less memory to be rehashed")
+	assert.True(t, stronger.Verify(encoded, "mysecretpassword"), "expected old parameters to still verify")
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+
+	encoded, err := hasher.Hash("mysecretpassword")
+	assert.NoError(t, err, "expected no error while hashing password")
+	assert.True(t, hasher.Verify(encoded, "mysecretpassword"), "expected the correct password to verify")
+	assert.False(t, hasher.Verify(encoded, "wrongpassword"), "expected a wrong password to fail")
+	assert.False(t, hasher.NeedsRehash(encoded), "expected a hash with the current cost to be kept")
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(encoded), "expected a cheaper hash to be rehashed")
+}
+
+func TestPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(HashAlgorithmArgon2id, testArgon2idParams, bcrypt.MinCost)
+	assert.NoError(t, err, "expected a supported algorithm")
+
+	// Existing bcrypt hashes still verify but are upgraded
+	legacy, _ := HashPassword("mysecretpassword")
+	assert.True(t, hasher.Verify(legacy, "mysecretpassword"), "expected bcrypt hashes to verify")
+	assert.True(t, hasher.NeedsRehash(legacy), "expected bcrypt hashes to be rehashed with argon2id")
+
+	encoded, err := hasher.Hash("mysecretpassword")
+	assert.NoError(t, err, "expected no error while hashing password")
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$"), "expected new hashes to use argon2id")
+	assert.True(t, hasher.Verify(encoded, "mysecretpassword"), "expected argon2id hashes to verify")
+	assert.False(t, hasher.NeedsRehash(encoded), "expected current hashes to be kept")
+
+	assert.False(t, hasher.Verify("plaintext", "plaintext"), "expected unknown formats to fail")
+	assert.True(t, SupportedHash(legacy), "expected bcrypt hashes to be supported")
+	assert.True(t, SupportedHash(encoded), "expected argon2id hashes to be supported")
+	assert.False(t, SupportedHash("plaintext"), "expected unknown formats to be unsupported")
+
+	_, err = NewPasswordHasher("md5", testArgon2idParams, bcrypt.MinCost)
+	assert.EqualError(t, err, `unsupported password hash algorithm "md5"`)
+}
+
+func TestHashParameterBounds(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	salt, key := "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	// Verifying these would exhaust memory, panic or never finish
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=4294967295,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1024,t=1,p=1$$" + key,
+		"$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$a2V5",
+	} {
+		assert.False(t, SupportedHash(encoded), "expected %s to be unsupported", encoded)
+		assert.False(t, hasher.Verify(encoded, "mysecretpassword"), "expected %s to be refused", encoded)
+	}
+
+	legacy, _ := HashPassword("mysecretpassword")
+	assert.False(t, SupportedHash(legacy[:len(legacy)-1]), "expected a truncated bcrypt hash to be unsupported")
+	assert.False(t, SupportedHash("$2a$31$"+legacy[7:]), "expected an excessive bcrypt cost to be unsupported")
+	assert.False(t, NewBcryptHasher(bcrypt.MinCost).Verify("$2a$31$"+legacy[7:], "mysecretpassword"), "expected an excessive bcrypt cost to be refused")
+
+	_, err := NewPasswordHasher(HashAlgorithmArgon2id, Argon2idParams{Memory: 4 * 1024 * 1024}, bcrypt.MinCost)
+	assert.Error(t, err, "expected parameters that couldn't be verified to be rejected")
+}
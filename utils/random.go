@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// passwordClasses are the characters of generated passwords, without the
+// ones easily confused with each other.
+var passwordClasses = []string{"ABCDEFGHJKLMNPQRSTUVWXYZ", "abcdefghijkmnopqrstuvwxyz", "23456789", "!#$%&*+-=?@^_"}
+
+// GenerateRandomHex returns n cryptographically random bytes encoded as hex.
+func GenerateRandomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("could not generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken hashes a high-entropy opaque token for storage and lookup.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GeneratePassword returns a random password of the given length, at least 4,
+// holding a character of every class so it passes any composition rule.
+func GeneratePassword(length int) (string, error) {
+	all := strings.Join(passwordClasses, "")
+	password := make([]byte, length)
+	for i := range password {
+		charset := all
+		if i < len(passwordClasses) {
+			charset = passwordClasses[i]
+		}
+		n, err := randomInt(len(charset))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[n]
+	}
+	// Shuffle, so the first characters don't reveal their class
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+func randomInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, fmt.Errorf("could not generate random bytes: %w", err)
+	}
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRandomHex(t *testing.T) {
+	value, err := GenerateRandomHex(16)
+
+	// Ensure the value has the expected length and is random
+	assert.NoError(t, err, "expected no error while generating random hex")
+	assert.Len(t, value, 32, "expected 16 bytes to encode to 32 hex characters")
+
+	other, err := GenerateRandomHex(16)
+	assert.NoError(t, err, "expected no error while generating random hex again")
+	assert.NotEqual(t, value, other, "expected different values on each call")
+}
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := GeneratePassword(24)
+
+	assert.NoError(t, err, "expected no error while generating a password")
+	assert.Len(t, password, 24, "expected the requested length")
+	for _, class := range passwordClasses {
+		assert.True(t, strings.ContainsAny(password, class), "expected a character of %q", class)
+	}
+
+	other, err := GeneratePassword(24)
+	assert.NoError(t, err, "expected no error while generating a password again")
+	assert.NotEqual(t, password, other, "expected different passwords on each call")
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignPayload returns the hex encoded HMAC-SHA256 of timestamp + "." + payload.
+// Including the timestamp lets receivers reject replayed deliveries.
+func SignPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature produced by SignPayload in constant time.
+func VerifySignature(secret string, timestamp string, payload []byte, signature string) bool {
+	expected := SignPayload(secret, timestamp, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignPayload(t *testing.T) {
+	payload := []byte(`{"type":"user.created"}`)
+
+	// Signing the same input twice must be deterministic
+	signature := SignPayload("secret", "1700000000", payload)
+	assert.NotEmpty(t, signature, "expected non-empty signature")
+	assert.Equal(t, signature, SignPayload("secret", "1700000000", payload), "expected deterministic signature")
+
+	// A different secret or timestamp must produce a different signature
+	assert.NotEqual(t, signature, SignPayload("other", "1700000000", payload), "expected signature to depend on secret")
+	assert.NotEqual(t, signature, SignPayload("secret", "1700000001", payload), "expected signature to depend on timestamp")
+}
+
+func TestVerifySignature(t *testing.T) {
+	payload := []byte(`{"type":"user.created"}`)
+	signature := SignPayload("secret", "1700000000", payload)
+
+	assert.True(t, VerifySignature("secret", "1700000000", payload, signature), "expected valid signature to verify")
+	assert.False(t, VerifySignature("secret", "1700000000", []byte(`{}`), signature), "expected tampered payload to fail")
+	assert.False(t, VerifySignature("wrong", "1700000000", payload, signature), "expected wrong secret to fail")
+}
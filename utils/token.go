@@ -2,24 +2,101 @@ package utils
 
 import (
 	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 )
 
+// TokenService signs and verifies the HS256 tokens issued to users and OAuth
+// clients with the configured secret.
+type TokenService struct {
+	keys atomic.Pointer[TokenKeys]
+}
+
+// TokenKeys are what a TokenService signs and verifies with.
+type TokenKeys struct {
+	Secret string
+	// PreviousSecrets are still accepted when verifying, so rotating the
+	// secret doesn't invalidate the tokens signed before.
+	PreviousSecrets []string
+	// TTL is the lifetime of the access tokens issued with Issue.
+	TTL time.Duration
+}
+
+// NewTokenService creates the token service. Access tokens issued with Issue
+// expire after ttl.
+func NewTokenService(secret string, ttl time.Duration) *TokenService {
+	s := &TokenService{}
+	s.Rotate(TokenKeys{Secret: secret, TTL: ttl})
+	return s
+}
+
+// Rotate swaps the keys while the service is in use. Every token is signed or
+// verified with either the old or the new keys, never a mix.
+func (s *TokenService) Rotate(keys TokenKeys) {
+	s.keys.Store(&keys)
+}
+
+// Issue signs an access token for the user.
+func (s *TokenService) Issue(userID uint) (string, error) {
+	keys := s.keys.Load()
+	return GenerateToken(keys.TTL, userID, keys.Secret)
+}
+
+// IssueWithClaims signs the claims into a token that expires after ttl.
+func (s *TokenService) IssueWithClaims(ttl time.Duration, claims map[string]interface{}) (string, error) {
+	return GenerateTokenWithClaims(ttl, claims, s.keys.Load().Secret)
+}
+
+// Parse verifies the token with the current or a previous secret and returns
+// all of its claims. The error is the one of the current secret.
+func (s *TokenService) Parse(token string) (map[string]interface{}, error) {
+	keys := s.keys.Load()
+	claims, err := ParseToken(token, keys.Secret)
+	if err == nil {
+		return claims, nil
+	}
+	for _, secret := range keys.PreviousSecrets {
+		if previous, previousErr := ParseToken(token, secret); previousErr == nil {
+			return previous, nil
+		}
+	}
+	return nil, err
+}
+
+// TTL is how long access tokens issued with Issue are valid.
+func (s *TokenService) TTL() time.Duration {
+	return s.keys.Load().TTL
+}
+
 func GenerateToken(ttl time.Duration, payload interface{}, secretJWTKey string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+	return GenerateTokenWithClaims(ttl, map[string]interface{}{"sub": payload}, secretJWTKey)
+}
 
+// GenerateTokenWithClaims signs the given claims together with the standard
+// exp, iat, nbf and a unique jti, which allows the token to be revoked.
+func GenerateTokenWithClaims(ttl time.Duration, extraClaims map[string]interface{}, secretJWTKey string) (string, error) {
+	token := jwt.New(jwt.SigningMethodHS256)
 	now := time.Now().UTC()
 	claims := token.Claims.(jwt.MapClaims)
 
-	claims["sub"] = payload
+	jti, err := GenerateRandomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("generating JWT Token failed: %w", err)
+	}
+
+	for key, value := range extraClaims {
+		claims[key] = value
+	}
 	claims["exp"] = now.Add(ttl).Unix()
 	claims["iat"] = now.Unix()
 	claims["nbf"] = now.Unix()
+	claims["jti"] = jti
 
 	tokenString, err := token.SignedString([]byte(secretJWTKey))
-
 	if err != nil {
 		return "", fmt.Errorf("generating JWT Token failed: %w", err)
 	}
@@ -28,6 +105,16 @@ func GenerateToken(ttl time.Duration, payload interface{}, secretJWTKey string)
 }
 
 func ValidateToken(token string, signedJWTKey string) (interface{}, error) {
+	claims, err := ParseToken(token, signedJWTKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims["sub"], nil
+}
+
+// ParseToken verifies the token and returns all of its claims.
+func ParseToken(token string, signedJWTKey string) (map[string]interface{}, error) {
 	tok, err := jwt.Parse(token, func(jwtToken *jwt.Token) (interface{}, error) {
 		if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected method: %s", jwtToken.Header["alg"])
@@ -44,5 +131,33 @@ func ValidateToken(token string, signedJWTKey string) (interface{}, error) {
 		return nil, fmt.Errorf("invalid token claim")
 	}
 
-	return claims["sub"], nil
+	return claims, nil
+}
+
+// TokenIssuedAt returns the iat claim, or the zero time when it is missing.
+func TokenIssuedAt(claims map[string]interface{}) time.Time {
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(int64(iat), 0)
+}
+
+// TokenSubjectID returns the sub claim of a user token as a user id. It
+// reports false for tokens whose subject isn't a user, such as client tokens.
+func TokenSubjectID(claims map[string]interface{}) (uint, bool) {
+	switch sub := claims["sub"].(type) {
+	case float64:
+		if sub < 1 || sub != math.Trunc(sub) || sub > math.MaxUint32 {
+			return 0, false
+		}
+		return uint(sub), true
+	case string:
+		id, err := strconv.ParseUint(sub, 10, 32)
+		if err != nil || id == 0 {
+			return 0, false
+		}
+		return uint(id), true
+	}
+	return 0, false
 }
@@ -60,3 +60,88 @@ func TestValidateToken(t *testing.T) {
 	_, err = ValidateToken(token, "wrongsecretkey")
 	assert.Error(t, err, "expected error for token validated with wrong secret key")
 }
+
+func TestGenerateTokenWithClaims(t *testing.T) {
+	secretKey := "testsecretkey"
+
+	token, err := GenerateTokenWithClaims(time.Minute*5, map[string]interface{}{"sub": "client-1", "scope": "read write"}, secretKey)
+	assert.NoError(t, err, "expected no error while generating token")
+
+	// Parse the token back and check both custom and standard claims
+	claims, err := ParseToken(token, secretKey)
+	assert.NoError(t, err, "expected no error while parsing token")
+	assert.Equal(t, "client-1", claims["sub"], "expected sub to match")
+	assert.Equal(t, "read write", claims["scope"], "expected scope to match")
+	assert.NotEmpty(t, claims["jti"], "expected a token id")
+
+	// Each token gets its own id so it can be revoked individually
+	other, _ := GenerateTokenWithClaims(time.Minute*5, map[string]interface{}{"sub": "client-1"}, secretKey)
+	otherClaims, _ := ParseToken(other, secretKey)
+	assert.NotEqual(t, claims["jti"], otherClaims["jti"], "expected unique token ids")
+}
+
+func TestTokenIssuedAt(t *testing.T) {
+	secretKey := "testsecretkey"
+	before := time.Now().Truncate(time.Second)
+
+	token, err := GenerateToken(time.Minute*5, 1, secretKey)
+	assert.NoError(t, err, "expected no error while generating token")
+	claims, err := ParseToken(token, secretKey)
+	assert.NoError(t, err, "expected no error while parsing token")
+
+	issuedAt := TokenIssuedAt(claims)
+	assert.False(t, issuedAt.Before(before), "expected iat not before the generation time")
+	assert.True(t, TokenIssuedAt(map[string]interface{}{}).IsZero(), "expected zero time without iat")
+}
+
+func TestTokenSubjectID(t *testing.T) {
+	secretKey := "testsecretkey"
+
+	token, err := GenerateToken(time.Minute*5, uint(7), secretKey)
+	assert.NoError(t, err, "expected no error while generating token")
+	claims, err := ParseToken(token, secretKey)
+	assert.NoError(t, err, "expected no error while parsing token")
+
+	id, ok := TokenSubjectID(claims)
+	assert.True(t, ok, "expected a user subject")
+	assert.Equal(t, uint(7), id)
+
+	id, ok = TokenSubjectID(map[string]interface{}{"sub": "12"})
+	assert.True(t, ok, "expected a numeric string subject to be accepted")
+	assert.Equal(t, uint(12), id)
+
+	for _, sub := range []interface{}{nil, "client-app", float64(0), 1.5, "-3"} {
+		_, ok := TokenSubjectID(map[string]interface{}{"sub": sub})
+		assert.False(t, ok, "expected %v to be rejected", sub)
+	}
+}
+
+func TestTokenService(t *testing.T) {
+	tokens := NewTokenService("testsecretkey", time.Minute*5)
+
+	token, err := tokens.Issue(7)
+	assert.NoError(t, err, "expected no error while issuing token")
+	claims, err := tokens.Parse(token)
+	assert.NoError(t, err, "expected no error while parsing token")
+	id, _ := TokenSubjectID(claims)
+	assert.Equal(t, uint(7), id)
+	assert.InDelta(t, time.Now().Add(tokens.TTL()).Unix(), claims["exp"], 5, "expected the configured ttl")
+
+	// Tokens signed with another secret are rejected
+	_, err = NewTokenService("othersecretkey", time.Minute*5).Parse(token)
+	assert.Error(t, err, "expected error for a token signed with another secret")
+
+	// Tokens signed before a rotation are accepted while the secret is kept
+	tokens.Rotate(TokenKeys{Secret: "othersecretkey", PreviousSecrets: []string{"testsecretkey"}, TTL: time.Minute})
+	_, err = tokens.Parse(token)
+	assert.NoError(t, err, "expected a token signed with a previous secret to be accepted")
+	rotated, err := tokens.Issue(7)
+	assert.NoError(t, err, "expected no error while issuing token")
+	_, err = ParseToken(rotated, "othersecretkey")
+	assert.NoError(t, err, "expected new tokens to be signed with the new secret")
+	assert.Equal(t, time.Minute, tokens.TTL())
+
+	tokens.Rotate(TokenKeys{Secret: "othersecretkey", TTL: time.Minute})
+	_, err = tokens.Parse(token)
+	assert.Error(t, err, "expected error once the previous secret is dropped")
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B test secret "12345678901234567890" in base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// Test vectors from RFC 6238 appendix B, truncated to six digits
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, expected := range vectors {
+		code, err := TOTPCode(rfcTOTPSecret, TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err, "expected no error while computing code")
+		assert.Equal(t, expected, code, "expected RFC 6238 code at %d", unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := TOTPCode(rfcTOTPSecret, TOTPStep(now))
+
+	// The current code and the neighbouring steps are accepted
+	step, ok := ValidateTOTP(rfcTOTPSecret, code, now)
+	assert.True(t, ok, "expected current code to be valid")
+	assert.Equal(t, TOTPStep(now), step, "expected matching step")
+
+	_, ok = ValidateTOTP(rfcTOTPSecret, code, now.Add(30*time.Second))
+	assert.True(t, ok, "expected code from the previous step to be valid")
+
+	// Codes further away are rejected
+	_, ok = ValidateTOTP(rfcTOTPSecret, code, now.Add(5*time.Minute))
+	assert.False(t, ok, "expected old code to be rejected")
+	_, ok = ValidateTOTP(rfcTOTPSecret, "000000", now)
+	assert.False(t, ok, "expected wrong code to be rejected")
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err, "expected no error while generating secret")
+	assert.Len(t, secret, 32, "expected 160 bits encoded as 32 base32 characters")
+
+	_, err = TOTPCode(secret, 1)
+	assert.NoError(t, err, "expected generated secret to be usable")
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("RBAC", "john_doe", rfcTOTPSecret)
+
+	parsed, err := url.Parse(uri)
+	assert.NoError(t, err, "expected a valid uri")
+	assert.Equal(t, "otpauth", parsed.Scheme, "expected otpauth scheme")
+	assert.Equal(t, "totp", parsed.Host, "expected totp type")
+	assert.Equal(t, "/RBAC:john_doe", parsed.Path, "expected issuer and account label")
+	assert.Equal(t, rfcTOTPSecret, parsed.Query().Get("secret"), "expected secret parameter")
+	assert.Equal(t, "RBAC", parsed.Query().Get("issuer"), "expected issuer parameter")
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	assert.NoError(t, err, "expected no error while generating codes")
+	assert.Len(t, codes, 10, "expected ten codes")
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		assert.Len(t, code, 11, "expected xxxxx-xxxxx format")
+		assert.True(t, strings.Contains(code, "-"), "expected a separator")
+		assert.False(t, seen[code], "expected unique codes")
+		seen[code] = true
+	}
+}